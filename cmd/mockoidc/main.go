@@ -0,0 +1,31 @@
+// Command mockoidc est un fournisseur OpenID Connect minimal pour tester le SSO en local.
+//
+//	go run ./cmd/mockoidc -addr :9000
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=pitch-ia OIDC_CLIENT_SECRET=secret go run .
+//
+// La page d'autorisation permet de choisir l'identité (sub, email, nom) à renvoyer.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"pitch/internal/mockoidc"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "adresse d'écoute")
+	issuer := flag.String("issuer", "http://localhost:9000", "URL de l'émetteur (doit correspondre à OIDC_ISSUER)")
+	clientID := flag.String("client-id", "pitch-ia", "client_id accepté")
+	clientSecret := flag.String("client-secret", "secret", "client_secret accepté (vide pour un client public)")
+	flag.Parse()
+
+	s, err := mockoidc.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("génération de la clé: %v", err)
+	}
+
+	log.Printf("mock OIDC sur %s (émetteur %s, client %s)", *addr, *issuer, *clientID)
+	log.Fatal(http.ListenAndServe(*addr, s.Handler()))
+}
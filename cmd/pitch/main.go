@@ -0,0 +1,131 @@
+// Command pitch génère un pitch depuis la ligne de commande, sans serveur web.
+//
+//	go run ./cmd/pitch "Une application de covoiturage pour les zones rurales"
+//	go run ./cmd/pitch -file projet.txt -format markdown -o pitch.md
+//	echo "Une plateforme de microcrédit" | go run ./cmd/pitch -lang en -framework yc -format json
+//
+// La configuration (OPENAI_API_KEY, OPENAI_MODEL, DEMO_MODE...) est lue depuis l'environnement et .env,
+// comme pour le serveur.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"pitch/models"
+	"pitch/render"
+	"pitch/service"
+)
+
+func main() {
+	var (
+		model     = flag.String("model", "", "modèle OpenAI (par défaut OPENAI_MODEL)")
+		lang      = flag.String("lang", service.DefaultLanguage, "langue de rédaction : "+strings.Join(service.Languages(), ", "))
+		framework = flag.String("framework", service.DefaultFramework, "framework de pitch : "+strings.Join(service.Frameworks(), ", "))
+		format    = flag.String("format", "text", "format de sortie : "+strings.Join(formatNames(), ", "))
+		output    = flag.String("o", "", "fichier de sortie (sortie standard par défaut)")
+		file      = flag.String("file", "", "fichier contenant la description (« - » pour l'entrée standard)")
+		noCache   = flag.Bool("no-cache", false, "ignorer le cache et forcer une nouvelle génération")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage : pitch [options] [description]\n\nSans description ni -file, la description est lue sur l'entrée standard.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	_ = godotenv.Load(".env")
+	// Les crédits concernent les comptes du site web, pas l'usage local en ligne de commande
+	os.Unsetenv("CREDITS_ENABLED")
+
+	f, ok := render.Lookup(*format)
+	if !ok {
+		fail(fmt.Errorf("format inconnu %q (disponibles : %s)", *format, strings.Join(formatNames(), ", ")))
+	}
+
+	desc, err := readDescription(flag.Args(), *file)
+	if err != nil {
+		fail(err)
+	}
+	desc = strings.TrimSpace(desc)
+	if err := service.ValidateDescription(desc); err != nil {
+		fail(err)
+	}
+
+	opts := service.GenerateOptions{NoCache: *noCache, Model: *model, Language: *lang, Framework: *framework}
+	if err := opts.Validate(); err != nil {
+		fail(err)
+	}
+	if service.DemoModeEnabled() {
+		fmt.Fprintln(os.Stderr, "Mode démo : pitch généré à partir des modèles intégrés (définissez OPENAI_API_KEY pour utiliser l'IA).")
+	}
+
+	resp, err := service.Generate(context.Background(), desc, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrGenerationFailed) {
+			fail(errors.New(service.GenerationFailureMessage()))
+		}
+		fail(err)
+	}
+
+	p := &models.Pitch{Description: desc, Sections: resp, CreatedAt: time.Now().UTC()}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := f.Render(out, p); err != nil {
+		fail(err)
+	}
+}
+
+// formatNames retourne les noms des formats de sortie enregistrés
+func formatNames() []string {
+	var names []string
+	for _, f := range render.Formats() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// readDescription lit la description depuis les arguments, le fichier ou l'entrée standard
+func readDescription(args []string, file string) (string, error) {
+	if file != "" && len(args) > 0 {
+		return "", errors.New("indiquez la description en argument ou avec -file, pas les deux")
+	}
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+
+	r := io.Reader(os.Stdin)
+	if file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	} else if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Saisissez la description du projet puis Ctrl+D :")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, 64*1024))
+	return string(data), err
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "pitch:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// AdminKeysData est le modèle de la page d'administration des clés API
+type AdminKeysData struct {
+	Keys      []models.APIKey
+	Scopes    []string
+	NewKey    string // clé en clair, affichée une seule fois après création
+	NewKeyFor string
+	Error     string
+}
+
+// renderAdminKeys affiche la page d'administration des clés
+func renderAdminKeys(w http.ResponseWriter, data AdminKeysData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("AdminKeys.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.Keys = service.ListAPIKeys()
+	data.Scopes = service.AllScopes
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// AdminAPIKeys liste les clés API (GET /admin/api-keys)
+func AdminAPIKeys(w http.ResponseWriter, r *http.Request) {
+	renderAdminKeys(w, AdminKeysData{})
+}
+
+// AdminCreateAPIKey crée une clé API (POST /admin/api-keys)
+func AdminCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	quota, _ := strconv.Atoi(r.FormValue("daily_quota"))
+	raw, key, err := service.CreateAPIKey(r.FormValue("name"), r.Form["scopes"], quota)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		renderAdminKeys(w, AdminKeysData{Error: err.Error()})
+		return
+	}
+	renderAdminKeys(w, AdminKeysData{NewKey: raw, NewKeyFor: key.Name})
+}
+
+// AdminRevokeAPIKey révoque une clé API (POST /admin/api-keys/{id}/revoke)
+func AdminRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := service.RevokeAPIKey(r.PathValue("id")); err != nil {
+		http.Error(w, "Clé introuvable", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/admin/api-keys", http.StatusSeeOther)
+}
+
+// UsageData est le modèle du tableau de bord de consommation
+type UsageData struct {
+	Since    time.Time
+	Days     int
+	Total    models.UsageSummary
+	ByDay    []models.UsageSummary
+	ByUser   []models.UsageSummary
+	ByAPIKey []models.UsageSummary
+	Budget   float64
+	Prices   map[string]service.ModelPrice
+	Model    string
+}
+
+// AdminUsage affiche la consommation de tokens et les coûts estimés (GET /admin/usage?days=30)
+func AdminUsage(w http.ResponseWriter, r *http.Request) {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days <= 0 || days > 366 {
+		days = 30
+	}
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days+1)
+
+	data := UsageData{
+		Since:    since,
+		Days:     days,
+		ByDay:    service.AggregateUsage(since, "day"),
+		ByUser:   service.AggregateUsage(since, "user"),
+		ByAPIKey: service.AggregateUsage(since, "api_key"),
+		Prices:   service.PriceTable(),
+		Model:    service.OpenAIModel(),
+	}
+	data.Budget, _ = strconv.ParseFloat(os.Getenv("MONTHLY_BUDGET_USD"), 64)
+	for _, d := range data.ByDay {
+		data.Total.Generations += d.Generations
+		data.Total.PromptTokens += d.PromptTokens
+		data.Total.CompletionTokens += d.CompletionTokens
+		data.Total.CostUSD += d.CostUSD
+	}
+
+	tmpl, err := template.ParseFiles(getTemplatePath("Usage.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
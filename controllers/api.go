@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"pitch/models"
+	"pitch/service"
+	"pitch/store"
+)
+
+// APIPrefix est le préfixe des routes de l'API JSON versionnée
+const APIPrefix = "/api/v1"
+
+// APIRoute décrit une route de l'API ; la même table sert à enregistrer les handlers
+// et à générer le document OpenAPI, ce qui garantit qu'ils restent synchronisés.
+type APIRoute struct {
+	Method      string
+	Path        string // relatif à APIPrefix, au format des patterns net/http ({id})
+	OperationID string
+	Summary     string
+	Scope       string         // scope de clé API requis
+	Request     string         // nom du schéma du corps de la requête, ou types MIME d'un fichier (vide si aucun)
+	Responses   map[int]string // statut -> nom du schéma ou types MIME d'un fichier ("" pour une réponse vide)
+	Produces    []string       // autres types MIME de la réponse de succès, choisis par Accept ou ?format=
+	Handler     http.HandlerFunc
+}
+
+// Pattern retourne le pattern net/http de la route ("POST /api/v1/pitches")
+func (rt APIRoute) Pattern() string {
+	return rt.Method + " " + APIPrefix + rt.Path
+}
+
+// APIRoutes retourne la table des routes de l'API v1
+func APIRoutes() []APIRoute {
+	return []APIRoute{
+		{
+			Method: "POST", Path: "/pitches", OperationID: "createPitch",
+			Scope:     service.ScopePitchesWrite,
+			Summary:   "Génère et enregistre un pitch (202 et tâche à suivre sur /jobs/{id} si la file d'attente est occupée)",
+			Request:   "PitchRequest",
+			Responses: map[int]string{201: "Pitch", 202: "Job", 400: "Problem", 402: "Problem", 406: "Problem", 422: "Problem", 502: "Problem", 503: "Problem"},
+			Produces:  exportMediaTypes(),
+			Handler:   APICreatePitch,
+		},
+		{
+			Method: "GET", Path: "/pitches", OperationID: "listPitches",
+			Scope:     service.ScopePitchesRead,
+			Summary:   "Liste les pitchs créés avec la clé API",
+			Responses: map[int]string{200: "PitchList"},
+			Handler:   APIListPitches,
+		},
+		{
+			Method: "GET", Path: "/pitches/{id}", OperationID: "getPitch",
+			Scope:     service.ScopePitchesRead,
+			Summary:   "Retourne un pitch",
+			Responses: map[int]string{200: "Pitch", 400: "Problem", 404: "Problem", 406: "Problem"},
+			Produces:  exportMediaTypes(),
+			Handler:   APIGetPitch,
+		},
+		{
+			Method: "DELETE", Path: "/pitches/{id}", OperationID: "deletePitch",
+			Scope:     service.ScopePitchesDelete,
+			Summary:   "Supprime un pitch",
+			Responses: map[int]string{204: "", 404: "Problem"},
+			Handler:   APIDeletePitch,
+		},
+		{
+			Method: "POST", Path: "/jobs", OperationID: "createJob",
+			Scope:     service.ScopePitchesWrite,
+			Summary:   "Lance la génération d'un pitch en arrière-plan",
+			Request:   "JobRequest",
+			Responses: map[int]string{202: "Job", 400: "Problem", 422: "Problem", 503: "Problem"},
+			Handler:   APICreateJob,
+		},
+		{
+			Method: "GET", Path: "/jobs/{id}", OperationID: "getJob",
+			Scope:     service.ScopePitchesRead,
+			Summary:   "Retourne l'état et le résultat d'une génération en arrière-plan",
+			Responses: map[int]string{200: "Job", 404: "Problem"},
+			Handler:   APIGetJob,
+		},
+		{
+			Method: "POST", Path: "/batches", OperationID: "createBatch",
+			Scope:     service.ScopePitchesWrite,
+			Summary:   "Importe un lot de descriptions (CSV ou JSONL) et lance leur génération",
+			Request:   "text/csv,application/x-ndjson",
+			Responses: map[int]string{202: "Batch", 400: "Problem", 413: "Problem", 422: "Problem"},
+			Handler:   APICreateBatch,
+		},
+		{
+			Method: "GET", Path: "/batches/{id}", OperationID: "getBatch",
+			Scope:     service.ScopePitchesRead,
+			Summary:   "Retourne l'avancement d'un lot et l'état de chaque ligne",
+			Responses: map[int]string{200: "Batch", 404: "Problem"},
+			Handler:   APIGetBatch,
+		},
+		{
+			Method: "GET", Path: "/batches/{id}/results", OperationID: "getBatchResults",
+			Scope:     service.ScopePitchesRead,
+			Summary:   "Télécharge les résultats d'un lot terminé (?format=csv ou zip)",
+			Responses: map[int]string{200: "text/csv,application/zip", 404: "Problem", 409: "Problem"},
+			Handler:   APIBatchResults,
+		},
+	}
+}
+
+// PitchRequest est le corps de POST /api/v1/pitches
+type PitchRequest struct {
+	Description string `json:"description"`
+	// NoCache force une nouvelle génération (équivalent à l'en-tête Cache-Control: no-cache)
+	NoCache bool `json:"no_cache,omitempty"`
+}
+
+// JobRequest est le corps de POST /api/v1/jobs
+type JobRequest struct {
+	Description string `json:"description"`
+	NoCache     bool   `json:"no_cache,omitempty"`
+	// CallbackURL reçoit la tâche terminée en POST, signée par l'en-tête X-Pitch-Signature
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// PitchList est la réponse paginée de GET /api/v1/pitches
+type PitchList struct {
+	Items  []models.Pitch `json:"items"`
+	Total  int            `json:"total"`
+	Offset int            `json:"offset"`
+	Limit  int            `json:"limit"`
+}
+
+// APICreatePitch traite POST /api/v1/pitches
+func APICreatePitch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10240)
+
+	var req PitchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteProblem(w, r, http.StatusBadRequest, "Corps JSON invalide.")
+		return
+	}
+	// Vérifier le format de réponse avant de lancer (et facturer) la génération
+	f, download, err := pitchFormat(r, apiOffers())
+	if err != nil {
+		formatError(w, r, err)
+		return
+	}
+
+	noCache := req.NoCache || noCacheRequested(r)
+	if service.GenerationQueueBusy() {
+		// La génération attendrait dans la file : la confier à une tâche dont la position se suit par polling
+		job, err := service.CreateJob(r.Context(), req.Description, noCache, "")
+		if err != nil {
+			jobError(w, r, err)
+			return
+		}
+		w.Header().Set("Location", APIPrefix+"/jobs/"+job.ID)
+		w.Header().Set("Retry-After", jobPollInterval)
+		writeJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	p, err := service.CreatePitch(r.Context(), req.Description, service.GenerateOptions{NoCache: noCache})
+	if err != nil {
+		status, message := generationError(err)
+		if status == http.StatusInternalServerError {
+			// Échec du fournisseur IA en amont
+			status = http.StatusBadGateway
+		}
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", queueRetryAfter)
+		}
+		WriteProblem(w, r, status, message)
+		return
+	}
+
+	w.Header().Set("Location", APIPrefix+"/pitches/"+p.ID)
+	w.Header().Set("Vary", "Accept")
+	if err := exportPitch(w, r, http.StatusCreated, p, f, download); err != nil {
+		status, message := exportError(err)
+		WriteProblem(w, r, status, message)
+	}
+}
+
+// APIListPitches traite GET /api/v1/pitches?offset=&limit=
+func APIListPitches(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	items, total := service.ListPitches(r.Context(), offset, limit)
+	if items == nil {
+		items = []models.Pitch{}
+	}
+	writeJSON(w, http.StatusOK, PitchList{Items: items, Total: total, Offset: offset, Limit: limit})
+}
+
+// APIGetPitch traite GET /api/v1/pitches/{id}
+func APIGetPitch(w http.ResponseWriter, r *http.Request) {
+	f, download, err := pitchFormat(r, apiOffers())
+	if err != nil {
+		formatError(w, r, err)
+		return
+	}
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteProblem(w, r, http.StatusNotFound, "Pitch introuvable.")
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+	if err := exportPitch(w, r, http.StatusOK, p, f, download); err != nil {
+		status, message := exportError(err)
+		WriteProblem(w, r, status, message)
+	}
+}
+
+// APIDeletePitch traite DELETE /api/v1/pitches/{id}
+func APIDeletePitch(w http.ResponseWriter, r *http.Request) {
+	if err := service.DeletePitch(r.Context(), r.PathValue("id")); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			WriteProblem(w, r, http.StatusNotFound, "Pitch introuvable.")
+			return
+		}
+		WriteProblem(w, r, http.StatusInternalServerError, "Suppression impossible.")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// jobError répond à l'échec de création d'une tâche : file pleine (503) ou requête invalide (422)
+func jobError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrQueueFull) {
+		status, message := generationError(err)
+		w.Header().Set("Retry-After", queueRetryAfter)
+		WriteProblem(w, r, status, message)
+		return
+	}
+	WriteProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+}
+
+// APICreateJob traite POST /api/v1/jobs : la tâche est créée immédiatement (202) et s'exécute en arrière-plan
+func APICreateJob(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10240)
+
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteProblem(w, r, http.StatusBadRequest, "Corps JSON invalide.")
+		return
+	}
+
+	job, err := service.CreateJob(r.Context(), req.Description, req.NoCache || noCacheRequested(r), req.CallbackURL)
+	if err != nil {
+		jobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", APIPrefix+"/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// APIGetJob traite GET /api/v1/jobs/{id}
+func APIGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := service.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteProblem(w, r, http.StatusNotFound, "Tâche introuvable.")
+		return
+	}
+	if job.Status == models.JobQueued || job.Status == models.JobRunning {
+		w.Header().Set("Retry-After", jobPollInterval)
+	}
+	writeJSON(w, http.StatusOK, job)
+}
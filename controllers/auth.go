@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// SessionCookieName est le nom du cookie de session
+const SessionCookieName = "pitch_session"
+
+// AuthData est le modèle des pages de connexion, d'inscription et de mot de passe oublié
+type AuthData struct {
+	Mode    string // login, register, forgot, reset
+	Email   string
+	Name    string
+	Token   string
+	Error   string
+	Message string
+	User    *models.User
+
+	// Connexion SSO proposée si OIDC est configuré
+	OIDCEnabled bool
+	OIDCName    string
+}
+
+// isSecureRequest indique si le cookie doit être marqué Secure (HTTPS direct, derrière un proxy, ou forcé par COOKIE_SECURE)
+func isSecureRequest(r *http.Request) bool {
+	if os.Getenv("COOKIE_SECURE") == "true" {
+		return true
+	}
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// baseURL retourne l'URL publique du service (BASE_URL ou déduite de la requête) ;
+// les liens envoyés par email ou partagés utilisent service.PublicBaseURL, qui ne se fie pas à l'en-tête Host
+func baseURL(r *http.Request) string {
+	if u := os.Getenv("BASE_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	scheme := "http"
+	if isSecureRequest(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// setSessionCookie ouvre une session pour l'utilisateur et pose le cookie
+func setSessionCookie(w http.ResponseWriter, r *http.Request, userID string) error {
+	token, expires, err := service.CreateSession(userID)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// clearSessionCookie supprime le cookie de session
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// renderAuth affiche la page d'authentification
+func renderAuth(w http.ResponseWriter, r *http.Request, status int, data AuthData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Auth.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.User = service.UserFromContext(r.Context())
+	if cfg, ok := service.LoadOIDCConfig(); ok {
+		data.OIDCEnabled = true
+		data.OIDCName = cfg.ProviderName
+	}
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// LoginPage affiche le formulaire de connexion (GET /login)
+func LoginPage(w http.ResponseWriter, r *http.Request) {
+	renderAuth(w, r, http.StatusOK, AuthData{Mode: "login"})
+}
+
+// Login traite le formulaire de connexion (POST /login)
+func Login(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	email := r.FormValue("email")
+	u, err := service.AuthenticateUser(email, r.FormValue("password"))
+	if err != nil {
+		renderAuth(w, r, http.StatusUnauthorized, AuthData{Mode: "login", Email: email, Error: "Email ou mot de passe incorrect."})
+		return
+	}
+	if err := setSessionCookie(w, r, u.ID); err != nil {
+		http.Error(w, "Erreur interne du serveur", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/mes-pitchs", http.StatusSeeOther)
+}
+
+// RegisterPage affiche le formulaire d'inscription (GET /register)
+func RegisterPage(w http.ResponseWriter, r *http.Request) {
+	renderAuth(w, r, http.StatusOK, AuthData{Mode: "register"})
+}
+
+// Register crée un compte et connecte l'utilisateur (POST /register)
+func Register(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	email, name := r.FormValue("email"), r.FormValue("name")
+	u, err := service.RegisterUser(email, name, r.FormValue("password"))
+	if err != nil {
+		renderAuth(w, r, http.StatusUnprocessableEntity, AuthData{Mode: "register", Email: email, Name: name, Error: err.Error()})
+		return
+	}
+	if err := setSessionCookie(w, r, u.ID); err != nil {
+		http.Error(w, "Erreur interne du serveur", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// Logout ferme la session (POST /logout)
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(SessionCookieName); err == nil {
+		service.DeleteSession(c.Value)
+	}
+	clearSessionCookie(w, r)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// ForgotPasswordPage affiche le formulaire de mot de passe oublié (GET /forgot-password)
+func ForgotPasswordPage(w http.ResponseWriter, r *http.Request) {
+	renderAuth(w, r, http.StatusOK, AuthData{Mode: "forgot"})
+}
+
+// ForgotPassword envoie le lien de réinitialisation (POST /forgot-password)
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	err := service.RequestPasswordReset(r.FormValue("email"), service.DefaultMailer())
+	if errors.Is(err, service.ErrNoBaseURL) {
+		log.Printf("mot de passe oublié: %v", err)
+		renderAuth(w, r, http.StatusServiceUnavailable, AuthData{Mode: "forgot", Error: "La réinitialisation par email n'est pas disponible pour le moment."})
+		return
+	}
+	if err != nil {
+		renderAuth(w, r, http.StatusInternalServerError, AuthData{Mode: "forgot", Error: "L'email n'a pas pu être envoyé, veuillez réessayer."})
+		return
+	}
+	renderAuth(w, r, http.StatusOK, AuthData{Mode: "forgot", Message: "Si un compte existe pour cette adresse, un lien de réinitialisation vient d'être envoyé."})
+}
+
+// ResetPasswordPage affiche le formulaire de nouveau mot de passe (GET /reset-password?token=)
+func ResetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	renderAuth(w, r, http.StatusOK, AuthData{Mode: "reset", Token: r.URL.Query().Get("token")})
+}
+
+// ResetPassword enregistre le nouveau mot de passe (POST /reset-password)
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	token := r.FormValue("token")
+	if err := service.ResetPassword(token, r.FormValue("password")); err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			status = http.StatusBadRequest
+		}
+		renderAuth(w, r, status, AuthData{Mode: "reset", Token: token, Error: err.Error()})
+		return
+	}
+	renderAuth(w, r, http.StatusOK, AuthData{Mode: "login", Message: "Mot de passe modifié, vous pouvez vous connecter."})
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"mime"
+	"net/http"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// BatchData est le modèle de la page « Génération par lot »
+type BatchData struct {
+	User    *models.User
+	Batch   *models.Batch
+	MaxRows int
+	Error   string
+}
+
+// Finished indique si le lot est terminé (les résultats sont téléchargeables)
+func (d BatchData) Finished() bool {
+	return d.Batch != nil && d.Batch.FinishedAt != nil
+}
+
+// Progress retourne le pourcentage de lignes traitées
+func (d BatchData) Progress() int {
+	if d.Batch == nil || d.Batch.Total == 0 {
+		return 0
+	}
+	return d.Batch.Done * 100 / d.Batch.Total
+}
+
+func renderBatch(w http.ResponseWriter, r *http.Request, status int, data BatchData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Batch.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.User = service.UserFromContext(r.Context())
+	data.MaxRows = service.BatchMaxRows()
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// readBatchUpload lit les lignes d'un lot envoyé en multipart (champ « file ») ou directement dans le corps
+func readBatchUpload(w http.ResponseWriter, r *http.Request) ([]models.BatchRow, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxBatchSize+64*1024)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return service.ParseBatch(r.Body, service.BatchFormat("", mediaType))
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, service.ErrBatchTooLarge
+		}
+		return nil, errors.New("veuillez joindre un fichier CSV ou JSONL")
+	}
+	defer file.Close()
+	return service.ParseBatch(file, service.BatchFormat(header.Filename, header.Header.Get("Content-Type")))
+}
+
+// writeBatchResults envoie les résultats du lot en pièce jointe CSV ou ZIP
+func writeBatchResults(w http.ResponseWriter, r *http.Request, b *models.Batch) {
+	format := r.URL.Query().Get("format")
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="lot-`+b.ID+`.zip"`)
+		service.WriteBatchZIP(r.Context(), w, b)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="lot-`+b.ID+`.csv"`)
+	// BOM pour qu'Excel reconnaisse l'UTF-8
+	w.Write([]byte("\xef\xbb\xbf"))
+	service.WriteBatchCSV(r.Context(), w, b)
+}
+
+// BatchPage affiche le formulaire d'import (GET /lots)
+func BatchPage(w http.ResponseWriter, r *http.Request) {
+	renderBatch(w, r, http.StatusOK, BatchData{})
+}
+
+// CreateBatch importe le fichier et lance la génération du lot (POST /lots)
+func CreateBatch(w http.ResponseWriter, r *http.Request) {
+	rows, err := readBatchUpload(w, r)
+	if err != nil {
+		renderBatch(w, r, http.StatusUnprocessableEntity, BatchData{Error: err.Error()})
+		return
+	}
+	b, err := service.CreateBatch(r.Context(), rows)
+	if err != nil {
+		renderBatch(w, r, http.StatusInternalServerError, BatchData{Error: "Impossible d'enregistrer le lot."})
+		return
+	}
+	http.Redirect(w, r, "/lots/"+b.ID, http.StatusSeeOther)
+}
+
+// ShowBatch affiche l'avancement d'un lot (GET /lots/{id}) ; la page se rafraîchit tant qu'il n'est pas terminé
+func ShowBatch(w http.ResponseWriter, r *http.Request) {
+	b, err := service.GetBatch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	renderBatch(w, r, http.StatusOK, BatchData{Batch: b})
+}
+
+// BatchResults télécharge les résultats d'un lot (GET /lots/{id}/resultats?format=csv|zip)
+func BatchResults(w http.ResponseWriter, r *http.Request) {
+	b, err := service.GetBatch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeBatchResults(w, r, b)
+}
+
+// APICreateBatch traite POST /api/v1/batches (corps text/csv, application/x-ndjson ou multipart)
+func APICreateBatch(w http.ResponseWriter, r *http.Request) {
+	rows, err := readBatchUpload(w, r)
+	if err != nil {
+		if errors.Is(err, service.ErrBatchTooLarge) {
+			WriteProblem(w, r, http.StatusRequestEntityTooLarge, "Le fichier dépasse la taille maximale de 1 Mo.")
+			return
+		}
+		WriteProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	b, err := service.CreateBatch(r.Context(), rows)
+	if err != nil {
+		WriteProblem(w, r, http.StatusInternalServerError, "Impossible d'enregistrer le lot.")
+		return
+	}
+
+	w.Header().Set("Location", APIPrefix+"/batches/"+b.ID)
+	writeJSON(w, http.StatusAccepted, b)
+}
+
+// APIGetBatch traite GET /api/v1/batches/{id}
+func APIGetBatch(w http.ResponseWriter, r *http.Request) {
+	b, err := service.GetBatch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteProblem(w, r, http.StatusNotFound, "Lot introuvable.")
+		return
+	}
+	if b.FinishedAt == nil {
+		w.Header().Set("Retry-After", "5")
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// APIBatchResults traite GET /api/v1/batches/{id}/results?format=csv|zip
+func APIBatchResults(w http.ResponseWriter, r *http.Request) {
+	b, err := service.GetBatch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteProblem(w, r, http.StatusNotFound, "Lot introuvable.")
+		return
+	}
+	if b.FinishedAt == nil {
+		w.Header().Set("Retry-After", "5")
+		WriteProblem(w, r, http.StatusConflict, "Le lot est encore en cours de génération.")
+		return
+	}
+	writeBatchResults(w, r, b)
+}
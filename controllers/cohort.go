@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// CohortData est le modèle du tableau de bord d'une cohorte
+type CohortData struct {
+	User      *models.User
+	Org       *models.Organization
+	Role      string
+	Dashboard *models.CohortDashboard
+	Pitches   []models.Pitch // pitchs de l'organisation à rattacher aux équipes (propriétaires)
+	Error     string
+}
+
+// IsOwner indique si l'utilisateur gère la cohorte (propriétaire de l'organisation)
+func (d CohortData) IsOwner() bool { return d.Role == models.OrgRoleOwner }
+
+// cohortErrorStatus convertit une erreur du service des cohortes en statut HTTP
+func cohortErrorStatus(err error) int {
+	if errors.Is(err, service.ErrCohortPitch) {
+		return http.StatusUnprocessableEntity
+	}
+	return orgErrorStatus(err)
+}
+
+// showCohort affiche le tableau de bord de la cohorte de la requête
+func showCohort(w http.ResponseWriter, r *http.Request, status int, data CohortData) {
+	d, role, err := service.GetCohortDashboard(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), cohortErrorStatus(err))
+		return
+	}
+	tmpl, err := template.ParseFiles(getTemplatePath("Cohort.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.User = service.UserFromContext(r.Context())
+	data.Dashboard, data.Role = d, role
+	data.Org, _, _ = service.GetOrganization(r.Context(), d.Cohort.OrgID)
+	if role == models.OrgRoleOwner {
+		data.Pitches, _ = service.ListPitches(service.WithWorkspace(r.Context(), d.Cohort.OrgID), 0, 0)
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// cohortAction exécute une action des propriétaires sur la cohorte puis revient au tableau de bord
+func cohortAction(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		showCohort(w, r, cohortErrorStatus(err), CohortData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/cohortes/"+r.PathValue("id")+"#equipes", http.StatusSeeOther)
+}
+
+// CreateCohort crée une cohorte dans l'organisation (POST /organisations/{id}/cohortes)
+func CreateCohort(w http.ResponseWriter, r *http.Request) {
+	c, err := service.CreateCohort(r.Context(), r.PathValue("id"), r.FormValue("name"))
+	if err != nil {
+		showOrganization(w, r, orgErrorStatus(err), OrganizationsData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/cohortes/"+c.ID, http.StatusSeeOther)
+}
+
+// CohortPage affiche le tableau de bord d'une cohorte (GET /cohortes/{id})
+func CohortPage(w http.ResponseWriter, r *http.Request) {
+	showCohort(w, r, http.StatusOK, CohortData{})
+}
+
+// CohortExport télécharge le tableau de bord de la cohorte en CSV (GET /cohortes/{id}/export.csv)
+func CohortExport(w http.ResponseWriter, r *http.Request) {
+	d, _, err := service.GetCohortDashboard(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), cohortErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="cohorte-`+d.Cohort.ID+`.csv"`)
+	// BOM pour qu'Excel reconnaisse l'UTF-8
+	w.Write([]byte("\xef\xbb\xbf"))
+	service.WriteCohortCSV(w, d)
+}
+
+// DeleteCohort supprime une cohorte (POST /cohortes/{id}/supprimer)
+func DeleteCohort(w http.ResponseWriter, r *http.Request) {
+	c, err := service.DeleteCohort(r.Context(), r.PathValue("id"))
+	if err != nil {
+		showCohort(w, r, cohortErrorStatus(err), CohortData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/organisations/"+c.OrgID+"#cohortes", http.StatusSeeOther)
+}
+
+// AddCohortTeam ajoute une équipe (POST /cohortes/{id}/equipes)
+func AddCohortTeam(w http.ResponseWriter, r *http.Request) {
+	_, err := service.AddCohortTeam(r.Context(), r.PathValue("id"), r.FormValue("name"))
+	cohortAction(w, r, err)
+}
+
+// RemoveCohortTeam retire une équipe (POST /cohortes/{id}/equipes/{team}/retirer)
+func RemoveCohortTeam(w http.ResponseWriter, r *http.Request) {
+	cohortAction(w, r, service.RemoveCohortTeam(r.Context(), r.PathValue("id"), r.PathValue("team")))
+}
+
+// AttachTeamPitch rattache un pitch de l'organisation à une équipe (POST /cohortes/{id}/equipes/{team}/pitchs)
+func AttachTeamPitch(w http.ResponseWriter, r *http.Request) {
+	cohortAction(w, r, service.AttachTeamPitch(r.Context(), r.PathValue("id"), r.PathValue("team"), r.FormValue("pitch")))
+}
+
+// DetachTeamPitch retire un pitch d'une équipe (POST /cohortes/{id}/equipes/{team}/pitchs/{pitch}/retirer)
+func DetachTeamPitch(w http.ResponseWriter, r *http.Request) {
+	cohortAction(w, r, service.DetachTeamPitch(r.Context(), r.PathValue("id"), r.PathValue("team"), r.PathValue("pitch")))
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"pitch/models"
+	"pitch/service"
+	"pitch/websocket"
+)
+
+// Délais de la connexion WebSocket de co-édition : un ping part toutes les collabPingInterval
+// et la connexion est fermée sans nouvelle du navigateur pendant collabIdleTimeout
+const (
+	collabPingInterval = 25 * time.Second
+	collabIdleTimeout  = 60 * time.Second
+)
+
+// EditorData est le modèle de la page de co-édition d'un pitch
+type EditorData struct {
+	User       *models.User
+	Pitch      *models.Pitch
+	SocketPath string // point de connexion WebSocket
+	BackURL    string
+	Guest      bool // co-fondateur venu par un lien de partage : il choisit le nom affiché
+	Error      string
+}
+
+func renderEditor(w http.ResponseWriter, status int, data EditorData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Editor.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if data.Guest {
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// EditPitch affiche la co-édition du pitch à son propriétaire (GET /pitches/{id}/editer)
+func EditPitch(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	renderEditor(w, http.StatusOK, EditorData{
+		User:       service.UserFromContext(r.Context()),
+		Pitch:      p,
+		SocketPath: "/pitches/" + p.ID + "/collab",
+		BackURL:    "/pitches/" + p.ID,
+	})
+}
+
+// CollabPitch est la connexion WebSocket de co-édition du propriétaire (GET /pitches/{id}/collab)
+func CollabPitch(w http.ResponseWriter, r *http.Request) {
+	c, err := service.JoinPitchCollab(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), shareErrorStatus(err))
+		return
+	}
+	serveCollab(w, r, c)
+}
+
+// EditShared affiche la co-édition à un co-fondateur venu par un lien de partage (GET /partage/{token}/editer)
+func EditShared(w http.ResponseWriter, r *http.Request) {
+	link, ok := openShare(w, r)
+	if !ok {
+		return
+	}
+	token := r.PathValue("token")
+	if !shareUnlocked(r, link) {
+		renderShared(w, http.StatusUnauthorized, SharedData{Token: token, Link: link, Locked: true})
+		return
+	}
+	if !link.CanEdit() {
+		showShared(w, r, http.StatusForbidden, link, SharedData{Error: service.ErrCollabForbidden.Error()})
+		return
+	}
+	p, err := service.ViewSharedPitch(link)
+	if err != nil {
+		renderShared(w, http.StatusNotFound, SharedData{Error: service.ErrShareInvalid.Error()})
+		return
+	}
+	renderEditor(w, http.StatusOK, EditorData{
+		Pitch:      p,
+		SocketPath: "/partage/" + token + "/collab",
+		BackURL:    "/partage/" + token,
+		Guest:      true,
+	})
+}
+
+// CollabShare est la connexion WebSocket de co-édition d'un lien de partage (GET /partage/{token}/collab?nom=)
+func CollabShare(w http.ResponseWriter, r *http.Request) {
+	link, err := service.OpenShareLink(r.PathValue("token"))
+	if err != nil {
+		http.Error(w, err.Error(), shareErrorStatus(err))
+		return
+	}
+	if !shareUnlocked(r, link) {
+		http.Error(w, service.ErrSharePassword.Error(), http.StatusUnauthorized)
+		return
+	}
+	c, err := service.JoinShareCollab(link, r.URL.Query().Get("nom"))
+	if err != nil {
+		http.Error(w, err.Error(), shareErrorStatus(err))
+		return
+	}
+	serveCollab(w, r, c)
+}
+
+// serveCollab bascule la requête en WebSocket puis relaie les messages entre le navigateur et la salle de co-édition
+func serveCollab(w http.ResponseWriter, r *http.Request, c *service.CollabClient) {
+	defer c.Leave()
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(4*service.MaxSectionLength + 1024)
+	conn.SetIdleTimeout(collabIdleTimeout)
+
+	// Écriture : messages de la salle et pings de maintien de connexion
+	go func() {
+		ticker := time.NewTicker(collabPingInterval)
+		defer ticker.Stop()
+		defer conn.Close()
+		for {
+			select {
+			case msg, ok := <-c.Outbox():
+				if !ok {
+					return
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.Ping(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// Lecture : jusqu'à la fermeture par le navigateur ou une erreur réseau
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.Handle(data)
+	}
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// CreditsData est le modèle de la page « Mes crédits »
+type CreditsData struct {
+	User    *models.User
+	Enabled bool
+	CanBuy  bool // un prestataire de paiement est configuré
+	Balance int
+	Plan    models.Plan
+	Plans   []models.Plan
+	Packs   []models.CreditPack
+	History []models.CreditEntry
+	Error   string
+	Message string
+}
+
+// userCredits retourne le solde de l'utilisateur connecté si les crédits sont activés
+func userCredits(r *http.Request) *int {
+	u := service.UserFromContext(r.Context())
+	if u == nil || !service.CreditsEnabled() {
+		return nil
+	}
+	balance, err := service.CreditBalance(u)
+	if err != nil {
+		return nil
+	}
+	return &balance
+}
+
+func renderCredits(w http.ResponseWriter, r *http.Request, status int, data CreditsData) {
+	tmpl, err := template.New("Credits.html").Funcs(template.FuncMap{
+		"divCents": func(cents int) float64 { return float64(cents) / 100 },
+	}).ParseFiles(getTemplatePath("Credits.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+
+	u := service.UserFromContext(r.Context())
+	data.User = u
+	data.Enabled = service.CreditsEnabled()
+	data.CanBuy = service.PaymentsEnabled()
+	data.Plan = service.UserPlan(u)
+	data.Plans = service.Plans()
+	data.Packs = service.CreditPacks()
+	data.History = service.CreditHistory(u)
+	if balance, err := service.CreditBalance(u); err == nil {
+		data.Balance = balance
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// Credits affiche le solde, le plan et l'historique des crédits (GET /credits)
+func Credits(w http.ResponseWriter, r *http.Request) {
+	renderCredits(w, r, http.StatusOK, CreditsData{})
+}
+
+// BuyCredits démarre l'achat d'un lot de crédits ou d'un plan (POST /credits/buy)
+func BuyCredits(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	u := service.UserFromContext(r.Context())
+	checkoutURL, err := service.StartPurchase(r.Context(), u, r.FormValue("item"), baseURL(r)+"/credits/complete")
+	if errors.Is(err, service.ErrPaymentsDisabled) {
+		renderCredits(w, r, http.StatusServiceUnavailable, CreditsData{Error: "Le paiement en ligne n'est pas disponible pour le moment."})
+		return
+	}
+	if err != nil {
+		renderCredits(w, r, http.StatusUnprocessableEntity, CreditsData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, checkoutURL, http.StatusSeeOther)
+}
+
+// CompleteCredits finalise un achat au retour du prestataire de paiement (GET /credits/complete?order=)
+func CompleteCredits(w http.ResponseWriter, r *http.Request) {
+	u := service.UserFromContext(r.Context())
+	if err := service.CompletePurchase(r.Context(), u, r.URL.Query().Get("order")); err != nil {
+		log.Printf("credits: %v", err)
+		renderCredits(w, r, http.StatusPaymentRequired, CreditsData{Error: "Le paiement n'a pas été finalisé."})
+		return
+	}
+	renderCredits(w, r, http.StatusOK, CreditsData{Message: "Merci ! Votre achat a bien été pris en compte."})
+}
+
+// fakeCheckoutPage est la page de paiement du prestataire factice
+var fakeCheckoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="fr"><head><meta charset="UTF-8"><title>Paiement (simulation)</title><script src="https://cdn.tailwindcss.com"></script></head>
+<body class="bg-gray-100 min-h-screen flex items-center justify-center p-4">
+<div class="bg-white rounded-2xl shadow-xl p-8 max-w-md w-full text-center">
+<p class="text-xs uppercase text-yellow-600 mb-2">Prestataire de paiement simulé</p>
+<h1 class="text-xl font-bold mb-2">{{.Description}}</h1>
+<p class="text-3xl font-bold mb-6">{{printf "%.2f" .Amount}} €</p>
+<form method="POST"><button class="w-full bg-blue-600 hover:bg-blue-700 text-white px-6 py-3 rounded-xl">Payer</button></form>
+<a href="/credits" class="block mt-4 text-sm text-gray-500 hover:underline">Annuler</a>
+</div></body></html>`))
+
+// FakeCheckout affiche (GET) ou valide (POST) le paiement simulé /credits/fake-checkout/{id}
+func FakeCheckout(w http.ResponseWriter, r *http.Request) {
+	fake, ok := service.FakePayments()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	order, ok := fake.Order(r.PathValue("id"))
+	if !ok || order.UserID != service.UserFromContext(r.Context()).ID {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		fake.Pay(order.ID)
+		http.Redirect(w, r, "/credits/complete?order="+order.ID, http.StatusSeeOther)
+		return
+	}
+	fakeCheckoutPage.Execute(w, map[string]any{
+		"Description": order.Description,
+		"Amount":      float64(order.AmountCents) / 100,
+	})
+}
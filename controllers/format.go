@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pitch/models"
+	"pitch/render"
+	"pitch/service"
+)
+
+var (
+	errUnknownFormat = errors.New("format inconnu")
+	errNotAcceptable = errors.New("aucun format acceptable")
+)
+
+// htmlOffers sont les types proposés par les pages web : HTML d'abord, puis les formats d'export
+func htmlOffers() []string {
+	return append([]string{"text/html"}, render.MediaTypes()...)
+}
+
+// apiOffers sont les types proposés par l'API : JSON d'abord, puis les autres formats d'export
+func apiOffers() []string {
+	offers := []string{"application/json"}
+	for _, mt := range render.MediaTypes() {
+		if mt != "application/json" {
+			offers = append(offers, mt)
+		}
+	}
+	return offers
+}
+
+// exportMediaTypes sont les types MIME d'export autres que JSON, documentés dans OpenAPI
+func exportMediaTypes() []string {
+	var types []string
+	seen := map[string]bool{"application/json": true}
+	for _, f := range render.Formats() {
+		if !seen[f.MediaTypes[0]] {
+			seen[f.MediaTypes[0]] = true
+			types = append(types, f.MediaTypes[0])
+		}
+	}
+	return types
+}
+
+// pitchFormat détermine le format de réponse d'un pitch : ?format= (téléchargement) ou négociation
+// sur l'en-tête Accept parmi offers. Un Format vide (Name == "") signifie la page HTML.
+func pitchFormat(r *http.Request, offers []string) (f render.Format, download bool, err error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		f, ok := render.Lookup(name)
+		if !ok {
+			return f, false, errUnknownFormat
+		}
+		return f, true, nil
+	}
+
+	mediaType := render.Negotiate(r.Header.Get("Accept"), offers)
+	if mediaType == "" {
+		return f, false, errNotAcceptable
+	}
+	f, _ = render.ByMediaType(mediaType)
+	return f, false, nil
+}
+
+// formatError écrit l'erreur de pitchFormat en problème JSON (400 ou 406)
+func formatError(w http.ResponseWriter, r *http.Request, err error) {
+	names := formatNames()
+	if errors.Is(err, errUnknownFormat) {
+		WriteProblem(w, r, http.StatusBadRequest, fmt.Sprintf("Format inconnu. Formats disponibles : %s.", strings.Join(names, ", ")))
+		return
+	}
+	WriteProblem(w, r, http.StatusNotAcceptable, fmt.Sprintf("Aucun des types demandés n'est disponible. Formats disponibles : %s.", strings.Join(names, ", ")))
+}
+
+// chargeExport débite CreditCostExport pour les formats facturés (documents Word et OpenDocument)
+// et retourne la fonction de remboursement à appeler si le rendu échoue
+func chargeExport(r *http.Request, f render.Format) (refund func(), err error) {
+	if !f.Billable {
+		return func() {}, nil
+	}
+	return service.SpendCredits(r.Context(), service.CreditCostExport, "Export "+f.Label)
+}
+
+// exportError convertit une erreur de chargeExport en statut HTTP et message pour l'utilisateur
+func exportError(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrOutOfCredits):
+		return http.StatusPaymentRequired, "⚠️ Vous n'avez plus de crédits pour exporter ce document. Rechargez votre compte ou passez au plan Premium."
+	case errors.Is(err, service.ErrAccountRequired):
+		return http.StatusUnauthorized, "⚠️ Connectez-vous ou créez un compte gratuit pour télécharger ce document."
+	}
+	return http.StatusInternalServerError, "⚠️ Export impossible pour le moment."
+}
+
+// writePitch écrit le pitch dans le format f ; en téléchargement, le fichier est proposé en pièce jointe.
+// Le rendu est fait en mémoire : en cas d'erreur rien n'est écrit et l'appelant peut répondre autrement.
+// Les formats facturés doivent avoir été débités avec chargeExport.
+func writePitch(w http.ResponseWriter, status int, p *models.Pitch, f render.Format, download bool) error {
+	var buf bytes.Buffer
+	if err := f.Render(&buf, p); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", f.ContentType())
+	if download {
+		name := "pitch"
+		if p.ID != "" {
+			name += "-" + p.ID
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+f.Extension+`"`)
+	}
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// exportPitch débite l'export si le format est facturé puis écrit le pitch ; le débit est remboursé si le rendu échoue
+func exportPitch(w http.ResponseWriter, r *http.Request, status int, p *models.Pitch, f render.Format, download bool) error {
+	refund, err := chargeExport(r, f)
+	if err != nil {
+		return err
+	}
+	if err := writePitch(w, status, p, f, download); err != nil {
+		refund()
+		return err
+	}
+	return nil
+}
+
+// formatNames retourne les noms des formats d'export enregistrés
+func formatNames() []string {
+	var names []string
+	for _, f := range render.Formats() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// pitchDownloads retourne les liens de téléchargement du pitch dans chaque format enregistré
+func pitchDownloads(id string) []models.Download {
+	var links []models.Download
+	for _, f := range render.Formats() {
+		links = append(links, models.Download{Label: f.Label, Href: "/pitches/" + id + "?format=" + f.Name, Billable: f.Billable})
+	}
+	return links
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"pitch/models"
+	"pitch/service"
+	"pitch/store"
+)
+
+// JuryData est le modèle des pages du jury simulé
+type JuryData struct {
+	User   *models.User
+	Pitch  *models.Pitch
+	Judges []models.JudgePersona
+	Rubric []models.RubricCriterion
+	Runs   []models.JuryRun
+	CanRun bool // auteurs et mentors du pitch
+	Report *models.JuryReport
+	Error  string
+}
+
+// juryErrorStatus convertit une erreur du jury simulé en statut HTTP et message pour l'utilisateur
+func juryErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound, "Pitch introuvable."
+	case errors.Is(err, service.ErrOrgForbidden):
+		return http.StatusForbidden, "Seuls les auteurs et les mentors du pitch peuvent le présenter au jury."
+	case errors.Is(err, service.ErrUnknownJudge), errors.Is(err, service.ErrTooManyJudges):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, service.ErrOutOfCredits):
+		return http.StatusPaymentRequired, "⚠️ Vous n'avez pas assez de crédits : chaque juré coûte un crédit. Rechargez votre compte ou choisissez moins de jurés."
+	}
+	return generationError(err)
+}
+
+func renderJury(w http.ResponseWriter, r *http.Request, file string, status int, data JuryData) {
+	tmpl, err := template.ParseFiles(getTemplatePath(file))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.User = service.UserFromContext(r.Context())
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// showJury affiche les jurés, la grille et les passages précédents du pitch de la requête
+func showJury(w http.ResponseWriter, r *http.Request, status int, data JuryData) {
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data.Pitch = p
+	data.Judges = service.JudgePanel()
+	data.Rubric = service.CompetitionRubric()
+	data.Runs, _ = service.ListJuryRuns(r.Context(), p.ID)
+	_, data.CanRun = service.PitchActor(r.Context(), p)
+	renderJury(w, r, "Jury.html", status, data)
+}
+
+// JuryPage présente le jury simulé d'un pitch (GET /pitches/{id}/jury)
+func JuryPage(w http.ResponseWriter, r *http.Request) {
+	showJury(w, r, http.StatusOK, JuryData{})
+}
+
+// StartJury fait passer le pitch devant les jurés cochés (POST /pitches/{id}/jury)
+func StartJury(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	run, err := service.CreateJuryRun(r.Context(), r.PathValue("id"), r.Form["judges"])
+	if err != nil {
+		status, message := juryErrorStatus(err)
+		showJury(w, r, status, JuryData{Error: message})
+		return
+	}
+	http.Redirect(w, r, "/jury/"+run.ID, http.StatusSeeOther)
+}
+
+// JuryReportPage affiche le rapport d'un passage devant le jury (GET /jury/{id}) ;
+// la page se rafraîchit tant que des jurés délibèrent
+func JuryReportPage(w http.ResponseWriter, r *http.Request) {
+	run, err := service.GetJuryRun(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	p, _ := service.GetPitch(r.Context(), run.PitchID)
+	renderJury(w, r, "JuryReport.html", http.StatusOK, JuryData{Pitch: p, Report: service.BuildJuryReport(run)})
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"pitch/service"
+)
+
+// oidcStateCookie lie la tentative de connexion SSO au navigateur qui l'a initiée
+const oidcStateCookie = "pitch_oidc_state"
+
+// oidcConfig retourne la configuration OIDC, avec l'URL de retour déduite de la requête si besoin
+func oidcConfig(r *http.Request) (service.OIDCConfig, bool) {
+	cfg, ok := service.LoadOIDCConfig()
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = baseURL(r) + "/auth/oidc/callback"
+	}
+	return cfg, ok
+}
+
+// OIDCLogin redirige vers le fournisseur d'identité (GET /auth/oidc/login)
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := oidcConfig(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	authURL, state, err := service.OIDCAuthURL(r.Context(), cfg)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		renderAuth(w, r, http.StatusBadGateway, AuthData{Mode: "login", Error: "Le fournisseur d'identité est injoignable."})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/auth/oidc",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback termine la connexion SSO (GET /auth/oidc/callback)
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := oidcConfig(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		renderAuth(w, r, http.StatusUnauthorized, AuthData{Mode: "login", Error: "Connexion refusée par le fournisseur d'identité (" + e + ")."})
+		return
+	}
+
+	state := q.Get("state")
+	c, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(c.Value), []byte(state)) != 1 {
+		renderAuth(w, r, http.StatusBadRequest, AuthData{Mode: "login", Error: "Session de connexion expirée, veuillez réessayer."})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc", MaxAge: -1})
+
+	u, err := service.OIDCCallback(r.Context(), cfg, state, q.Get("code"))
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		renderAuth(w, r, http.StatusUnauthorized, AuthData{Mode: "login", Error: "La connexion via le fournisseur d'identité a échoué."})
+		return
+	}
+
+	if err := setSessionCookie(w, r, u.ID); err != nil {
+		http.Error(w, "Erreur interne du serveur", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/mes-pitchs", http.StatusSeeOther)
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"pitch/internal/mockoidc"
+	"pitch/models"
+	"pitch/service"
+)
+
+// TestMain se place à la racine du dépôt pour que les handlers trouvent les templates de views/
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// oidcFlow pilote une connexion SSO contre le fournisseur mockoidc
+type oidcFlow struct {
+	t        *testing.T
+	provider *httptest.Server
+	client   *http.Client // ne suit pas les redirections
+}
+
+// newOIDCFlow démarre mockoidc et configure le SSO sur lui
+func newOIDCFlow(t *testing.T) *oidcFlow {
+	t.Helper()
+	var handler http.Handler
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { handler.ServeHTTP(w, r) }))
+	t.Cleanup(provider.Close)
+	mock, err := mockoidc.New(provider.URL, "pitch-ia", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = mock.Handler()
+
+	t.Setenv("OIDC_ISSUER", provider.URL)
+	t.Setenv("OIDC_CLIENT_ID", "pitch-ia")
+	t.Setenv("OIDC_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_REDIRECT_URL", "http://pitch.test/auth/oidc/callback")
+	return &oidcFlow{t: t, provider: provider, client: &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+// login appelle GET /auth/oidc/login et retourne l'URL d'autorisation et le cookie de state
+func (f *oidcFlow) login() (string, *http.Cookie) {
+	f.t.Helper()
+	w := httptest.NewRecorder()
+	OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		f.t.Fatalf("login : statut %d, veut 302", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oidcStateCookie {
+		f.t.Fatalf("login : cookie de state absent (%v)", cookies)
+	}
+	return w.Header().Get("Location"), cookies[0]
+}
+
+// authorize valide la page d'autorisation du fournisseur et retourne l'URL de retour (code et state)
+func (f *oidcFlow) authorize(authURL string, identity url.Values) *url.URL {
+	f.t.Helper()
+	resp, err := f.client.PostForm(authURL, identity)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		f.t.Fatalf("autorisation : statut %d, veut 302", resp.StatusCode)
+	}
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return callback
+}
+
+// callback appelle GET /auth/oidc/callback avec le cookie de state éventuel
+func (f *oidcFlow) callback(callback *url.URL, cookie *http.Cookie) *httptest.ResponseRecorder {
+	f.t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+callback.RawQuery, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	OIDCCallback(w, r)
+	return w
+}
+
+// sessionUser retourne l'utilisateur de la session ouverte par la réponse
+func sessionUser(t *testing.T, w *httptest.ResponseRecorder) *models.User {
+	t.Helper()
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/mes-pitchs" {
+		t.Fatalf("callback : statut %d vers %q, veut 303 vers /mes-pitchs\n%s", w.Code, w.Header().Get("Location"), w.Body.String())
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == SessionCookieName {
+			if u, ok := service.SessionUser(c.Value); ok {
+				return u
+			}
+		}
+	}
+	t.Fatal("callback : aucune session ouverte")
+	return nil
+}
+
+func identity(sub, email string) url.Values {
+	return url.Values{"sub": {sub}, "email": {email}, "name": {"Camille " + sub}}
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+	f := newOIDCFlow(t)
+
+	// Première connexion : un compte est créé et lié au couple (émetteur, sub)
+	authURL, cookie := f.login()
+	created := sessionUser(t, f.callback(f.authorize(authURL, identity("sub-1", "camille@example.com")), cookie))
+	if created.OIDCIssuer != f.provider.URL || created.OIDCSubject != "sub-1" || created.Email != "camille@example.com" {
+		t.Fatalf("compte créé = %+v", created)
+	}
+
+	// Reconnexion : même compte, même si l'email a changé chez le fournisseur
+	authURL, cookie = f.login()
+	again := sessionUser(t, f.callback(f.authorize(authURL, identity("sub-1", "autre@example.com")), cookie))
+	if again.ID != created.ID {
+		t.Errorf("reconnexion : compte %s, veut %s", again.ID, created.ID)
+	}
+}
+
+func TestOIDCLinksExistingAccount(t *testing.T) {
+	f := newOIDCFlow(t)
+	existing, err := service.RegisterUser("dominique@example.com", "Dominique", "motdepasse123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, cookie := f.login()
+	u := sessionUser(t, f.callback(f.authorize(authURL, identity("sub-linked", "Dominique@Example.com")), cookie))
+	if u.ID != existing.ID {
+		t.Fatalf("le compte existant doit être lié (email vérifié) : %s, veut %s", u.ID, existing.ID)
+	}
+	if linked, _ := service.FindUserByEmail("dominique@example.com"); linked.OIDCSubject != "sub-linked" {
+		t.Errorf("sub lié = %q", linked.OIDCSubject)
+	}
+}
+
+func TestOIDCCallbackRejections(t *testing.T) {
+	f := newOIDCFlow(t)
+
+	tests := []struct {
+		name   string
+		run    func() *httptest.ResponseRecorder
+		status int
+	}{
+		{name: "sans cookie de state", status: http.StatusBadRequest, run: func() *httptest.ResponseRecorder {
+			authURL, _ := f.login()
+			return f.callback(f.authorize(authURL, identity("sub-x", "x@example.com")), nil)
+		}},
+		{name: "state d'une autre tentative", status: http.StatusBadRequest, run: func() *httptest.ResponseRecorder {
+			authURL, _ := f.login()
+			_, other := f.login()
+			return f.callback(f.authorize(authURL, identity("sub-x", "x@example.com")), other)
+		}},
+		{name: "nonce modifié", status: http.StatusUnauthorized, run: func() *httptest.ResponseRecorder {
+			authURL, cookie := f.login()
+			u, _ := url.Parse(authURL)
+			q := u.Query()
+			q.Set("nonce", "nonce-forge")
+			u.RawQuery = q.Encode()
+			return f.callback(f.authorize(u.String(), identity("sub-x", "x@example.com")), cookie)
+		}},
+		{name: "code rejoué", status: http.StatusUnauthorized, run: func() *httptest.ResponseRecorder {
+			authURL, cookie := f.login()
+			callback := f.authorize(authURL, identity("sub-replay", "replay@example.com"))
+			sessionUser(t, f.callback(callback, cookie))
+			return f.callback(callback, cookie)
+		}},
+		{name: "code inconnu", status: http.StatusUnauthorized, run: func() *httptest.ResponseRecorder {
+			authURL, cookie := f.login()
+			callback := f.authorize(authURL, identity("sub-x", "x@example.com"))
+			q := callback.Query()
+			q.Set("code", "inconnu")
+			callback.RawQuery = q.Encode()
+			return f.callback(callback, cookie)
+		}},
+		{name: "accès refusé", status: http.StatusUnauthorized, run: func() *httptest.ResponseRecorder {
+			authURL, cookie := f.login()
+			return f.callback(f.authorize(authURL, url.Values{"deny": {"1"}}), cookie)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.run()
+			if w.Code != tt.status {
+				t.Fatalf("statut %d, veut %d", w.Code, tt.status)
+			}
+			for _, c := range w.Result().Cookies() {
+				if c.Name == SessionCookieName && c.MaxAge >= 0 {
+					t.Fatal("aucune session ne doit être ouverte")
+				}
+			}
+			if !strings.Contains(w.Body.String(), "Connexion") && !strings.Contains(w.Body.String(), "connexion") {
+				t.Errorf("la page de connexion doit expliquer l'échec :\n%s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"pitch/models"
+)
+
+// openAPISchemas associe les noms de schémas utilisés dans APIRoutes aux types Go correspondants.
+// Les schémas JSON sont dérivés des tags json de ces types.
+var openAPISchemas = map[string]any{
+	"PitchRequest":  PitchRequest{},
+	"Pitch":         models.Pitch{},
+	"PitchResponse": models.PitchResponse{},
+	"PitchList":     PitchList{},
+	"JobRequest":    JobRequest{},
+	"Job":           models.Job{},
+	"Batch":         models.Batch{},
+	"Problem":       Problem{},
+}
+
+// OpenAPI sert le document OpenAPI 3 de l'API v1 (GET /api/v1/openapi.json)
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, BuildOpenAPI(APIRoutes()))
+}
+
+// contentFor décrit le contenu d'une requête ou d'une réponse : soit un schéma JSON nommé,
+// soit une liste de types MIME séparés par des virgules pour un fichier brut ("text/csv,application/zip")
+func contentFor(schema, jsonType string) map[string]any {
+	if !strings.Contains(schema, "/") {
+		return map[string]any{jsonType: map[string]any{"schema": schemaRef(schema)}}
+	}
+	content := map[string]any{}
+	for _, mediaType := range strings.Split(schema, ",") {
+		content[mediaType] = map[string]any{"schema": map[string]string{"type": "string", "format": "binary"}}
+	}
+	return content
+}
+
+// BuildOpenAPI génère le document OpenAPI à partir de la table des routes
+func BuildOpenAPI(routes []APIRoute) map[string]any {
+	paths := map[string]map[string]any{}
+	for _, rt := range routes {
+		path := APIPrefix + rt.Path
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+
+		op := map[string]any{
+			"operationId": rt.OperationID,
+			"summary":     rt.Summary,
+		}
+
+		// Les routes protégées par clé API peuvent aussi répondre 401, 403 et 429
+		statuses := rt.Responses
+		if rt.Scope != "" {
+			op["security"] = []map[string][]string{{"apiKey": {}}, {"bearer": {}}}
+			op["x-required-scope"] = rt.Scope
+			statuses = map[int]string{401: "Problem", 403: "Problem", 429: "Problem"}
+			for status, schema := range rt.Responses {
+				statuses[status] = schema
+			}
+		}
+
+		var params []map[string]any
+		for _, name := range pathParams(rt.Path) {
+			params = append(params, map[string]any{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		if len(rt.Produces) > 0 {
+			// Alternative à l'en-tête Accept : la réponse est alors proposée en téléchargement
+			params = append(params, map[string]any{
+				"name": "format", "in": "query", "required": false,
+				"schema": map[string]any{"type": "string", "enum": formatNames()},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if rt.Request != "" {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  contentFor(rt.Request, "application/json"),
+			}
+		}
+
+		responses := map[string]any{}
+		for status, schema := range statuses {
+			resp := map[string]any{"description": http.StatusText(status)}
+			if schema == "Problem" {
+				resp["content"] = contentFor(schema, "application/problem+json")
+			} else if schema != "" {
+				content := contentFor(schema, "application/json")
+				if status < 300 {
+					for _, mediaType := range rt.Produces {
+						content[mediaType] = map[string]any{"schema": map[string]string{"type": "string"}}
+					}
+				}
+				resp["content"] = content
+			}
+			responses[strconv.Itoa(status)] = resp
+		}
+		op["responses"] = responses
+
+		paths[path][strings.ToLower(rt.Method)] = op
+	}
+
+	schemas := map[string]any{}
+	for name, v := range openAPISchemas {
+		schemas[name] = jsonSchema(reflect.TypeOf(v), true)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "Pitch IA API",
+			"version": "1.0.0",
+		},
+		"servers": []map[string]string{{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]string{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": map[string]string{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// pathParams extrait les noms de paramètres {x} d'un chemin
+func pathParams(path string) []string {
+	var params []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params = append(params, strings.Trim(seg, "{}"))
+		}
+	}
+	return params
+}
+
+func schemaRef(name string) map[string]string {
+	return map[string]string{"$ref": "#/components/schemas/" + name}
+}
+
+// jsonSchema convertit un type Go en schéma OpenAPI.
+// Les structs déclarées dans openAPISchemas sont référencées plutôt que recopiées.
+func jsonSchema(t reflect.Type, root bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchema(t.Elem(), false)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchema(t.Elem(), false)}
+	case reflect.Struct:
+		if !root {
+			for name, v := range openAPISchemas {
+				if reflect.TypeOf(v) == t {
+					return map[string]any{"$ref": "#/components/schemas/" + name}
+				}
+			}
+		}
+		props := map[string]any{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = jsonSchema(f.Type, false)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]any{}
+}
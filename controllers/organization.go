@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"pitch/models"
+	"pitch/service"
+	"pitch/store"
+)
+
+// WorkspaceCookieName est le nom du cookie de l'espace de travail courant (organisation choisie)
+const WorkspaceCookieName = "pitch_workspace"
+
+// OrganizationsData est le modèle des pages d'organisation
+type OrganizationsData struct {
+	User          *models.User
+	Organizations []models.OrgMembership
+	Workspace     string // organisation de l'espace de travail courant
+
+	Org     *models.Organization
+	Role    string
+	Roles   []string
+	Members []models.Member
+	Invites []models.OrgInvite
+	Pitches []models.Pitch
+	Cohorts []models.Cohort
+	NewURL  string // lien d'invitation créé, affiché une seule fois
+	Error   string
+}
+
+// RoleLabel retourne le libellé du rôle role (utilisé dans les listes de rôles)
+func (d OrganizationsData) RoleLabel(role string) string { return models.OrgRoleLabel(role) }
+
+// IsOwner indique si l'utilisateur est propriétaire de l'organisation affichée
+func (d OrganizationsData) IsOwner() bool { return d.Role == models.OrgRoleOwner }
+
+// ShowCohorts indique si l'utilisateur a accès aux cohortes de l'organisation affichée (pas les fondateurs)
+func (d OrganizationsData) ShowCohorts() bool { return d.Role != "" && d.Role != models.OrgRoleFounder }
+
+// InviteData est le modèle de la page d'une invitation
+type InviteData struct {
+	User   *models.User
+	Token  string
+	Org    *models.Organization
+	Invite *models.OrgInvite
+	Member bool // l'utilisateur est déjà membre
+	Error  string
+}
+
+// orgErrorStatus convertit une erreur du service des organisations en statut HTTP
+func orgErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccountRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrOrgForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, service.ErrInviteInvalid):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInviteExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrLastOwner):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrOwnerInvite):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+func renderOrganizations(w http.ResponseWriter, r *http.Request, file string, status int, data OrganizationsData) {
+	tmpl, err := template.ParseFiles(getTemplatePath(file))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.User = service.UserFromContext(r.Context())
+	data.Workspace = service.WorkspaceFromContext(r.Context())
+	data.Roles = models.OrgRoles
+	if data.Organizations == nil {
+		data.Organizations = service.ListOrganizations(r.Context())
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// OrganizationsPage liste les organisations de l'utilisateur (GET /organisations)
+func OrganizationsPage(w http.ResponseWriter, r *http.Request) {
+	renderOrganizations(w, r, "Organizations.html", http.StatusOK, OrganizationsData{})
+}
+
+// CreateOrganization crée une organisation dont l'utilisateur devient propriétaire (POST /organisations)
+func CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := service.CreateOrganization(r.Context(), r.FormValue("name"))
+	if err != nil {
+		renderOrganizations(w, r, "Organizations.html", orgErrorStatus(err), OrganizationsData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusSeeOther)
+}
+
+// showOrganization affiche une organisation : membres, invitations (propriétaires), cohortes et pitchs
+func showOrganization(w http.ResponseWriter, r *http.Request, status int, data OrganizationsData) {
+	id := r.PathValue("id")
+	org, role, err := service.GetOrganization(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), orgErrorStatus(err))
+		return
+	}
+	data.Org, data.Role = org, role
+	data.Members, _ = service.ListMembers(r.Context(), id)
+	if role == models.OrgRoleOwner {
+		data.Invites, _ = service.ListInvites(r.Context(), id)
+	}
+	data.Pitches, _ = service.ListPitches(service.WithWorkspace(r.Context(), id), 0, 0)
+	data.Cohorts, _ = service.ListCohorts(r.Context(), id)
+	renderOrganizations(w, r, "Organization.html", status, data)
+}
+
+// OrganizationPage affiche une organisation (GET /organisations/{id})
+func OrganizationPage(w http.ResponseWriter, r *http.Request) {
+	showOrganization(w, r, http.StatusOK, OrganizationsData{})
+}
+
+// CreateInvite crée un lien d'invitation (POST /organisations/{id}/invitations)
+func CreateInvite(w http.ResponseWriter, r *http.Request) {
+	base, err := service.PublicBaseURL()
+	if err != nil {
+		showOrganization(w, r, http.StatusServiceUnavailable, OrganizationsData{Error: "Les liens d'invitation nécessitent que BASE_URL soit configurée."})
+		return
+	}
+	raw, _, err := service.CreateInvite(r.Context(), r.PathValue("id"), r.FormValue("role"))
+	if err != nil {
+		showOrganization(w, r, orgErrorStatus(err), OrganizationsData{Error: err.Error()})
+		return
+	}
+	showOrganization(w, r, http.StatusCreated, OrganizationsData{NewURL: base + "/invitations/" + raw})
+}
+
+// RevokeInvite révoque un lien d'invitation (POST /organisations/{id}/invitations/{invite}/revoquer)
+func RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := service.RevokeInvite(r.Context(), id, r.PathValue("invite")); err != nil {
+		showOrganization(w, r, orgErrorStatus(err), OrganizationsData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/organisations/"+id+"#invitations", http.StatusSeeOther)
+}
+
+// ChangeMemberRole change le rôle d'un membre (POST /organisations/{id}/membres/{user}/role)
+func ChangeMemberRole(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := service.ChangeMemberRole(r.Context(), id, r.PathValue("user"), r.FormValue("role")); err != nil {
+		showOrganization(w, r, orgErrorStatus(err), OrganizationsData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/organisations/"+id+"#membres", http.StatusSeeOther)
+}
+
+// RemoveMember retire un membre, ou fait quitter l'organisation à l'utilisateur (POST /organisations/{id}/membres/{user}/retirer)
+func RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, userID := r.PathValue("id"), r.PathValue("user")
+	if err := service.RemoveMember(r.Context(), id, userID); err != nil {
+		showOrganization(w, r, orgErrorStatus(err), OrganizationsData{Error: err.Error()})
+		return
+	}
+	if u := service.UserFromContext(r.Context()); u.ID == userID {
+		if service.WorkspaceFromContext(r.Context()) == id {
+			setWorkspaceCookie(w, r, "")
+		}
+		http.Redirect(w, r, "/organisations", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/organisations/"+id+"#membres", http.StatusSeeOther)
+}
+
+// openInvite retrouve l'invitation de la requête ; en cas d'erreur la page d'erreur est déjà écrite
+func openInvite(w http.ResponseWriter, r *http.Request) (InviteData, bool) {
+	data := InviteData{User: service.UserFromContext(r.Context()), Token: r.PathValue("token")}
+	inv, org, err := service.OpenInvite(data.Token)
+	if err != nil {
+		data.Error = err.Error()
+		renderInvite(w, orgErrorStatus(err), data)
+		return data, false
+	}
+	data.Invite, data.Org = inv, org
+	data.Member = data.User != nil && service.IsMember(data.User.ID, org.ID)
+	return data, true
+}
+
+func renderInvite(w http.ResponseWriter, status int, data InviteData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Invite.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	// Le jeton est dans l'URL : ne pas le transmettre aux sites liés
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// InvitePage présente une invitation (GET /invitations/{token}) ; il faut être connecté pour l'accepter
+func InvitePage(w http.ResponseWriter, r *http.Request) {
+	data, ok := openInvite(w, r)
+	if !ok {
+		return
+	}
+	renderInvite(w, http.StatusOK, data)
+}
+
+// AcceptInvite fait rejoindre l'organisation et en fait l'espace de travail courant (POST /invitations/{token})
+func AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	data, ok := openInvite(w, r)
+	if !ok {
+		return
+	}
+	org, err := service.AcceptInvite(r.Context(), data.Token)
+	if err != nil {
+		data.Error = err.Error()
+		renderInvite(w, orgErrorStatus(err), data)
+		return
+	}
+	setWorkspaceCookie(w, r, org.ID)
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusSeeOther)
+}
+
+// SwitchWorkspace change l'espace de travail courant (POST /espace) : une organisation dont l'utilisateur
+// est membre, ou l'espace personnel si org est vide
+func SwitchWorkspace(w http.ResponseWriter, r *http.Request) {
+	orgID := r.FormValue("org")
+	if orgID != "" && !service.IsMember(service.UserFromContext(r.Context()).ID, orgID) {
+		http.NotFound(w, r)
+		return
+	}
+	setWorkspaceCookie(w, r, orgID)
+
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = "/mes-pitchs"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// setWorkspaceCookie enregistre l'espace de travail courant ; orgID vide revient à l'espace personnel
+func setWorkspaceCookie(w http.ResponseWriter, r *http.Request, orgID string) {
+	cookie := &http.Cookie{
+		Name:     WorkspaceCookieName,
+		Value:    orgID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if orgID == "" {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
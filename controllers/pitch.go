@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -10,6 +12,7 @@ import (
 	"strings"
 
 	"pitch/models"
+	"pitch/render"
 	"pitch/service"
 )
 
@@ -21,14 +24,50 @@ func min(a, b int) int {
 	return b
 }
 
-// getTemplatePath retourne le chemin absolu vers le template
-func getTemplatePath() string {
+// generationError convertit une erreur de CreatePitch en statut HTTP et message pour l'utilisateur
+func generationError(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrGenerationFailed):
+		return http.StatusInternalServerError, service.GenerationFailureMessage()
+	case errors.Is(err, service.ErrQueueFull):
+		return http.StatusServiceUnavailable, "⚠️ Le service est très sollicité et la file d'attente est pleine. Veuillez réessayer dans quelques instants."
+	case errors.Is(err, service.ErrProvidersUnavailable):
+		return http.StatusServiceUnavailable, "⚠️ Le service de génération est temporairement indisponible. Veuillez réessayer dans quelques instants."
+	case errors.Is(err, service.ErrOutOfCredits):
+		return http.StatusPaymentRequired, "⚠️ Vous n'avez plus de crédits. Rechargez votre compte ou passez au plan Premium pour continuer à générer des pitchs."
+	case errors.Is(err, service.ErrAccountRequired):
+		return http.StatusUnauthorized, "⚠️ Connectez-vous ou créez un compte gratuit pour générer un pitch."
+	case errors.Is(err, service.ErrBudgetExceeded):
+		return http.StatusPaymentRequired, "⚠️ Le budget mensuel de génération est atteint. Veuillez réessayer le mois prochain ou contacter l'administrateur."
+	}
+	// Erreur de validation : message destiné à l'utilisateur
+	return http.StatusUnprocessableEntity, err.Error()
+}
+
+// queueRetryAfter est le délai conseillé (en secondes) quand la génération est indisponible
+const queueRetryAfter = "10"
+
+// jobPollInterval est l'intervalle de polling conseillé (en secondes) pour suivre une génération en file d'attente
+const jobPollInterval = "2"
+
+// noCacheRequested indique si le client demande à contourner le cache (Cache-Control: no-cache)
+func noCacheRequested(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Cache-Control"), "no-cache")
+}
+
+// wantsJSON indique si le client attend une réponse JSON (requête AJAX)
+func wantsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	xreq := r.Header.Get("X-Requested-With")
+	return strings.Contains(accept, "application/json") || xreq == "XMLHttpRequest"
+}
+
+// getTemplatePath retourne le chemin absolu vers le template file du dossier views
+func getTemplatePath(file string) string {
 	// Chemins possibles (en production, les fichiers sont dans le répertoire de travail)
 	paths := []string{
-		"views/Pitch.html",
-		"./views/Pitch.html",
-		filepath.Join("views", "Pitch.html"),
-		filepath.Join(".", "views", "Pitch.html"),
+		filepath.Join("views", file),
+		filepath.Join(".", "views", file),
 	}
 
 	// Essayer chaque chemin
@@ -41,12 +80,12 @@ func getTemplatePath() string {
 		}
 	}
 
-	return "views/Pitch.html"
+	return filepath.Join("views", file)
 }
 
 // Pitch affiche la page principale (GET /)
 func Pitch(w http.ResponseWriter, r *http.Request) {
-	tmplPath := getTemplatePath()
+	tmplPath := getTemplatePath("Pitch.html")
 	tmpl, err := template.ParseFiles(tmplPath)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
@@ -58,6 +97,8 @@ func Pitch(w http.ResponseWriter, r *http.Request) {
 		Response:  nil,
 		Loading:   false,
 		Error:     "",
+		User:      service.UserFromContext(r.Context()),
+		Credits:   userCredits(r),
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
@@ -85,7 +126,7 @@ func AnalyzePitch(w http.ResponseWriter, r *http.Request) {
 
 	desc := r.FormValue("project_description")
 
-	tmplPath := getTemplatePath()
+	tmplPath := getTemplatePath("Pitch.html")
 	tmpl, err := template.ParseFiles(tmplPath)
 	if err != nil {
 		http.Error(w, "Template error", http.StatusInternalServerError)
@@ -97,76 +138,251 @@ func AnalyzePitch(w http.ResponseWriter, r *http.Request) {
 		Response:  nil,
 		Loading:   false,
 		Error:     "",
+		User:      service.UserFromContext(r.Context()),
+		Credits:   userCredits(r),
 	}
 
-	if desc == "" {
-		data.Error = "Veuillez décrire votre projet."
-		if err := tmpl.Execute(w, data); err != nil {
-			http.Error(w, "Render error", http.StatusInternalServerError)
+	opts := service.GenerateOptions{NoCache: r.FormValue("no_cache") != "" || noCacheRequested(r)}
+	var p *models.Pitch
+	if service.GenerationQueueBusy() {
+		// La génération attendrait dans la file : la lancer en tâche et suivre sa position sur une page d'attente
+		var job *models.Job
+		if job, err = service.CreateJob(r.Context(), desc, opts.NoCache, ""); err == nil {
+			if wantsJSON(r) {
+				w.Header().Set("Location", "/generations/"+job.ID)
+				w.Header().Set("Retry-After", jobPollInterval)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(job)
+				return
+			}
+			http.Redirect(w, r, "/generations/"+job.ID, http.StatusSeeOther)
+			return
 		}
-		return
+	} else {
+		p, err = service.CreatePitch(r.Context(), desc, opts)
 	}
+	data.Credits = userCredits(r) // solde après débit ou remboursement
+	if err != nil {
+		status, message := generationError(err)
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", queueRetryAfter)
+		}
+		data.Error = message
+		data.OutOfCredits = errors.Is(err, service.ErrOutOfCredits)
+		data.AccountRequired = errors.Is(err, service.ErrAccountRequired)
+
+		// Si c'est une requête AJAX, retourner JSON (les erreurs de validation restent affichées dans la page)
+		if status != http.StatusUnprocessableEntity && wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": data.Error})
+			return
+		}
 
-	// Validation de la longueur
-	if len(desc) < 10 {
-		data.Error = "La description doit contenir au moins 10 caractères."
 		if err := tmpl.Execute(w, data); err != nil {
 			http.Error(w, "Render error", http.StatusInternalServerError)
 		}
 		return
 	}
 
-	if len(desc) > 2000 {
-		data.Error = "La description ne doit pas dépasser 2000 caractères."
-		if err := tmpl.Execute(w, data); err != nil {
-			http.Error(w, "Render error", http.StatusInternalServerError)
-		}
+	data.Response = p.Sections
+	data.PitchID = p.ID
+	data.Downloads = pitchDownloads(p.ID)
+
+	// Si requête AJAX, renvoyer les sections en JSON (format historique)
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data.Response)
 		return
 	}
 
-	resp := service.GenerationwithAI(desc)
-	if resp == nil {
-		// Vérifier si la clé API est présente pour donner un message d'erreur plus précis
-		apiKey := os.Getenv("OPENAI_API_KEY")
-		if apiKey == "" {
-			data.Error = "⚠️ La clé API OpenAI n'est pas configurée. Veuillez définir la variable d'environnement OPENAI_API_KEY dans les paramètres de votre service."
-		} else {
-			// Vérifier le format de la clé (doit commencer par sk-)
-			if !strings.HasPrefix(apiKey, "sk-") {
-				data.Error = "⚠️ Format de clé API invalide. La clé OpenAI doit commencer par 'sk-'. Vérifiez votre configuration."
-			} else {
-				data.Error = "⚠️ Impossible de générer le pitch après plusieurs tentatives.\n\nCauses possibles :\n• Problème réseau temporaire\n• Timeout de l'API OpenAI (>25s)\n• Quota/rate limit atteint\n• Service OpenAI temporairement indisponible\n\nVeuillez réessayer dans quelques instants."
-			}
-		}
-		// Si c'est une requête AJAX, retourner JSON
-		accept := r.Header.Get("Accept")
-		xreq := r.Header.Get("X-Requested-With")
-		if strings.Contains(accept, "application/json") || xreq == "XMLHttpRequest" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": data.Error})
+	// Autres formats demandés par ?format= ou l'en-tête Accept (Markdown, texte, YAML)
+	w.Header().Set("Vary", "Accept")
+	if f, download, err := pitchFormat(r, htmlOffers()); err == nil && f.Name != "" {
+		err := exportPitch(w, r, http.StatusOK, p, f, download)
+		if err == nil {
 			return
 		}
+		_, data.Error = exportError(err)
+	}
 
-		if err := tmpl.Execute(w, data); err != nil {
-			http.Error(w, "Render error", http.StatusInternalServerError)
-		}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
 		return
 	}
+}
 
-	data.Response = resp
+// GenerationData est passé à la page d'attente d'une génération en file (Generation.html)
+type GenerationData struct {
+	User *models.User
+	Job  *models.Job
+}
 
-	// Si requête AJAX, renvoyer JSON
-	accept := r.Header.Get("Accept")
-	xreq := r.Header.Get("X-Requested-With")
-	if strings.Contains(accept, "application/json") || xreq == "XMLHttpRequest" {
+// ShowGeneration suit une génération lancée depuis le formulaire quand la file était occupée
+// (GET /generations/{id}) : page rafraîchie avec la position courante, puis redirection vers le pitch
+func ShowGeneration(w http.ResponseWriter, r *http.Request) {
+	job, err := service.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	pending := job.Status == models.JobQueued || job.Status == models.JobRunning
+	if wantsJSON(r) {
+		if pending {
+			w.Header().Set("Retry-After", jobPollInterval)
+		}
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(data.Response)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+	if job.Status == models.JobSucceeded {
+		http.Redirect(w, r, "/pitches/"+job.PitchID, http.StatusSeeOther)
 		return
 	}
 
+	tmpl, err := template.ParseFiles(getTemplatePath("Generation.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, GenerationData{User: service.UserFromContext(r.Context()), Job: job}); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// ShowPitch affiche un pitch enregistré (GET /pitches/{id}), en HTML ou dans le format
+// demandé par ?format= (téléchargement) ou l'en-tête Accept
+func ShowPitch(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept")
+	f, download, err := pitchFormat(r, htmlOffers())
+	if errors.Is(err, errUnknownFormat) {
+		formatError(w, r, err)
+		return
+	}
+
+	data := models.TemplateData{
+		UserInput: p.Description,
+		Response:  p.Sections,
+		PitchID:   p.ID,
+		Downloads: pitchDownloads(p.ID),
+		User:      service.UserFromContext(r.Context()),
+	}
+	// Auteurs et membres de l'organisation du pitch : revue et commentaires selon le rôle
+	if role := service.PitchRole(r.Context(), p); role != "" {
+		actor, canComment := service.PitchActor(r.Context(), p)
+		data.CanShare = canComment && actor.Role == service.ReviewRoleAuthor
+		data.CanComment = canComment
+		data.ReviewLabel = p.ReviewLabel()
+		data.ReviewHistory = p.ReviewHistory
+		data.ReviewActions = service.ReviewActions(p, actor.Role)
+		data.Threads = service.CommentThreads(p)
+		data.Notifications = service.UnreadNotifications(r.Context())
+	}
+
+	// Sans format acceptable, la page HTML est servie ; si l'export échoue, la page affiche pourquoi
+	status := http.StatusOK
+	if err == nil && f.Name != "" {
+		err := exportPitch(w, r, http.StatusOK, p, f, download)
+		if err == nil {
+			return
+		}
+		status, data.Error = exportError(err)
+		data.OutOfCredits = errors.Is(err, service.ErrOutOfCredits)
+		data.AccountRequired = errors.Is(err, service.ErrAccountRequired)
+	}
+	data.Credits = userCredits(r)
+
+	tmpl, err := template.ParseFiles(getTemplatePath("Pitch.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// PitchCanvas sert le canvas d'un pitch en image (GET /pitches/{id}/canvas/{file}, ex. lean.svg, bmc.png),
+// affichée dans la page plutôt que téléchargée : l'URL peut être intégrée dans un <img> ou un autre document
+func PitchCanvas(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	name, ext, _ := strings.Cut(r.PathValue("file"), ".")
+	f, ok := render.Lookup(name + "-" + ext)
+	if !ok || (ext != "svg" && ext != "png") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if err := writePitch(w, http.StatusOK, p, f, false); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// PresentPitch affiche le pitch en présentation plein écran (GET /pitches/{id}/present) ; la page est autonome
+// (CSS et JS embarqués) et ?download=1 la propose en fichier HTML pour présenter sans connexion
+func PresentPitch(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := render.Presentation(&buf, p); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", `attachment; filename="presentation-`+p.ID+`.html"`)
+	}
+	buf.WriteTo(w)
+}
+
+// MyPitchesData est le modèle de la page « Mes pitchs »
+type MyPitchesData struct {
+	User    *models.User
+	Pitches []models.Pitch
+
+	// Espace de travail courant (nil = espace personnel) et organisations de l'utilisateur
+	Workspace     *models.OrgMembership
+	Organizations []models.OrgMembership
+}
+
+// MyPitches liste les pitchs de l'utilisateur connecté (GET /mes-pitchs)
+func MyPitches(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFiles(getTemplatePath("MyPitches.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
+
+	pitches, _ := service.ListPitches(r.Context(), 0, 0)
+	data := MyPitchesData{
+		User:          service.UserFromContext(r.Context()),
+		Pitches:       pitches,
+		Organizations: service.ListOrganizations(r.Context()),
+	}
+	for i, o := range data.Organizations {
+		if o.ID == service.WorkspaceFromContext(r.Context()) {
+			data.Workspace = &data.Organizations[i]
+		}
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
 }
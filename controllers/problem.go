@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// Problem est une erreur au format RFC 7807 (application/problem+json)
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// WriteProblem envoie une erreur RFC 7807 (utilisé aussi par les middlewares de l'API)
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// writeJSON envoie v encodé en JSON avec le statut donné
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// TooManyRequests répond 429 : erreur RFC 7807 pour l'API et les requêtes AJAX, page HTML sinon
+func TooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter int) {
+	detail := fmt.Sprintf("Trop de requêtes. Veuillez réessayer dans %d secondes.", retryAfter)
+	if strings.HasPrefix(r.URL.Path, APIPrefix) || wantsJSON(r) {
+		WriteProblem(w, r, http.StatusTooManyRequests, detail)
+		return
+	}
+
+	tmpl, err := template.ParseFiles(getTemplatePath("Pitch.html"))
+	if err != nil {
+		http.Error(w, detail, http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusTooManyRequests)
+	tmpl.Execute(w, models.TemplateData{
+		UserInput: r.FormValue("project_description"),
+		Error:     "⚠️ " + detail,
+		User:      service.UserFromContext(r.Context()),
+	})
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pitch/models"
+	"pitch/service"
+)
+
+// hangingProvider configure un fournisseur qui ne répond (en erreur) qu'après l'appel de release
+func hangingProvider(t *testing.T) (release func()) {
+	t.Helper()
+	ch := make(chan struct{})
+	var once sync.Once
+	release = func() { once.Do(func() { close(ch) }) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-ch
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(release)
+	t.Setenv("DEMO_MODE", "false")
+	t.Setenv("OPENAI_BASE_URL", srv.URL+"/v1")
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("FALLBACK_MODEL", "")
+	t.Setenv("RETRY_MAX_ATTEMPTS", "1")
+	t.Setenv("CACHE_ENABLED", "false")
+	return release
+}
+
+func analyzeRequest(desc string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/analyze-pitch", strings.NewReader(url.Values{"project_description": {desc}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "application/json")
+	return r
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("délai dépassé : %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGenerationRejectedWhenQueueFull(t *testing.T) {
+	release := hangingProvider(t)
+	t.Setenv("GENERATION_CONCURRENCY", "1")
+	t.Setenv("GENERATION_QUEUE_SIZE", "1")
+	service.ConfigureGenerationQueue()
+	t.Cleanup(service.ConfigureGenerationQueue)
+
+	// Une génération occupe le seul slot
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AnalyzePitch(httptest.NewRecorder(), analyzeRequest("Une application de covoiturage rural"))
+	}()
+	waitUntil(t, "génération en cours", service.GenerationQueueBusy)
+
+	// La suivante prend la seule place de la file, sous forme de tâche
+	w := httptest.NewRecorder()
+	AnalyzePitch(w, analyzeRequest("Une place de marché pour les artisans"))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("deuxième génération : statut %d, veut %d (%s)", w.Code, http.StatusAccepted, w.Body)
+	}
+	var job models.Job
+	json.NewDecoder(w.Body).Decode(&job)
+
+	// Au-delà, la file est pleine : 503 sans créer de tâche
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     *http.Request
+	}{
+		{name: "formulaire", handler: AnalyzePitch, req: analyzeRequest("Un service de garde d'enfants à domicile")},
+		{name: "API jobs", handler: APICreateJob, req: httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(`{"description": "Un service de garde d'enfants à domicile"}`))},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler(w, tt.req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s : statut %d, veut %d (%s)", tt.name, w.Code, http.StatusServiceUnavailable, w.Body)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s : Retry-After absent", tt.name)
+		}
+	}
+
+	release()
+	<-done
+	waitUntil(t, "fin de la tâche", func() bool {
+		j, err := service.GetJob(context.Background(), job.ID)
+		return err == nil && j.FinishedAt != nil
+	})
+}
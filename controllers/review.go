@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pitch/models"
+	"pitch/service"
+	"pitch/store"
+)
+
+// reviewErrorStatus convertit une erreur de revue ou de commentaire en statut HTTP
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrReviewTransition):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrReviewScore):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrParentComment), errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return shareErrorStatus(err)
+}
+
+// sectionAnchor retourne l'ancre HTML du bloc de commentaires d'une section (general pour les commentaires généraux)
+func sectionAnchor(key string) string {
+	if key == "" {
+		return "general"
+	}
+	return key
+}
+
+// reviewScore lit la note facultative jointe au verdict d'un mentor ; une valeur illisible est refusée par le service
+func reviewScore(r *http.Request) int {
+	v := strings.TrimSpace(r.FormValue("score"))
+	if v == "" {
+		return 0
+	}
+	score, err := strconv.Atoi(v)
+	if err != nil {
+		return -1
+	}
+	return score
+}
+
+// CommentPitch ajoute un commentaire d'un auteur ou d'un mentor de l'organisation (POST /pitches/{id}/commentaires)
+func CommentPitch(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := service.AddUserComment(r.Context(), id, r.FormValue("section"), r.FormValue("parent"), r.FormValue("body")); err != nil {
+		http.Error(w, err.Error(), reviewErrorStatus(err))
+		return
+	}
+	http.Redirect(w, r, "/pitches/"+id+"#section-"+sectionAnchor(r.FormValue("section")), http.StatusSeeOther)
+}
+
+// ReviewPitch change le statut de revue d'un pitch par un auteur ou un mentor de l'organisation (POST /pitches/{id}/revue)
+func ReviewPitch(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	p, err := service.GetPitch(r.Context(), id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	actor, ok := service.PitchActor(r.Context(), p)
+	if !ok {
+		http.Error(w, service.ErrOrgForbidden.Error(), http.StatusForbidden)
+		return
+	}
+	if _, err := service.ChangeReviewStatus(p.ID, actor, r.FormValue("status"), r.FormValue("note"), reviewScore(r), service.DefaultMailer()); err != nil {
+		http.Error(w, err.Error(), reviewErrorStatus(err))
+		return
+	}
+	http.Redirect(w, r, "/pitches/"+id+"#revue", http.StatusSeeOther)
+}
+
+// NotificationsData est le modèle de la page des notifications
+type NotificationsData struct {
+	User          *models.User
+	Notifications []models.Notification
+}
+
+// NotificationsPage affiche les notifications de l'utilisateur puis les marque comme lues (GET /notifications)
+func NotificationsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Notifications.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data := NotificationsData{
+		User:          service.UserFromContext(r.Context()),
+		Notifications: service.ListNotifications(r.Context()),
+	}
+	service.MarkNotificationsRead(r.Context())
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
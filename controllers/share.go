@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"pitch/models"
+	"pitch/service"
+	"pitch/store"
+)
+
+// shareCookiePrefix préfixe le cookie de déverrouillage d'un lien protégé par mot de passe
+const shareCookiePrefix = "pitch_share_"
+
+// SharesData est le modèle de la page de gestion des liens de partage d'un pitch
+type SharesData struct {
+	User   *models.User
+	Pitch  *models.Pitch
+	Links  []models.ShareLink
+	NewURL string // lien créé, affiché une seule fois
+	Error  string
+}
+
+// SharedData est le modèle de la page publique d'un pitch partagé
+type SharedData struct {
+	Token     string
+	Link      *models.ShareLink
+	Pitch     *models.Pitch
+	Threads   []models.SectionThreads
+	Actions   []models.ReviewAction // changements de statut proposés au mentor
+	Locked    bool                  // mot de passe à saisir
+	Commented bool                  // un commentaire vient d'être publié
+	Error     string
+}
+
+// ScoreChoices retourne les notes proposées au mentor avec son verdict
+func (d SharedData) ScoreChoices() []int { return models.ReviewScoreChoices() }
+
+func renderShares(w http.ResponseWriter, r *http.Request, status int, data SharesData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Shares.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	data.User = service.UserFromContext(r.Context())
+	if data.Pitch != nil && data.Links == nil {
+		data.Links, _ = service.ListShareLinks(r.Context(), data.Pitch.ID)
+	}
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+func renderShared(w http.ResponseWriter, status int, data SharedData) {
+	tmpl, err := template.ParseFiles(getTemplatePath("Shared.html"))
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	// Le jeton est dans l'URL : ne pas le transmettre aux sites liés ni l'indexer
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Render error", http.StatusInternalServerError)
+	}
+}
+
+// shareErrorStatus convertit une erreur du service de partage en statut HTTP
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccountRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrNotAuthor), errors.Is(err, service.ErrOrgForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, service.ErrShareInvalid):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrShareExpired):
+		return http.StatusGone
+	case errors.Is(err, service.ErrSharePassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrShareReadOnly), errors.Is(err, service.ErrCollabForbidden):
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// SharesPage liste les liens de partage d'un pitch (GET /pitches/{id}/partages)
+func SharesPage(w http.ResponseWriter, r *http.Request) {
+	links, err := service.ListShareLinks(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), shareErrorStatus(err))
+		return
+	}
+	p, _ := service.GetPitch(r.Context(), r.PathValue("id"))
+	renderShares(w, r, http.StatusOK, SharesData{Pitch: p, Links: links})
+}
+
+// CreateShare crée un lien de partage (POST /pitches/{id}/partages)
+func CreateShare(w http.ResponseWriter, r *http.Request) {
+	p, err := service.GetPitch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var expiresAt *time.Time
+	if v := r.FormValue("expires"); v != "" {
+		// La date saisie est le dernier jour de validité (inclus)
+		day, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			renderShares(w, r, http.StatusBadRequest, SharesData{Pitch: p, Error: "Date d'expiration invalide."})
+			return
+		}
+		end := day.AddDate(0, 0, 1)
+		expiresAt = &end
+	}
+
+	base, err := service.PublicBaseURL()
+	if err != nil {
+		renderShares(w, r, http.StatusServiceUnavailable, SharesData{Pitch: p, Error: "Le partage par lien nécessite que BASE_URL soit configurée."})
+		return
+	}
+	raw, _, err := service.CreateShareLink(r.Context(), p.ID, r.FormValue("label"), r.FormValue("permission"), r.FormValue("password"), expiresAt)
+	if err != nil {
+		renderShares(w, r, shareErrorStatus(err), SharesData{Pitch: p, Error: err.Error()})
+		return
+	}
+	renderShares(w, r, http.StatusCreated, SharesData{Pitch: p, NewURL: base + "/partage/" + raw})
+}
+
+// RevokeShare révoque un lien de partage (POST /pitches/{id}/partages/{share}/revoquer)
+func RevokeShare(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := service.RevokeShareLink(r.Context(), id, r.PathValue("share")); err != nil {
+		http.Error(w, err.Error(), shareErrorStatus(err))
+		return
+	}
+	http.Redirect(w, r, "/pitches/"+id+"/partages", http.StatusSeeOther)
+}
+
+// openShare ouvre le lien de la requête ; en cas d'erreur la page d'erreur est déjà écrite
+func openShare(w http.ResponseWriter, r *http.Request) (*models.ShareLink, bool) {
+	link, err := service.OpenShareLink(r.PathValue("token"))
+	if err != nil {
+		renderShared(w, shareErrorStatus(err), SharedData{Error: err.Error()})
+		return nil, false
+	}
+	return link, true
+}
+
+// shareUnlocked indique si le mot de passe du lien a déjà été saisi dans ce navigateur
+func shareUnlocked(r *http.Request, link *models.ShareLink) bool {
+	var value string
+	if c, err := r.Cookie(shareCookiePrefix + link.ID); err == nil {
+		value = c.Value
+	}
+	return service.ShareUnlocked(link, value)
+}
+
+// showShared affiche le pitch partagé et comptabilise la consultation
+func showShared(w http.ResponseWriter, r *http.Request, status int, link *models.ShareLink, data SharedData) {
+	p, err := service.ViewSharedPitch(link)
+	if err != nil {
+		renderShared(w, http.StatusNotFound, SharedData{Error: service.ErrShareInvalid.Error()})
+		return
+	}
+	data.Token = r.PathValue("token")
+	data.Link = link
+	data.Pitch = p
+	data.Threads = service.CommentThreads(p)
+	if link.CanReview() {
+		data.Actions = service.ReviewActions(p, service.ReviewRoleReviewer)
+	}
+	renderShared(w, status, data)
+}
+
+// SharedPitch affiche un pitch partagé en lecture seule (GET /partage/{token})
+func SharedPitch(w http.ResponseWriter, r *http.Request) {
+	link, ok := openShare(w, r)
+	if !ok {
+		return
+	}
+	if !shareUnlocked(r, link) {
+		renderShared(w, http.StatusUnauthorized, SharedData{Token: r.PathValue("token"), Link: link, Locked: true})
+		return
+	}
+	showShared(w, r, http.StatusOK, link, SharedData{Commented: r.URL.Query().Has("ok")})
+}
+
+// UnlockShare vérifie le mot de passe d'un lien protégé (POST /partage/{token})
+func UnlockShare(w http.ResponseWriter, r *http.Request) {
+	link, ok := openShare(w, r)
+	if !ok {
+		return
+	}
+	value, err := service.CheckSharePassword(link, r.FormValue("password"))
+	if err != nil {
+		renderShared(w, http.StatusUnauthorized, SharedData{Token: r.PathValue("token"), Link: link, Locked: true, Error: err.Error()})
+		return
+	}
+
+	cookie := &http.Cookie{
+		Name:     shareCookiePrefix + link.ID,
+		Value:    value,
+		Path:     "/partage/",
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if link.ExpiresAt != nil {
+		cookie.Expires = *link.ExpiresAt
+	}
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, "/partage/"+r.PathValue("token"), http.StatusSeeOther)
+}
+
+// CommentShare ajoute un commentaire via un lien de partage (POST /partage/{token}/commentaires)
+func CommentShare(w http.ResponseWriter, r *http.Request) {
+	link, ok := openShare(w, r)
+	if !ok {
+		return
+	}
+	if !shareUnlocked(r, link) {
+		renderShared(w, http.StatusUnauthorized, SharedData{Token: r.PathValue("token"), Link: link, Locked: true})
+		return
+	}
+	if _, err := service.AddShareComment(link, r.FormValue("author"), r.FormValue("section"), r.FormValue("parent"), r.FormValue("body")); err != nil {
+		showShared(w, r, shareErrorStatus(err), link, SharedData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/partage/"+r.PathValue("token")+"?ok=1#section-"+sectionAnchor(r.FormValue("section")), http.StatusSeeOther)
+}
+
+// ReviewShare change le statut de revue via un lien mentor (POST /partage/{token}/revue)
+func ReviewShare(w http.ResponseWriter, r *http.Request) {
+	link, ok := openShare(w, r)
+	if !ok {
+		return
+	}
+	if !shareUnlocked(r, link) {
+		renderShared(w, http.StatusUnauthorized, SharedData{Token: r.PathValue("token"), Link: link, Locked: true})
+		return
+	}
+	if !link.CanReview() {
+		showShared(w, r, http.StatusForbidden, link, SharedData{Error: "Ce lien ne permet pas de modifier le statut de revue."})
+		return
+	}
+
+	actor := service.ShareActor(link, r.FormValue("author"))
+	if _, err := service.ChangeReviewStatus(link.PitchID, actor, r.FormValue("status"), r.FormValue("note"), reviewScore(r), service.DefaultMailer()); err != nil {
+		showShared(w, r, reviewErrorStatus(err), link, SharedData{Error: err.Error()})
+		return
+	}
+	http.Redirect(w, r, "/partage/"+r.PathValue("token")+"#revue", http.StatusSeeOther)
+}
@@ -5,4 +5,7 @@ go 1.22.2
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/sashabaranov/go-openai v1.41.2
+	golang.org/x/image v0.23.0
 )
+
+require golang.org/x/text v0.21.0 // indirect
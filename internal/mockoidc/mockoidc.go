@@ -0,0 +1,238 @@
+// Package mockoidc est un fournisseur OpenID Connect minimal (découverte, autorisation avec PKCE S256,
+// token, JWKS) utilisé par la commande mockoidc et par les tests du SSO.
+package mockoidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+// authCode est un code d'autorisation émis et pas encore échangé
+type authCode struct {
+	ClientID      string
+	RedirectURI   string
+	Nonce         string
+	CodeChallenge string
+	Subject       string
+	Email         string
+	Name          string
+	ExpiresAt     time.Time
+}
+
+// Server est le fournisseur ; Handler expose ses endpoints
+type Server struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+	kid          string
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="fr"><head><meta charset="UTF-8"><title>Mock OIDC</title></head>
+<body style="font-family:sans-serif;max-width:420px;margin:40px auto">
+<h1>Mock OIDC</h1>
+<p>Client : <code>{{.ClientID}}</code></p>
+<form method="POST" action="/authorize?{{.Query}}">
+<p><label>sub <input name="sub" value="mock-user-1"></label></p>
+<p><label>email <input name="email" value="mock@example.com"></label></p>
+<p><label>nom <input name="name" value="Utilisateur Mock"></label></p>
+<p><button type="submit">Se connecter</button> <button type="submit" name="deny" value="1">Refuser</button></p>
+</form></body></html>`))
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA", "kid": s.kid, "alg": "RS256", "use": "sig",
+			"n": b64.EncodeToString(pub.N.Bytes()),
+			"e": b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.clientID || q.Get("response_type") != "code" || q.Get("redirect_uri") == "" {
+		http.Error(w, "requête d'autorisation invalide", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "PKCE S256 obligatoire", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		authorizePage.Execute(w, map[string]string{"ClientID": s.clientID, "Query": r.URL.RawQuery})
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "redirect_uri invalide", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("state", q.Get("state"))
+
+	if r.FormValue("deny") != "" {
+		params.Set("error", "access_denied")
+	} else {
+		code := randomString()
+		s.mu.Lock()
+		s.codes[code] = authCode{
+			ClientID:      s.clientID,
+			RedirectURI:   q.Get("redirect_uri"),
+			Nonce:         q.Get("nonce"),
+			CodeChallenge: q.Get("code_challenge"),
+			Subject:       r.FormValue("sub"),
+			Email:         r.FormValue("email"),
+			Name:          r.FormValue("name"),
+			ExpiresAt:     time.Now().Add(time.Minute),
+		}
+		s.mu.Unlock()
+		params.Set("code", code)
+	}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// Authentification du client : Basic ou client_secret_post
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != s.clientID || (s.clientSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(s.clientSecret)) != 1) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	c, found := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || time.Now().After(c.ExpiresAt) || c.RedirectURI != r.PostForm.Get("redirect_uri") || b64.EncodeToString(sum[:]) != c.CodeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now().Unix()
+	idToken, err := s.sign(map[string]any{
+		"iss":            s.issuer,
+		"sub":            c.Subject,
+		"aud":            c.ClientID,
+		"iat":            now,
+		"exp":            now + 300,
+		"nonce":          c.Nonce,
+		"email":          c.Email,
+		"email_verified": true,
+		"name":           c.Name,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+// sign produit un JWT RS256
+func (s *Server) sign(claims map[string]any) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.kid})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + b64.EncodeToString(sig), nil
+}
+
+// New crée un fournisseur pour l'émetteur issuer (URL publique du serveur, sans / final) qui n'accepte
+// que le client clientID ; clientSecret vide désigne un client public
+func New(issuer, clientID, clientSecret string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		issuer:       strings.TrimRight(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		kid:          randomString()[:8],
+		codes:        map[string]authCode{},
+	}, nil
+}
+
+// Handler retourne les routes du fournisseur : découverte, JWKS, autorisation et token
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	return mux
+}
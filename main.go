@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"pitch/routes"
+	"pitch/service"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +14,20 @@ func main() {
 	// Charger les variables d'environnement depuis .env (optionnel, pour le développement local)
 	_ = godotenv.Load(".env")
 
+	// Sans PAYMENT_PROVIDER, les achats de crédits sont refusés
+	service.ConfigurePayments()
+
+	// Grille et jurés du concours (JURY_CONFIG), lus une seule fois
+	service.ConfigureJury()
+
+	// Slots d'appel au fournisseur et taille de la file d'attente
+	service.ConfigureGenerationQueue()
+
+	// Les générations en arrière-plan ne survivent pas à un redémarrage
+	service.RecoverJobs()
+	service.RecoverBatches()
+	service.RecoverJuryRuns()
+
 	// Configurer les routes
 	routes.Web()
 
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// APIKey est une clé d'accès à l'API pour les clients programmatiques.
+// Seule l'empreinte SHA-256 de la clé est conservée.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	Scopes     []string   `json:"scopes"`
+	DailyQuota int        `json:"daily_quota"` // 0 = illimité
+	UsageDay   string     `json:"usage_day"`   // jour (AAAA-MM-JJ) du compteur UsageCount
+	UsageCount int        `json:"usage_count"`
+	TotalUsage int        `json:"total_usage"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope indique si la clé donne accès au scope demandé
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// BatchRow est une ligne d'un lot : description importée puis résultat de sa génération
+type BatchRow struct {
+	Line        int    `json:"line"` // numéro de ligne dans le fichier importé
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description"`
+	Status      string `json:"status"` // mêmes états que Job
+	PitchID     string `json:"pitch_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Batch est la génération d'un lot de pitchs importé depuis un fichier CSV ou JSONL
+type Batch struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"` // queued, running, succeeded (même avec des lignes en erreur) ou failed
+	Total      int        `json:"total"`
+	Done       int        `json:"done"`   // lignes traitées, en succès ou en erreur
+	Failed     int        `json:"failed"` // lignes en erreur
+	Rows       []BatchRow `json:"rows"`
+	OwnerID    string     `json:"owner_id,omitempty"`
+	APIKeyID   string     `json:"api_key_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cohort est une promotion d'un programme d'incubation : les équipes d'une organisation suivies ensemble
+type Cohort struct {
+	ID        string       `json:"id"`
+	OrgID     string       `json:"org_id"`
+	Name      string       `json:"name"`
+	Teams     []CohortTeam `json:"teams"`
+	CreatedBy string       `json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Team retourne l'équipe id de la cohorte
+func (c *Cohort) Team(id string) (*CohortTeam, bool) {
+	for i := range c.Teams {
+		if c.Teams[i].ID == id {
+			return &c.Teams[i], true
+		}
+	}
+	return nil, false
+}
+
+// CohortTeam est une startup de la cohorte et les versions successives de son pitch
+type CohortTeam struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	PitchIDs []string  `json:"pitch_ids"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// ProgressPoint est l'état d'une version du pitch d'une équipe
+type ProgressPoint struct {
+	PitchID      string
+	Description  string
+	CreatedAt    time.Time
+	ReviewStatus string
+	Score        int // dernière note des mentors (0 = non noté)
+}
+
+// ReviewLabel retourne le libellé du statut de revue de la version
+func (p ProgressPoint) ReviewLabel() string { return ReviewLabel(p.ReviewStatus) }
+
+// TeamProgress est la ligne d'une équipe dans le tableau de bord de la cohorte
+type TeamProgress struct {
+	Team         CohortTeam
+	Rank         int             // rang au classement (0 = pas encore noté)
+	Latest       *ProgressPoint  // dernière version du pitch (nil si aucun pitch)
+	Points       []ProgressPoint // versions du pitch, de la plus ancienne à la plus récente
+	Score        int             // note la plus récente, toutes versions confondues (0 = jamais noté)
+	FirstScore   int             // première note reçue
+	LastActivity time.Time       // dernier changement : version, revue ou co-édition
+}
+
+// ScoreDelta retourne la progression entre la première et la dernière note reçues
+func (t TeamProgress) ScoreDelta() int {
+	if t.Score == 0 || t.FirstScore == 0 {
+		return 0
+	}
+	return t.Score - t.FirstScore
+}
+
+// StatusCount est le nombre d'équipes dont le dernier pitch est au statut de revue Status
+type StatusCount struct {
+	Status string
+	Count  int
+}
+
+// Label retourne le libellé du statut
+func (s StatusCount) Label() string { return ReviewLabel(s.Status) }
+
+// CohortDashboard est la vue d'ensemble d'une cohorte : classement des équipes et répartition des statuts
+type CohortDashboard struct {
+	Cohort       Cohort
+	Teams        []TeamProgress // classées par note décroissante, les équipes non notées en dernier
+	Statuses     []StatusCount
+	AverageScore float64 // moyenne des dernières notes (0 si aucune équipe notée)
+	Scored       int     // nombre d'équipes notées
+}
+
+// Sparkline retourne les points d'une courbe des notes successives, dans un cadre de width × height pixels
+// (attribut points d'une polyline SVG ; vide s'il y a moins de deux notes)
+func (t TeamProgress) Sparkline(width, height int) string {
+	var scores []int
+	for _, p := range t.Points {
+		if p.Score > 0 {
+			scores = append(scores, p.Score)
+		}
+	}
+	if len(scores) < 2 {
+		return ""
+	}
+	points := make([]string, len(scores))
+	for i, s := range scores {
+		x := float64(width) * float64(i) / float64(len(scores)-1)
+		y := float64(height) * float64(MaxReviewScore-s) / float64(MaxReviewScore-MinReviewScore)
+		points[i] = strconv.FormatFloat(x, 'f', 1, 64) + "," + strconv.FormatFloat(y, 'f', 1, 64)
+	}
+	return strings.Join(points, " ")
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Types d'écritures du registre de crédits
+const (
+	CreditGrant    = "grant"    // crédits mensuels du plan
+	CreditExpire   = "expire"   // crédits mensuels non utilisés en fin de mois
+	CreditDebit    = "debit"    // génération ou export
+	CreditRefund   = "refund"   // remboursement d'une génération échouée
+	CreditPurchase = "purchase" // achat de crédits
+)
+
+// CreditEntry est une écriture du registre de crédits d'un compte (montant positif ou négatif)
+type CreditEntry struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Kind      string    `json:"kind"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	Reference string    `json:"reference,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Plan est une offre : crédits offerts chaque mois et prix mensuel
+type Plan struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	MonthlyCredits int    `json:"monthly_credits"`
+	PriceCents     int    `json:"price_cents"`
+}
+
+// CreditPack est un lot de crédits achetable à l'unité
+type CreditPack struct {
+	ID         string `json:"id"`
+	Credits    int    `json:"credits"`
+	PriceCents int    `json:"price_cents"`
+}
+
+// Order est une commande transmise au prestataire de paiement
+type Order struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Description string     `json:"description"`
+	AmountCents int        `json:"amount_cents"`
+	PackID      string     `json:"pack_id,omitempty"`
+	PlanID      string     `json:"plan_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+}
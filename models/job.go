@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// États d'une tâche de génération asynchrone
+const (
+	JobQueued    = "queued"    // en attente d'un slot de génération
+	JobRunning   = "running"   // génération en cours
+	JobSucceeded = "succeeded" // pitch généré, voir PitchID
+	JobFailed    = "failed"    // échec, voir Error
+)
+
+// Job est une génération de pitch exécutée en arrière-plan
+type Job struct {
+	ID            string     `json:"id"`
+	Status        string     `json:"status"`
+	Description   string     `json:"description"`
+	NoCache       bool       `json:"no_cache,omitempty"`
+	QueuePosition int        `json:"queue_position,omitempty"` // position courante dans la file (1 = prochain servi)
+	PitchID       string     `json:"pitch_id,omitempty"`
+	Result        *Pitch     `json:"result,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	APIKeyID      string     `json:"api_key_id,omitempty"`
+	OwnerID       string     `json:"owner_id,omitempty"` // utilisateur connecté qui a lancé la tâche depuis le site
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+
+	// Webhook appelé à la fin de la tâche ; le secret de signature n'est communiqué qu'à la création
+	CallbackURL      string     `json:"callback_url,omitempty"`
+	WebhookSecret    string     `json:"webhook_secret,omitempty"`
+	WebhookDelivered *time.Time `json:"webhook_delivered_at,omitempty"`
+	WebhookError     string     `json:"webhook_error,omitempty"`
+}
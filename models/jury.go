@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// RubricCriterion est un critère de la grille d'évaluation commune aux jurés d'un concours
+type RubricCriterion struct {
+	Key         string  `json:"key"`
+	Label       string  `json:"label"`
+	Description string  `json:"description"`      // ce que les jurés évaluent
+	Weight      float64 `json:"weight,omitempty"` // poids dans la note globale (1 par défaut)
+}
+
+// JudgePersona est le profil d'un juré simulé
+type JudgePersona struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Persona string `json:"persona"` // consigne décrivant son parcours, ses attentes et sa sévérité
+}
+
+// CriterionScore est la note d'un juré sur un critère
+type CriterionScore struct {
+	Key     string `json:"key"`
+	Score   int    `json:"score"` // de 1 à 10
+	Comment string `json:"comment,omitempty"`
+}
+
+// ScoreSheet est la feuille de notes d'un juré
+type ScoreSheet struct {
+	JudgeID   string           `json:"judge_id"`
+	JudgeName string           `json:"judge_name"`
+	Status    string           `json:"status"` // mêmes états que Job
+	Scores    []CriterionScore `json:"scores,omitempty"`
+	Total     float64          `json:"total,omitempty"` // moyenne pondérée sur 10
+	Verdict   string           `json:"verdict,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	Demo      bool             `json:"demo,omitempty"` // notes simulées sans IA (mode démo)
+}
+
+// Score retourne la note du juré sur le critère key (0 si absente)
+func (s ScoreSheet) Score(key string) int {
+	for _, c := range s.Scores {
+		if c.Key == key {
+			return c.Score
+		}
+	}
+	return 0
+}
+
+// JuryRun est le passage d'un pitch devant un jury simulé
+type JuryRun struct {
+	ID         string            `json:"id"`
+	PitchID    string            `json:"pitch_id"`
+	OwnerID    string            `json:"owner_id"` // utilisateur ayant lancé le jury
+	Status     string            `json:"status"`   // queued, running puis succeeded (même si des jurés ont échoué)
+	Rubric     []RubricCriterion `json:"rubric"`   // grille au moment du passage
+	Sheets     []ScoreSheet      `json:"sheets"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// Finished indique si tous les jurés ont rendu leur feuille (ou échoué)
+func (r *JuryRun) Finished() bool {
+	return r.Status == JobSucceeded || r.Status == JobFailed
+}
+
+// CriterionResult est le résultat agrégé d'un critère
+type CriterionResult struct {
+	Criterion    RubricCriterion
+	Average      float64
+	Min, Max     int
+	Disagreement bool // écart entre la note la plus haute et la plus basse au-delà du seuil
+}
+
+// Spread retourne l'écart entre la note la plus haute et la plus basse
+func (c CriterionResult) Spread() int { return c.Max - c.Min }
+
+// JudgeResult est la place d'un juré dans le rapport
+type JudgeResult struct {
+	Rank      int
+	Sheet     ScoreSheet
+	Deviation float64 // écart de sa note globale à la moyenne du jury
+	Outlier   bool    // note globale éloignée de celle du jury
+}
+
+// JuryReport est le rapport agrégé d'un passage devant le jury : jurés classés du plus au moins favorable,
+// critères classés des points forts aux points faibles, désaccords mis en évidence
+type JuryReport struct {
+	Run           JuryRun
+	Completed     int     // feuilles rendues
+	Average       float64 // moyenne des notes globales
+	Min, Max      float64 // notes globales extrêmes
+	Judges        []JudgeResult
+	Criteria      []CriterionResult
+	Disagreements int // critères en désaccord
+	Threshold     int // écart de notes considéré comme un désaccord
+	Failed        []ScoreSheet
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Rôles d'un membre dans une organisation (incubateur, accélérateur…)
+const (
+	OrgRoleOwner   = "owner"   // gère l'organisation, ses membres et ses pitchs
+	OrgRoleMentor  = "mentor"  // lit, commente et fait avancer la revue des pitchs
+	OrgRoleFounder = "founder" // crée et modifie les pitchs de l'organisation
+	OrgRoleViewer  = "viewer"  // lecture seule
+)
+
+// OrgRoles liste les rôles dans l'ordre d'affichage
+var OrgRoles = []string{OrgRoleOwner, OrgRoleMentor, OrgRoleFounder, OrgRoleViewer}
+
+// orgRoleLabels sont les libellés affichés des rôles
+var orgRoleLabels = map[string]string{
+	OrgRoleOwner:   "Propriétaire",
+	OrgRoleMentor:  "Mentor",
+	OrgRoleFounder: "Fondateur",
+	OrgRoleViewer:  "Lecteur",
+}
+
+// OrgRoleLabel retourne le libellé d'un rôle
+func OrgRoleLabel(role string) string {
+	if label, ok := orgRoleLabels[role]; ok {
+		return label
+	}
+	return role
+}
+
+// Organization est un espace de travail partagé par plusieurs utilisateurs ; ses pitchs lui appartiennent
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Membership est l'appartenance d'un utilisateur à une organisation ; l'ID vaut <org>_<utilisateur>
+type Membership struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"org_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoleLabel retourne le libellé du rôle du membre
+func (m Membership) RoleLabel() string { return OrgRoleLabel(m.Role) }
+
+// Member est un membre affiché avec son nom et son email
+type Member struct {
+	Membership
+	Name  string
+	Email string
+}
+
+// OrgInvite est un lien d'invitation dans une organisation, utilisable jusqu'à son expiration.
+// Seule l'empreinte SHA-256 du jeton est conservée.
+type OrgInvite struct {
+	ID        string     `json:"id"`
+	OrgID     string     `json:"org_id"`
+	Hash      string     `json:"hash"`
+	Role      string     `json:"role"` // rôle donné aux membres qui l'acceptent
+	CreatedBy string     `json:"created_by"`
+	Uses      int        `json:"uses"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Active indique si l'invitation peut encore être acceptée
+func (i *OrgInvite) Active() bool {
+	return i.RevokedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// RoleLabel retourne le libellé du rôle donné par l'invitation
+func (i *OrgInvite) RoleLabel() string { return OrgRoleLabel(i.Role) }
+
+// OrgMembership est une organisation de l'utilisateur avec son rôle
+type OrgMembership struct {
+	Organization
+	Role string
+}
+
+// RoleLabel retourne le libellé du rôle de l'utilisateur
+func (m OrgMembership) RoleLabel() string { return OrgRoleLabel(m.Role) }
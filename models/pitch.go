@@ -1,13 +1,96 @@
 package models
 
+import "time"
+
 // Struct pour la réponse de l'API
 type PitchResponse struct {
-	Probleme string
-	Solution string
-	Marche   string
-	Valeur   string
-	Canaux   string
-	Modele   string
+	Probleme string `json:"probleme"`
+	Solution string `json:"solution"`
+	Marche   string `json:"marche"`
+	Valeur   string `json:"valeur"`
+	Canaux   string `json:"canaux"`
+	Modele   string `json:"modele"`
+
+	// Demo indique que le pitch a été généré par les modèles de démonstration (sans IA)
+	Demo bool `json:"demo"`
+	// Industrie est le secteur détecté en mode démo
+	Industrie string `json:"industrie,omitempty"`
+}
+
+// field retourne le champ de la section key (probleme, solution, marche, valeur, canaux, modele)
+func (r *PitchResponse) field(key string) *string {
+	switch key {
+	case "probleme":
+		return &r.Probleme
+	case "solution":
+		return &r.Solution
+	case "marche":
+		return &r.Marche
+	case "valeur":
+		return &r.Valeur
+	case "canaux":
+		return &r.Canaux
+	case "modele":
+		return &r.Modele
+	}
+	return nil
+}
+
+// Section retourne le contenu de la section key ; ok est faux si la section n'existe pas
+func (r *PitchResponse) Section(key string) (content string, ok bool) {
+	if f := r.field(key); f != nil {
+		return *f, true
+	}
+	return "", false
+}
+
+// SetSection remplace le contenu de la section key ; retourne faux si la section n'existe pas
+func (r *PitchResponse) SetSection(key, content string) bool {
+	f := r.field(key)
+	if f == nil {
+		return false
+	}
+	*f = content
+	return true
+}
+
+// Pitch est un pitch généré et enregistré
+type Pitch struct {
+	ID          string         `json:"id"`
+	Description string         `json:"description"`
+	Sections    *PitchResponse `json:"sections"`
+	OwnerID     string         `json:"owner_id,omitempty"`   // utilisateur propriétaire (vide si anonyme)
+	OrgID       string         `json:"org_id,omitempty"`     // organisation à laquelle appartient le pitch
+	APIKeyID    string         `json:"api_key_id,omitempty"` // clé API ayant créé le pitch
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   *time.Time     `json:"updated_at,omitempty"` // dernière modification en co-édition
+
+	// Workflow de revue par les mentors (statut vide = brouillon)
+	ReviewStatus  string        `json:"review_status,omitempty"`
+	ReviewHistory []ReviewEvent `json:"review_history,omitempty"`
+}
+
+// Review retourne le statut de revue du pitch
+func (p *Pitch) Review() string {
+	if p.ReviewStatus == "" {
+		return ReviewDraft
+	}
+	return p.ReviewStatus
+}
+
+// ReviewLabel retourne le libellé du statut de revue du pitch
+func (p *Pitch) ReviewLabel() string {
+	return ReviewLabel(p.Review())
+}
+
+// Score retourne la dernière note donnée par un mentor (0 si le pitch n'a pas encore été noté)
+func (p *Pitch) Score() int {
+	for i := len(p.ReviewHistory) - 1; i >= 0; i-- {
+		if p.ReviewHistory[i].Score > 0 {
+			return p.ReviewHistory[i].Score
+		}
+	}
+	return 0
 }
 
 // Struct pour le template
@@ -16,4 +99,34 @@ type TemplateData struct {
 	Response  *PitchResponse
 	Loading   bool
 	Error     string
+	PitchID   string
+	User      *User
+
+	// Actions proposées avec le message d'erreur
+	OutOfCredits    bool
+	AccountRequired bool
+	Credits         *int // solde affiché quand les crédits sont activés
+
+	// Liens de téléchargement du pitch dans les formats d'export
+	Downloads []Download
+
+	// Auteurs (co-édition, partage) et membres de l'organisation du pitch : revue par les mentors
+	// et commentaires par section
+	CanShare      bool
+	CanComment    bool
+	ReviewLabel   string
+	ReviewHistory []ReviewEvent
+	ReviewActions []ReviewAction
+	Threads       []SectionThreads
+	Notifications int // notifications non lues
+}
+
+// ScoreChoices retourne les notes proposées au mentor avec son verdict
+func (d TemplateData) ScoreChoices() []int { return ReviewScoreChoices() }
+
+// Download est un lien de téléchargement d'un pitch dans un format d'export
+type Download struct {
+	Label    string
+	Href     string
+	Billable bool // le téléchargement est décompté des crédits
 }
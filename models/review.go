@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+// Statuts du workflow de revue d'un pitch par les mentors
+const (
+	ReviewDraft            = "draft"
+	ReviewSubmitted        = "submitted"
+	ReviewInReview         = "in_review"
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+)
+
+// reviewLabels sont les libellés affichés des statuts de revue
+var reviewLabels = map[string]string{
+	ReviewDraft:            "Brouillon",
+	ReviewSubmitted:        "Soumis",
+	ReviewInReview:         "En revue",
+	ReviewApproved:         "Approuvé",
+	ReviewChangesRequested: "Modifications demandées",
+}
+
+// ReviewLabel retourne le libellé d'un statut de revue
+func ReviewLabel(status string) string {
+	if label, ok := reviewLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+// ReviewEvent est un changement de statut de revue, conservé dans l'historique du pitch
+type ReviewEvent struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Actor string    `json:"actor"`           // nom de l'auteur du changement
+	Role  string    `json:"role"`            // author ou reviewer
+	Note  string    `json:"note,omitempty"`  // message joint (demande de modifications…)
+	Score int       `json:"score,omitempty"` // note du mentor sur 10 jointe à son verdict (0 = non noté)
+	At    time.Time `json:"at"`
+}
+
+// FromLabel retourne le libellé du statut de départ
+func (e ReviewEvent) FromLabel() string { return ReviewLabel(e.From) }
+
+// ToLabel retourne le libellé du statut d'arrivée
+func (e ReviewEvent) ToLabel() string { return ReviewLabel(e.To) }
+
+// Notes des mentors : un verdict (approbation ou demande de modifications) peut être noté de 1 à 10
+const (
+	MinReviewScore = 1
+	MaxReviewScore = 10
+)
+
+// ScoredReview indique si un passage au statut status est un verdict de mentor qui peut être noté
+func ScoredReview(status string) bool {
+	return status == ReviewApproved || status == ReviewChangesRequested
+}
+
+// ReviewScoreChoices retourne les notes proposées aux mentors, de 1 à 10
+func ReviewScoreChoices() []int {
+	scores := make([]int, 0, MaxReviewScore-MinReviewScore+1)
+	for n := MinReviewScore; n <= MaxReviewScore; n++ {
+		scores = append(scores, n)
+	}
+	return scores
+}
+
+// ReviewAction est un changement de statut proposé à l'auteur ou au mentor
+type ReviewAction struct {
+	Status string
+	Label  string
+}
+
+// Scored indique si l'action est un verdict qui peut être noté
+func (a ReviewAction) Scored() bool { return ScoredReview(a.Status) }
+
+// CommentThread est un commentaire et ses réponses
+type CommentThread struct {
+	Comment
+	Replies []Comment
+}
+
+// SectionThreads est une section du pitch avec ses fils de discussion ; Key vide = commentaires généraux
+type SectionThreads struct {
+	Key     string
+	Title   string
+	Content string
+	Threads []CommentThread
+}
+
+// Notification est un message destiné à un utilisateur (changement de statut de revue…)
+type Notification struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	PitchID   string     `json:"pitch_id,omitempty"`
+	Message   string     `json:"message"`
+	Link      string     `json:"link,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Permissions d'un lien de partage
+const (
+	SharePermissionRead    = "read"    // lecture seule
+	SharePermissionComment = "comment" // lecture et commentaires
+	SharePermissionReview  = "review"  // commentaires et revue (mentor)
+	SharePermissionEdit    = "edit"    // commentaires et co-édition (co-fondateur)
+)
+
+// ShareLink est un lien de partage public d'un pitch, utilisable sans compte.
+// Seule l'empreinte SHA-256 du jeton est conservée.
+type ShareLink struct {
+	ID           string     `json:"id"`
+	PitchID      string     `json:"pitch_id"`
+	OwnerID      string     `json:"owner_id"`
+	Label        string     `json:"label,omitempty"` // destinataire ou usage (« Mentor Station F »)
+	Hash         string     `json:"hash"`
+	Permission   string     `json:"permission"`
+	PasswordHash string     `json:"password_hash,omitempty"` // vide = sans mot de passe
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	Views        int        `json:"views"`
+	LastViewedAt *time.Time `json:"last_viewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Expired indique si la date d'expiration du lien est passée
+func (s *ShareLink) Expired() bool {
+	return s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt)
+}
+
+// Active indique si le lien peut encore être ouvert
+func (s *ShareLink) Active() bool {
+	return s.RevokedAt == nil && !s.Expired()
+}
+
+// CanComment indique si le lien autorise les commentaires
+func (s *ShareLink) CanComment() bool {
+	switch s.Permission {
+	case SharePermissionComment, SharePermissionReview, SharePermissionEdit:
+		return true
+	}
+	return false
+}
+
+// CanReview indique si le lien permet de faire avancer la revue du pitch
+func (s *ShareLink) CanReview() bool {
+	return s.Permission == SharePermissionReview
+}
+
+// CanEdit indique si le lien permet de modifier le pitch en co-édition
+func (s *ShareLink) CanEdit() bool {
+	return s.Permission == SharePermissionEdit
+}
+
+// Comment est un commentaire laissé sur un pitch, rattaché à une section et éventuellement
+// en réponse à un autre commentaire (fil de discussion)
+type Comment struct {
+	ID        string    `json:"id"`
+	PitchID   string    `json:"pitch_id"`
+	Section   string    `json:"section,omitempty"`   // clé de la section (probleme, solution…), vide = général
+	ParentID  string    `json:"parent_id,omitempty"` // commentaire auquel celui-ci répond
+	ShareID   string    `json:"share_id,omitempty"`  // lien de partage utilisé (vide pour le propriétaire)
+	UserID    string    `json:"user_id,omitempty"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
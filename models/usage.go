@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// UsageRecord est la consommation d'une génération OpenAI
+type UsageRecord struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id,omitempty"`
+	APIKeyID         string    `json:"api_key_id,omitempty"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	Success          bool      `json:"success"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// UsageSummary agrège les consommations d'un groupe (jour, utilisateur ou clé API)
+type UsageSummary struct {
+	Key              string  `json:"key"`
+	Label            string  `json:"label"`
+	Generations      int     `json:"generations"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
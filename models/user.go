@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// User est un compte utilisateur
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"password_hash"` // vide pour un compte créé par SSO
+	OIDCIssuer   string    `json:"oidc_issuer,omitempty"`
+	OIDCSubject  string    `json:"oidc_subject,omitempty"`
+	Plan         string    `json:"plan,omitempty"` // identifiant du plan (vide = gratuit)
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session est une session de connexion ; l'ID est l'empreinte du jeton stocké dans le cookie
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordReset est une demande de réinitialisation de mot de passe ; l'ID est l'empreinte du jeton envoyé par email
+type PasswordReset struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
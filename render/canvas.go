@@ -0,0 +1,364 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"pitch/models"
+)
+
+// Canvas est une mise en page d'une page (Lean Canvas, Business Model Canvas) :
+// 9 blocs placés sur une grille de 10 colonnes et 3 lignes.
+type Canvas struct {
+	Name   string // identifiant utilisé dans les URL et les formats (lean, bmc)
+	Title  string
+	blocks []canvasBlock
+}
+
+type canvasBlock struct {
+	Title      string
+	X, Y, W, H int // position et taille dans la grille 10 x 3
+	Content    func(*models.PitchResponse) string
+}
+
+// Les blocs absents du pitch restent à compléter par l'équipe
+func empty(*models.PitchResponse) string { return "" }
+
+// LeanCanvas est le Lean Canvas d'Ash Maurya
+var LeanCanvas = Canvas{Name: "lean", Title: "Lean Canvas", blocks: []canvasBlock{
+	{"Problème", 0, 0, 2, 2, func(r *models.PitchResponse) string { return r.Probleme }},
+	{"Solution", 2, 0, 2, 1, func(r *models.PitchResponse) string { return r.Solution }},
+	{"Indicateurs clés", 2, 1, 2, 1, empty},
+	{"Proposition de valeur unique", 4, 0, 2, 2, func(r *models.PitchResponse) string { return r.Valeur }},
+	{"Avantage déloyal", 6, 0, 2, 1, empty},
+	{"Canaux", 6, 1, 2, 1, func(r *models.PitchResponse) string { return r.Canaux }},
+	{"Segments de clientèle", 8, 0, 2, 2, func(r *models.PitchResponse) string { return r.Marche }},
+	{"Structure de coûts", 0, 2, 5, 1, empty},
+	{"Sources de revenus", 5, 2, 5, 1, func(r *models.PitchResponse) string { return r.Modele }},
+}}
+
+// BusinessModelCanvas est le Business Model Canvas d'Alexander Osterwalder
+var BusinessModelCanvas = Canvas{Name: "bmc", Title: "Business Model Canvas", blocks: []canvasBlock{
+	{"Partenaires clés", 0, 0, 2, 2, empty},
+	{"Activités clés", 2, 0, 2, 1, func(r *models.PitchResponse) string { return r.Solution }},
+	{"Ressources clés", 2, 1, 2, 1, empty},
+	{"Propositions de valeur", 4, 0, 2, 2, func(r *models.PitchResponse) string {
+		if r.Probleme == "" {
+			return r.Valeur
+		}
+		return r.Valeur + "\nProblème résolu : " + r.Probleme
+	}},
+	{"Relations clients", 6, 0, 2, 1, empty},
+	{"Canaux", 6, 1, 2, 1, func(r *models.PitchResponse) string { return r.Canaux }},
+	{"Segments de clientèle", 8, 0, 2, 2, func(r *models.PitchResponse) string { return r.Marche }},
+	{"Structure de coûts", 0, 2, 5, 1, empty},
+	{"Sources de revenus", 5, 2, 5, 1, func(r *models.PitchResponse) string { return r.Modele }},
+}}
+
+// Canvases retourne les canvas disponibles
+func Canvases() []Canvas {
+	return []Canvas{LeanCanvas, BusinessModelCanvas}
+}
+
+// Dimensions et typographie du canvas (en pixels)
+const (
+	canvasWidth    = 1600
+	canvasHeight   = 1000
+	canvasMargin   = 24
+	canvasHeader   = 96
+	blockPadding   = 14
+	blockTitleSize = 16
+	maxFontSize    = 20
+	minFontSize    = 9
+	lineSpacing    = 1.3
+	placeholder    = "À compléter"
+)
+
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorBorder     = color.RGBA{0x1e, 0x3a, 0x8a, 0xff}
+	colorTitle      = color.RGBA{0x1e, 0x3a, 0x8a, 0xff}
+	colorText       = color.RGBA{0x1f, 0x29, 0x37, 0xff}
+	colorMuted      = color.RGBA{0x9c, 0xa3, 0xaf, 0xff}
+)
+
+var (
+	fontsOnce   sync.Once
+	regularFont *opentype.Font
+	boldFont    *opentype.Font
+)
+
+// faces crée les polices Go à la demande ; un opentype.Face n'est pas utilisable en concurrence,
+// chaque rendu a donc son propre jeu de faces
+type faces map[[2]float64]font.Face
+
+func (f faces) get(bold bool, size float64) font.Face {
+	fontsOnce.Do(func() {
+		regularFont, _ = opentype.Parse(goregular.TTF)
+		boldFont, _ = opentype.Parse(gobold.TTF)
+	})
+	key := [2]float64{size, 0}
+	src := regularFont
+	if bold {
+		key[1] = 1
+		src = boldFont
+	}
+	if face, ok := f[key]; ok {
+		return face
+	}
+	face, _ := opentype.NewFace(src, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	f[key] = face
+	return face
+}
+
+func measure(face font.Face, s string) float64 {
+	return float64(font.MeasureString(face, s)) / 64
+}
+
+func ascent(face font.Face) float64 {
+	return float64(face.Metrics().Ascent) / 64
+}
+
+// placedBlock est un bloc mis en page : position en pixels et texte découpé en lignes
+type placedBlock struct {
+	X, Y, W, H  float64
+	Title       string
+	Lines       []string
+	FontSize    float64
+	Placeholder bool
+}
+
+type canvasLayout struct {
+	Title    string // nom du canvas
+	Subtitle string // projet
+	Blocks   []placedBlock
+}
+
+// layoutCanvas place les blocs et choisit pour chacun la plus grande taille de police qui fait tenir le texte
+func layoutCanvas(c Canvas, p *models.Pitch, ff faces) canvasLayout {
+	sections := p.Sections
+	if sections == nil {
+		sections = &models.PitchResponse{}
+	}
+
+	gridW := float64(canvasWidth-2*canvasMargin) / 10
+	gridH := float64(canvasHeight-canvasHeader-canvasMargin) / 3
+
+	subtitleFace := ff.get(false, 18)
+	l := canvasLayout{
+		Title:    c.Title,
+		Subtitle: truncate(subtitleFace, strings.Join(strings.Fields(p.Description), " "), canvasWidth-2*canvasMargin),
+	}
+
+	for _, b := range c.blocks {
+		pb := placedBlock{
+			X:     canvasMargin + float64(b.X)*gridW,
+			Y:     canvasHeader + float64(b.Y)*gridH,
+			W:     float64(b.W) * gridW,
+			H:     float64(b.H) * gridH,
+			Title: b.Title,
+		}
+		text := strings.TrimSpace(b.Content(sections))
+		if text == "" {
+			text = placeholder
+			pb.Placeholder = true
+		}
+
+		textW := pb.W - 2*blockPadding
+		textH := pb.H - 2*blockPadding - blockTitleSize*1.6
+		for size := float64(maxFontSize); ; size -= 0.5 {
+			face := ff.get(false, size)
+			lines := wrapText(face, text, textW)
+			maxLines := int(textH / (size * lineSpacing))
+			if len(lines) <= maxLines || size <= minFontSize {
+				if len(lines) > maxLines {
+					// Même à la taille minimale le texte déborde : couper avec des points de suspension
+					lines = lines[:max(maxLines, 1)]
+					last := len(lines) - 1
+					lines[last] = truncate(face, lines[last]+" …", textW)
+				}
+				pb.Lines, pb.FontSize = lines, size
+				break
+			}
+		}
+		l.Blocks = append(l.Blocks, pb)
+	}
+	return l
+}
+
+// wrapText découpe le texte en lignes de largeur maximale width ; les mots trop longs sont coupés
+func wrapText(face font.Face, text string, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if measure(face, candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for measure(face, word) > width && utf8.RuneCountInString(word) > 1 {
+				n := fitRunes(face, word, width)
+				lines = append(lines, word[:n])
+				word = word[n:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes retourne la longueur en octets du plus long préfixe de s (au moins une rune) qui tient dans width
+func fitRunes(face font.Face, s string, width float64) int {
+	end := 0
+	for i, r := range s {
+		next := i + utf8.RuneLen(r)
+		if end > 0 && measure(face, s[:next]) > width {
+			break
+		}
+		end = next
+	}
+	return end
+}
+
+// truncate raccourcit s avec « … » pour qu'il tienne dans width
+func truncate(face font.Face, s string, width float64) string {
+	if measure(face, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && measure(face, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// CanvasSVG écrit le canvas du pitch en SVG autonome, intégrable dans une page ou un document
+func CanvasSVG(w io.Writer, c Canvas, p *models.Pitch) error {
+	l := layoutCanvas(c, p, faces{})
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Go, 'Helvetica Neue', Arial, sans-serif">`+"\n",
+		canvasWidth, canvasHeight, canvasWidth, canvasHeight)
+	fmt.Fprintf(&b, `<title>%s</title>`+"\n", esc(l.Title+" — "+l.Subtitle))
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(colorBackground))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="30" font-weight="bold" fill="%s">%s</text>`+"\n", canvasMargin, 46, hexColor(colorTitle), esc(l.Title))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="18" fill="%s">%s</text>`+"\n", canvasMargin, 76, hexColor(colorText), esc(l.Subtitle))
+
+	ff := faces{}
+	for _, pb := range l.Blocks {
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+			pb.X, pb.Y, pb.W, pb.H, hexColor(colorBorder))
+		titleY := pb.Y + blockPadding + ascent(ff.get(true, blockTitleSize))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="%d" font-weight="bold" fill="%s">%s</text>`+"\n",
+			pb.X+blockPadding, titleY, blockTitleSize, hexColor(colorTitle), esc(pb.Title))
+
+		fill := colorText
+		if pb.Placeholder {
+			fill = colorMuted
+		}
+		y := pb.Y + blockPadding + blockTitleSize*1.6 + ascent(ff.get(false, pb.FontSize))
+		for _, line := range pb.Lines {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="%g" fill="%s" xml:space="preserve">%s</text>`+"\n",
+				pb.X+blockPadding, y, pb.FontSize, hexColor(fill), esc(line))
+			y += pb.FontSize * lineSpacing
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// CanvasPNG écrit le canvas du pitch en PNG, rastérisé en Go avec les polices Go
+func CanvasPNG(w io.Writer, c Canvas, p *models.Pitch) error {
+	ff := faces{}
+	l := layoutCanvas(c, p, ff)
+	img := image.NewRGBA(image.Rect(0, 0, canvasWidth, canvasHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+
+	drawText(img, ff.get(true, 30), colorTitle, canvasMargin, 46, l.Title)
+	drawText(img, ff.get(false, 18), colorText, canvasMargin, 76, l.Subtitle)
+
+	for _, pb := range l.Blocks {
+		strokeRect(img, pb.X, pb.Y, pb.W, pb.H, 2, colorBorder)
+		titleFace := ff.get(true, blockTitleSize)
+		drawText(img, titleFace, colorTitle, pb.X+blockPadding, pb.Y+blockPadding+ascent(titleFace), pb.Title)
+
+		fill := colorText
+		if pb.Placeholder {
+			fill = colorMuted
+		}
+		face := ff.get(false, pb.FontSize)
+		y := pb.Y + blockPadding + blockTitleSize*1.6 + ascent(face)
+		for _, line := range pb.Lines {
+			drawText(img, face, fill, pb.X+blockPadding, y, line)
+			y += pb.FontSize * lineSpacing
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// drawText écrit s avec sa ligne de base en (x, y)
+func drawText(img draw.Image, face font.Face, c color.Color, x, y float64, s string) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)},
+	}
+	d.DrawString(s)
+}
+
+// strokeRect trace le contour d'un rectangle, centré sur ses bords comme le stroke SVG
+func strokeRect(img draw.Image, x, y, w, h float64, thickness int, c color.Color) {
+	src := image.NewUniform(c)
+	x0, y0 := int(x)-thickness/2, int(y)-thickness/2
+	x1, y1 := int(x+w)+thickness/2, int(y+h)+thickness/2
+	for _, r := range []image.Rectangle{
+		image.Rect(x0, y0, x1, y0+thickness), // haut
+		image.Rect(x0, y1-thickness, x1, y1), // bas
+		image.Rect(x0, y0, x0+thickness, y1), // gauche
+		image.Rect(x1-thickness, y0, x1, y1), // droite
+	} {
+		draw.Draw(img, r, src, image.Point{}, draw.Src)
+	}
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// registerCanvas enregistre les exports SVG et PNG d'un canvas (lean-svg, lean-png…)
+func registerCanvas(c Canvas) {
+	Register(Format{
+		Name: c.Name + "-svg", Label: c.Title + " (SVG)", MediaTypes: []string{"image/svg+xml"}, Extension: ".svg",
+		Render: func(w io.Writer, p *models.Pitch) error { return CanvasSVG(w, c, p) },
+	})
+	Register(Format{
+		Name: c.Name + "-png", Label: c.Title + " (PNG)", MediaTypes: []string{"image/png"}, Extension: ".png",
+		Render: func(w io.Writer, p *models.Pitch) error { return CanvasPNG(w, c, p) },
+	})
+}
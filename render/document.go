@@ -0,0 +1,68 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pitch/models"
+)
+
+// document est le plan commun des exports bureautiques (DOCX, ODT) :
+// page de garde, sommaire puis un chapitre par section du pitch.
+type document struct {
+	Title    string
+	Subtitle string
+	Date     string
+	Created  time.Time
+	Chapters []Section
+}
+
+// maxTitleLength borne le titre de la page de garde, tiré de la première phrase de la description
+const maxTitleLength = 80
+
+func newDocument(p *models.Pitch) document {
+	created := p.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	title := strings.TrimSpace(p.Description)
+	if i := strings.IndexAny(title, ".\n"); i > 0 {
+		title = title[:i]
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength-1]) + "…"
+	}
+
+	return document{
+		Title:    title,
+		Subtitle: p.Description,
+		Date:     "Document généré le " + created.Format("02/01/2006"),
+		Created:  created,
+		Chapters: Sections(p.Sections),
+	}
+}
+
+// paragraphs découpe un contenu en paragraphes non vides
+func paragraphs(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	if len(out) == 0 {
+		out = []string{""}
+	}
+	return out
+}
+
+// esc échappe un texte pour l'insérer dans un document XML
+func esc(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"pitch/models"
+)
+
+const odtMimeType = "application/vnd.oasis.opendocument.text"
+
+// ODT écrit le pitch en document OpenDocument Text : page de garde, sommaire et un chapitre par section.
+// Le sommaire est pré-rempli ; LibreOffice ajoute les numéros de page via Outils > Mettre à jour.
+func ODT(w io.Writer, p *models.Pitch) error {
+	doc := newDocument(p)
+	zw := zip.NewWriter(w)
+
+	// Le fichier mimetype doit être le premier de l'archive, non compressé
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, odtMimeType); err != nil {
+		return err
+	}
+
+	files := []struct{ name, content string }{
+		{"META-INF/manifest.xml", odtManifest},
+		{"meta.xml", odtMeta(doc)},
+		{"styles.xml", odtStyles},
+		{"content.xml", odtContent(doc)},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+const odtManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+<manifest:file-entry manifest:full-path="/" manifest:media-type="` + odtMimeType + `"/>
+<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>`
+
+func odtMeta(doc document) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/" office:version="1.2">
+<office:meta>
+<dc:title>%s</dc:title>
+<meta:initial-creator>Assistant Pitch AI</meta:initial-creator>
+<dc:language>fr-FR</dc:language>
+<meta:creation-date>%s</meta:creation-date>
+</office:meta>
+</office:document-meta>`, esc(doc.Title), doc.Created.UTC().Format(time.RFC3339))
+}
+
+const odtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" office:version="1.2">
+<office:styles>
+<style:default-style style:family="paragraph">
+<style:paragraph-properties fo:margin-bottom="0.28cm" fo:line-height="115%"/>
+<style:text-properties style:font-name="Liberation Sans" fo:font-family="'Liberation Sans', Calibri, sans-serif" fo:font-size="11pt" fo:language="fr" fo:country="FR"/>
+</style:default-style>
+<style:style style:name="Standard" style:family="paragraph" style:class="text">
+<style:paragraph-properties fo:text-align="justify"/>
+</style:style>
+<style:style style:name="Title" style:family="paragraph" style:parent-style-name="Standard" style:class="chapter">
+<style:paragraph-properties fo:text-align="center" fo:margin-top="8cm" fo:margin-bottom="0.8cm"/>
+<style:text-properties fo:font-size="28pt" fo:font-weight="bold" fo:color="#1e3a8a"/>
+</style:style>
+<style:style style:name="Subtitle" style:family="paragraph" style:parent-style-name="Standard" style:class="chapter">
+<style:paragraph-properties fo:text-align="center" fo:margin-bottom="1.7cm"/>
+<style:text-properties fo:font-size="14pt" fo:font-style="italic" fo:color="#4b5563"/>
+</style:style>
+<style:style style:name="Date" style:family="paragraph" style:parent-style-name="Standard">
+<style:paragraph-properties fo:text-align="center"/>
+</style:style>
+<style:style style:name="Heading_20_1" style:display-name="Heading 1" style:family="paragraph" style:parent-style-name="Standard" style:default-outline-level="1" style:class="text">
+<style:paragraph-properties fo:text-align="start" fo:break-before="page" fo:margin-top="0.4cm" fo:margin-bottom="0.4cm" fo:keep-with-next="always"/>
+<style:text-properties fo:font-size="18pt" fo:font-weight="bold" fo:color="#1e3a8a"/>
+</style:style>
+<style:style style:name="Contents_20_Heading" style:display-name="Contents Heading" style:family="paragraph" style:parent-style-name="Standard" style:class="index">
+<style:paragraph-properties fo:text-align="start" fo:break-before="page" fo:margin-bottom="0.4cm"/>
+<style:text-properties fo:font-size="18pt" fo:font-weight="bold" fo:color="#1e3a8a"/>
+</style:style>
+<style:style style:name="Contents_20_1" style:display-name="Contents 1" style:family="paragraph" style:parent-style-name="Standard" style:class="index">
+<style:paragraph-properties fo:text-align="start">
+<style:tab-stops><style:tab-stop style:position="16cm" style:type="right" style:leader-style="dotted" style:leader-text="."/></style:tab-stops>
+</style:paragraph-properties>
+</style:style>
+</office:styles>
+<office:automatic-styles>
+<style:page-layout style:name="A4">
+<style:page-layout-properties fo:page-width="21cm" fo:page-height="29.7cm" fo:margin-top="2.5cm" fo:margin-bottom="2.5cm" fo:margin-left="2.5cm" fo:margin-right="2.5cm"/>
+</style:page-layout>
+</office:automatic-styles>
+<office:master-styles>
+<style:master-page style:name="Standard" style:page-layout-name="A4"/>
+</office:master-styles>
+</office:document-styles>`
+
+func odtContent(doc document) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:xlink="http://www.w3.org/1999/xlink" office:version="1.2">
+<office:body><office:text>`)
+
+	// Page de garde
+	fmt.Fprintf(&b, `<text:p text:style-name="Title">%s</text:p>`, esc(doc.Title))
+	for _, para := range paragraphs(doc.Subtitle) {
+		fmt.Fprintf(&b, `<text:p text:style-name="Subtitle">%s</text:p>`, esc(para))
+	}
+	fmt.Fprintf(&b, `<text:p text:style-name="Date">%s</text:p>`, esc(doc.Date))
+
+	// Sommaire : index des titres de niveau 1, pré-rempli avec des liens vers les chapitres
+	b.WriteString(`<text:table-of-content text:name="Sommaire" text:protected="true">
+<text:table-of-content-source text:outline-level="1">
+<text:index-title-template text:style-name="Contents_20_Heading">Sommaire</text:index-title-template>
+<text:table-of-content-entry-template text:outline-level="1" text:style-name="Contents_20_1">
+<text:index-entry-link-start/><text:index-entry-chapter/><text:index-entry-text/><text:index-entry-tab-stop style:type="right" style:leader-char="."/><text:index-entry-page-number/><text:index-entry-link-end/>
+</text:table-of-content-entry-template>
+</text:table-of-content-source>
+<text:index-body>
+<text:index-title text:name="Sommaire_Head"><text:p text:style-name="Contents_20_Heading">Sommaire</text:p></text:index-title>`)
+	for i, ch := range doc.Chapters {
+		fmt.Fprintf(&b, `<text:p text:style-name="Contents_20_1"><text:a xlink:type="simple" xlink:href="#chapitre%d">%d. %s</text:a></text:p>`, i+1, i+1, esc(ch.Title))
+	}
+	b.WriteString(`</text:index-body></text:table-of-content>`)
+
+	// Un chapitre par section, chacun sur une nouvelle page (style Heading 1)
+	for i, ch := range doc.Chapters {
+		fmt.Fprintf(&b, `<text:h text:style-name="Heading_20_1" text:outline-level="1"><text:bookmark text:name="chapitre%d"/>%d. %s</text:h>`, i+1, i+1, esc(ch.Title))
+		for _, para := range paragraphs(ch.Content) {
+			fmt.Fprintf(&b, `<text:p text:style-name="Standard">%s</text:p>`, esc(para))
+		}
+	}
+
+	b.WriteString(`</office:text></office:body></office:document-content>`)
+	return b.String()
+}
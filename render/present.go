@@ -0,0 +1,108 @@
+package render
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"html/template"
+	"io"
+	"strings"
+
+	"pitch/models"
+)
+
+// Les assets de la présentation sont embarqués dans le binaire et insérés dans la page :
+// elle fonctionne hors ligne et peut être enregistrée en un seul fichier HTML.
+//
+//go:embed present
+var presentFiles embed.FS
+
+var presentTemplate = template.Must(template.ParseFS(presentFiles, "present/present.html"))
+
+// maxSlidePoints borne le nombre de puces par diapositive ; le texte complet reste dans les notes
+const maxSlidePoints = 4
+
+// Slide est une diapositive de la présentation d'un pitch
+type Slide struct {
+	Kind     string // title, section, canvas ou end
+	Title    string
+	Subtitle string
+	Points   []string
+	Image    template.URL // image intégrée (data URI)
+	Notes    string       // notes de l'orateur
+}
+
+// Slides découpe le pitch en diapositives : titre, une diapositive par section, Lean Canvas et conclusion
+func Slides(p *models.Pitch) []Slide {
+	doc := newDocument(p)
+	description := strings.Join(strings.Fields(p.Description), " ")
+	title := Slide{Kind: "title", Title: doc.Title, Notes: "Présentez-vous puis annoncez le projet en une phrase : " + description}
+	if description != doc.Title {
+		title.Subtitle = description
+	}
+	slides := []Slide{title}
+
+	for _, s := range doc.Chapters {
+		points := sentences(s.Content)
+		if len(points) > maxSlidePoints {
+			points = points[:maxSlidePoints]
+		}
+		slides = append(slides, Slide{Kind: "section", Title: s.Title, Points: points, Notes: s.Content})
+	}
+
+	var svg bytes.Buffer
+	if err := CanvasSVG(&svg, LeanCanvas, p); err == nil {
+		slides = append(slides, Slide{
+			Kind:  "canvas",
+			Title: LeanCanvas.Title,
+			Image: template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg.Bytes())),
+			Notes: "Récapitulez le modèle en une vue : problème, solution, clients et revenus. Les blocs « À compléter » sont les prochaines étapes.",
+		})
+	}
+
+	return append(slides, Slide{
+		Kind:     "end",
+		Title:    "Merci !",
+		Subtitle: "Questions ?",
+		Notes:    "Rappelez la proposition de valeur en une phrase et ce que vous attendez du public (financement, partenaires, retours).",
+	})
+}
+
+// sentences découpe un texte en phrases, une par puce
+func sentences(s string) []string {
+	var out []string
+	for _, para := range paragraphs(s) {
+		start := 0
+		runes := []rune(para)
+		for i, r := range runes {
+			if strings.ContainsRune(".!?…", r) && (i+1 == len(runes) || runes[i+1] == ' ') {
+				if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+					out = append(out, sentence)
+				}
+				start = i + 1
+			}
+		}
+		if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+			out = append(out, rest)
+		}
+	}
+	return out
+}
+
+// Presentation écrit le pitch en présentation HTML autonome, navigable au clavier
+func Presentation(w io.Writer, p *models.Pitch) error {
+	css, err := presentFiles.ReadFile("present/present.css")
+	if err != nil {
+		return err
+	}
+	js, err := presentFiles.ReadFile("present/present.js")
+	if err != nil {
+		return err
+	}
+	return presentTemplate.Execute(w, struct {
+		Title  string
+		Slides []Slide
+		CSS    template.CSS
+		JS     template.JS
+	}{newDocument(p).Title, Slides(p), template.CSS(css), template.JS(js)})
+}
@@ -0,0 +1,125 @@
+// Navigation au clavier de la présentation. La diapositive courante est dans l'ancre (#3)
+// pour que le rechargement et les liens conservent la position ; la vue orateur (#orateur)
+// est synchronisée avec la fenêtre de présentation par BroadcastChannel.
+(function () {
+    "use strict";
+
+    var slides = Array.prototype.slice.call(document.querySelectorAll(".slide"));
+    var speaker = location.hash.indexOf("orateur") !== -1;
+    var channel = "BroadcastChannel" in window ? new BroadcastChannel("pitch-present:" + location.pathname) : null;
+    var current = 0;
+    var started = Date.now();
+
+    function clamp(i) {
+        return Math.max(0, Math.min(slides.length - 1, i));
+    }
+
+    function show(i, broadcast) {
+        current = clamp(i);
+        slides.forEach(function (s, j) {
+            s.classList.toggle("active", j === current);
+        });
+        document.querySelector(".progress-bar").style.width = (100 * (current + 1) / slides.length) + "%";
+        document.querySelector(".counter").textContent = (current + 1) + " / " + slides.length;
+        if (speaker) {
+            renderSpeaker();
+        } else {
+            history.replaceState(null, "", "#" + (current + 1));
+        }
+        if (broadcast && channel) {
+            channel.postMessage({ slide: current });
+        }
+    }
+
+    function renderSpeaker() {
+        var s = slides[current];
+        var next = slides[current + 1];
+        document.querySelector(".speaker-slide").textContent = "Diapositive " + (current + 1) + " / " + slides.length;
+        document.querySelector(".speaker-title").textContent = s.querySelector("h1").textContent;
+        document.querySelector(".speaker-notes").textContent = s.querySelector(".notes").textContent;
+        document.querySelector(".speaker-next").textContent = next ? "Ensuite : " + next.querySelector("h1").textContent : "Dernière diapositive";
+    }
+
+    function tick() {
+        var seconds = Math.floor((Date.now() - started) / 1000);
+        var mm = String(Math.floor(seconds / 60)).padStart(2, "0");
+        var ss = String(seconds % 60).padStart(2, "0");
+        document.querySelector(".speaker-timer").textContent = mm + ":" + ss;
+    }
+
+    // Le chronomètre de la vue orateur part à son ouverture ; touche T pour le remettre à zéro
+    document.addEventListener("keydown", function (e) {
+        if (e.ctrlKey || e.metaKey || e.altKey) {
+            return;
+        }
+        switch (e.key) {
+        case "ArrowRight": case "ArrowDown": case "PageDown": case " ": case "Enter":
+            show(current + 1, true);
+            break;
+        case "ArrowLeft": case "ArrowUp": case "PageUp": case "Backspace":
+            show(current - 1, true);
+            break;
+        case "Home":
+            show(0, true);
+            break;
+        case "End":
+            show(slides.length - 1, true);
+            break;
+        case "n": case "N":
+            document.body.classList.toggle("show-notes");
+            break;
+        case "s": case "S":
+            window.open(location.pathname + "#orateur", "orateur", "width=900,height=600");
+            break;
+        case "f": case "F":
+            if (document.fullscreenElement) {
+                document.exitFullscreen();
+            } else if (document.documentElement.requestFullscreen) {
+                document.documentElement.requestFullscreen();
+            }
+            break;
+        case "t": case "T":
+            started = Date.now();
+            tick();
+            break;
+        case "?":
+            var help = document.querySelector(".help");
+            help.hidden = !help.hidden;
+            break;
+        case "Escape":
+            document.querySelector(".help").hidden = true;
+            break;
+        default:
+            return;
+        }
+        e.preventDefault();
+    });
+
+    // Un clic avance d'une diapositive
+    document.querySelector(".deck").addEventListener("click", function () {
+        show(current + 1, true);
+    });
+
+    if (channel) {
+        channel.onmessage = function (e) {
+            if (typeof e.data.slide === "number") {
+                show(e.data.slide, false);
+            } else if (e.data.hello && !speaker) {
+                channel.postMessage({ slide: current });
+            }
+        };
+    }
+
+    if (speaker) {
+        document.body.classList.add("speaker-mode");
+        document.querySelector(".speaker").hidden = false;
+        setInterval(tick, 1000);
+        show(0, false);
+        if (channel) {
+            channel.postMessage({ hello: true });
+        }
+        return;
+    }
+
+    show((parseInt(location.hash.slice(1), 10) || 1) - 1, false);
+})();
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pitch/models"
+)
+
+// Format est un format d'export enregistré : il est proposé en téléchargement (?format=Name)
+// et sélectionnable par négociation de contenu (en-tête Accept) sur ses MediaTypes.
+type Format struct {
+	Name       string   // identifiant utilisé dans ?format=
+	Label      string   // libellé affiché sur les liens de téléchargement
+	MediaTypes []string // le premier est envoyé dans Content-Type, les suivants sont des alias acceptés
+	Extension  string   // extension du fichier téléchargé, point compris
+	Aliases    []string // autres valeurs acceptées dans ?format=
+	Billable   bool     // document complet dont le téléchargement est décompté des crédits
+	Render     func(io.Writer, *models.Pitch) error
+}
+
+// ContentType retourne l'en-tête Content-Type du format
+func (f Format) ContentType() string {
+	ct := f.MediaTypes[0]
+	if strings.HasPrefix(ct, "text/") {
+		ct += "; charset=utf-8"
+	}
+	return ct
+}
+
+var registry []Format
+
+// Register ajoute un format ; l'ordre d'enregistrement est l'ordre d'affichage des liens
+func Register(f Format) {
+	registry = append(registry, f)
+}
+
+// Formats retourne les formats enregistrés
+func Formats() []Format {
+	return append([]Format(nil), registry...)
+}
+
+// Lookup retourne le format désigné par son nom, un alias ou son extension
+func Lookup(name string) (Format, bool) {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), ".")
+	for _, f := range registry {
+		if f.Name == name || strings.TrimPrefix(f.Extension, ".") == name {
+			return f, true
+		}
+		for _, a := range f.Aliases {
+			if a == name {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// ByMediaType retourne le format qui sert ce type MIME
+func ByMediaType(mediaType string) (Format, bool) {
+	for _, f := range registry {
+		for _, mt := range f.MediaTypes {
+			if mt == mediaType {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+func init() {
+	Register(Format{Name: "markdown", Label: "Markdown", MediaTypes: []string{"text/markdown", "text/x-markdown"}, Extension: ".md", Aliases: []string{"md"}, Render: Markdown})
+	Register(Format{Name: "text", Label: "Texte", MediaTypes: []string{"text/plain"}, Extension: ".txt", Aliases: []string{"txt", "plain"}, Render: Text})
+	Register(Format{Name: "json", Label: "JSON", MediaTypes: []string{"application/json"}, Extension: ".json", Render: JSON})
+	Register(Format{Name: "yaml", Label: "YAML", MediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"}, Extension: ".yaml", Aliases: []string{"yml"}, Render: YAML})
+	Register(Format{Name: "docx", Label: "Word", MediaTypes: []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"}, Extension: ".docx", Aliases: []string{"word"}, Billable: true, Render: DOCX})
+	Register(Format{Name: "odt", Label: "OpenDocument", MediaTypes: []string{odtMimeType}, Extension: ".odt", Billable: true, Render: ODT})
+	for _, c := range Canvases() {
+		registerCanvas(c)
+	}
+}
+
+// Negotiate choisit parmi offers (types MIME, par ordre de préférence du serveur) celui que
+// l'en-tête Accept préfère. Sans en-tête Accept, le premier type proposé est retenu.
+// Retourne "" si aucun type proposé n'est acceptable.
+func Negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, candidate{mediaType, q})
+	}
+	// Les plages les plus spécifiques l'emportent à qualité égale (text/markdown avant text/* avant */*)
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return strings.Count(ranges[i].mediaType, "*") < strings.Count(ranges[j].mediaType, "*")
+	})
+
+	// Un type explicitement refusé (q=0) n'est pas retenu via un joker
+	refused := map[string]bool{}
+	for _, rg := range ranges {
+		if rg.q <= 0 {
+			refused[rg.mediaType] = true
+		}
+	}
+
+	for _, rg := range ranges {
+		if rg.q <= 0 {
+			continue
+		}
+		for _, offer := range offers {
+			if !refused[offer] && matchMediaRange(rg.mediaType, offer) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+func matchMediaRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
+
+// MediaTypes retourne les types MIME de tous les formats, sans doublon (utile pour construire les offres de Negotiate)
+func MediaTypes() []string {
+	var types []string
+	seen := map[string]bool{}
+	for _, f := range registry {
+		for _, mt := range f.MediaTypes {
+			if !seen[mt] {
+				seen[mt] = true
+				types = append(types, mt)
+			}
+		}
+	}
+	return types
+}
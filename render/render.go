@@ -0,0 +1,94 @@
+// Package render met en forme un pitch dans les formats d'export (texte, Markdown, JSON, YAML).
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"pitch/models"
+)
+
+// Section est une section du pitch avec son libellé
+type Section struct {
+	Key     string // tag json de la section dans PitchResponse
+	Title   string
+	Content string
+}
+
+// Sections retourne les 6 sections du pitch dans l'ordre de présentation
+func Sections(r *models.PitchResponse) []Section {
+	if r == nil {
+		r = &models.PitchResponse{}
+	}
+	return []Section{
+		{"probleme", "Problème", r.Probleme},
+		{"solution", "Solution", r.Solution},
+		{"marche", "Marché", r.Marche},
+		{"valeur", "Proposition de valeur", r.Valeur},
+		{"canaux", "Canaux", r.Canaux},
+		{"modele", "Modèle économique", r.Modele},
+	}
+}
+
+// Text écrit le pitch en texte brut : chaque libellé en majuscules suivi de son contenu
+func Text(w io.Writer, p *models.Pitch) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", p.Description)
+	for _, s := range Sections(p.Sections) {
+		fmt.Fprintf(&b, "%s\n%s\n\n", strings.ToUpper(s.Title), s.Content)
+	}
+	_, err := io.WriteString(w, strings.TrimRight(b.String(), "\n")+"\n")
+	return err
+}
+
+// Markdown écrit le pitch en Markdown : la description en citation puis une section de niveau 2 par partie
+func Markdown(w io.Writer, p *models.Pitch) error {
+	var b strings.Builder
+	b.WriteString("# Pitch\n\n")
+	for _, line := range strings.Split(p.Description, "\n") {
+		fmt.Fprintf(&b, "> %s\n", line)
+	}
+	for _, s := range Sections(p.Sections) {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", s.Title, s.Content)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// JSON écrit le pitch au format JSON indenté (mêmes champs que l'API)
+func JSON(w io.Writer, p *models.Pitch) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// YAML écrit le pitch en YAML ; les chaînes sont toujours entre guillemets doubles pour rester valides
+func YAML(w io.Writer, p *models.Pitch) error {
+	var b strings.Builder
+	if p.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", yamlString(p.ID))
+	}
+	fmt.Fprintf(&b, "description: %s\n", yamlString(p.Description))
+	if !p.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "created_at: %s\n", p.CreatedAt.Format(time.RFC3339))
+	}
+	b.WriteString("sections:\n")
+	for _, s := range Sections(p.Sections) {
+		fmt.Fprintf(&b, "  %s: %s\n", s.Key, yamlString(s.Content))
+	}
+	if p.Sections != nil && p.Sections.Demo {
+		b.WriteString("demo: true\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// yamlString retourne une chaîne YAML entre guillemets doubles ; les échappements de
+// strconv.Quote (\n, \", \t, \xNN, \uNNNN) sont tous valides en YAML.
+func yamlString(s string) string {
+	return strconv.Quote(s)
+}
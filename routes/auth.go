@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"pitch/controllers"
+	"pitch/service"
+)
+
+// apiKeyFromRequest lit la clé API depuis X-API-Key ou Authorization: Bearer
+func apiKeyFromRequest(r *http.Request) string {
+	if k := r.Header.Get("X-API-Key"); k != "" {
+		return k
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// apiKeyMiddleware exige une clé API valide disposant du scope demandé
+func apiKeyMiddleware(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw := apiKeyFromRequest(r)
+		if raw == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pitch-ia"`)
+			controllers.WriteProblem(w, r, http.StatusUnauthorized, "Clé API manquante (en-tête X-API-Key ou Authorization: Bearer).")
+			return
+		}
+
+		key, err := service.AuthenticateAPIKey(raw, scope)
+		switch {
+		case errors.Is(err, service.ErrInvalidAPIKey):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pitch-ia", error="invalid_token"`)
+			controllers.WriteProblem(w, r, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, service.ErrScopeDenied):
+			controllers.WriteProblem(w, r, http.StatusForbidden, err.Error()+" (scope requis : "+scope+")")
+			return
+		case err != nil:
+			controllers.WriteProblem(w, r, http.StatusInternalServerError, "Vérification de la clé API impossible.")
+			return
+		}
+
+		next(w, r.WithContext(service.WithAPIKey(r.Context(), key)))
+	}
+}
+
+// apiQuotaMiddleware décompte l'appel du quota journalier de la clé API authentifiée.
+// Placé après la limite de débit : une requête refusée en 429 ne consomme pas le quota.
+func apiQuotaMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if k := service.APIKeyFromContext(r.Context()); k != nil {
+			switch err := service.ConsumeAPIKeyQuota(k.ID); {
+			case errors.Is(err, service.ErrQuotaExceeded):
+				controllers.WriteProblem(w, r, http.StatusTooManyRequests, err.Error())
+				return
+			case errors.Is(err, service.ErrInvalidAPIKey):
+				controllers.WriteProblem(w, r, http.StatusUnauthorized, err.Error())
+				return
+			case err != nil:
+				controllers.WriteProblem(w, r, http.StatusInternalServerError, "Vérification de la clé API impossible.")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// adminMiddleware protège les pages d'administration par le jeton ADMIN_TOKEN (Basic Auth, utilisateur libre).
+// Sans ADMIN_TOKEN, l'administration est désactivée.
+func adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		_, pass, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(pass), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="pitch-ia admin"`)
+			http.Error(w, "Accès réservé aux administrateurs", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// sessionMiddleware attache l'utilisateur connecté (cookie de session) au contexte de la requête,
+// ainsi que l'organisation choisie comme espace de travail s'il en est toujours membre
+func sessionMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie(controllers.SessionCookieName); err == nil {
+			if u, ok := service.SessionUser(c.Value); ok {
+				ctx := service.WithUser(r.Context(), u)
+				if ws, err := r.Cookie(controllers.WorkspaceCookieName); err == nil && service.IsMember(u.ID, ws.Value) {
+					ctx = service.WithWorkspace(ctx, ws.Value)
+				}
+				r = r.WithContext(ctx)
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requireUser redirige vers la page de connexion si aucun utilisateur n'est connecté
+func requireUser(next http.HandlerFunc) http.HandlerFunc {
+	return sessionMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if service.UserFromContext(r.Context()) == nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pitch/service"
+)
+
+func TestRateLimitedCallsKeepQuota(t *testing.T) {
+	raw, key, err := service.CreateAPIKey("quota", []string{service.ScopePitchesRead}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	call := func(h http.HandlerFunc) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/pitches", nil)
+		r.Header.Set("X-API-Key", raw)
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w.Code
+	}
+	usage := func() int {
+		for _, k := range service.ListAPIKeys() {
+			if k.ID == key.ID {
+				return k.UsageCount
+			}
+		}
+		return -1
+	}
+
+	// Une requête par minute : les suivantes sont refusées par la limite de débit sans entamer le quota
+	limited := apiKeyMiddleware(service.ScopePitchesRead, rateLimitMiddleware("test-quota", "1/m", apiQuotaMiddleware(ok)))
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		if got := call(limited); got != want {
+			t.Fatalf("appel %d : statut %d, veut %d", i+1, got, want)
+		}
+	}
+	if got := usage(); got != 1 {
+		t.Errorf("quota consommé = %d, veut 1", got)
+	}
+
+	// Le quota de deux appels par jour reste disponible pour le second appel admis
+	unlimited := apiKeyMiddleware(service.ScopePitchesRead, apiQuotaMiddleware(ok))
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		if got := call(unlimited); got != want {
+			t.Errorf("appel %d sans limite de débit : statut %d, veut %d", i+1, got, want)
+		}
+	}
+	if got := usage(); got != 2 {
+		t.Errorf("quota consommé = %d, veut 2", got)
+	}
+
+	// Une clé révoquée est refusée avant tout décompte
+	if err := service.RevokeAPIKey(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := call(unlimited); got != http.StatusUnauthorized {
+		t.Errorf("clé révoquée : statut %d, veut %d", got, http.StatusUnauthorized)
+	}
+	if err := service.ConsumeAPIKeyQuota(key.ID); err != service.ErrInvalidAPIKey {
+		t.Errorf("ConsumeAPIKeyQuota(clé révoquée) = %v, veut %v", err, service.ErrInvalidAPIKey)
+	}
+}
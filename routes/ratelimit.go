@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pitch/controllers"
+	"pitch/service"
+)
+
+// bucket est un seau à jetons : capacity jetons max, rechargés à refill jetons par seconde
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// rateLimiter applique une limite par clé (clé API, utilisateur ou IP) pour une route
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	refill   float64 // jetons par seconde
+	buckets  map[string]*bucket
+	calls    int
+}
+
+// parseRate lit une limite au format "N/s", "N/m" ou "N/h" ; "off" ou "0" désactive la limite
+func parseRate(spec string) (capacity float64, refill float64, err error) {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	if spec == "off" || spec == "0" {
+		return 0, 0, nil
+	}
+	n, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("limite %q invalide (attendu N/s, N/m ou N/h)", spec)
+	}
+	count, err := strconv.Atoi(n)
+	if err != nil || count < 0 {
+		return 0, 0, fmt.Errorf("limite %q invalide", spec)
+	}
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return 0, 0, fmt.Errorf("unité %q invalide dans %q", unit, spec)
+	}
+	return float64(count), float64(count) / period.Seconds(), nil
+}
+
+// newRateLimiter crée la limite de la route name : RATE_LIMIT_<NAME> si défini, sinon defaultSpec.
+// Une valeur invalide est signalée dans les logs et remplacée par defaultSpec.
+func newRateLimiter(name, defaultSpec string) *rateLimiter {
+	env := "RATE_LIMIT_" + strings.ToUpper(strings.NewReplacer("-", "_", "/", "_").Replace(name))
+	spec := os.Getenv(env)
+	if spec == "" {
+		spec = defaultSpec
+	}
+	capacity, refill, err := parseRate(spec)
+	if err != nil {
+		log.Printf("ratelimit: %s ignorée, limite par défaut %q appliquée: %v", env, defaultSpec, err)
+		capacity, refill, _ = parseRate(defaultSpec)
+	}
+	return &rateLimiter{capacity: capacity, refill: refill, buckets: map[string]*bucket{}}
+}
+
+// take consomme un jeton pour key ; retourne s'il est autorisé, les jetons restants
+// et le délai avant le prochain jeton disponible
+func (l *rateLimiter) take(key string, now time.Time) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.calls%1000 == 0 {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.updated).Seconds()*l.refill)
+	b.updated = now
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, int(b.tokens), 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.refill * float64(time.Second))
+	return false, 0, wait
+}
+
+// sweep supprime les seaux inactifs depuis assez longtemps pour être pleins (verrou pris)
+func (l *rateLimiter) sweep(now time.Time) {
+	full := time.Duration(l.capacity / l.refill * float64(time.Second))
+	for k, b := range l.buckets {
+		if now.Sub(b.lastSeen) > full {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// clientIP retourne l'IP du client ; X-Forwarded-For n'est pris en compte que si TRUST_PROXY=true.
+// Le client peut écrire ce qu'il veut au début de l'en-tête : seules les adresses ajoutées par nos
+// proxys (les TRUST_PROXY_HOPS dernières, 1 par défaut) sont fiables, l'IP du client est la plus à gauche d'entre elles.
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY") == "true" {
+		var hops []string
+		for _, xff := range r.Header.Values("X-Forwarded-For") {
+			for _, ip := range strings.Split(xff, ",") {
+				if ip = strings.TrimSpace(ip); ip != "" {
+					hops = append(hops, ip)
+				}
+			}
+		}
+		if len(hops) > 0 {
+			trusted, err := strconv.Atoi(os.Getenv("TRUST_PROXY_HOPS"))
+			if err != nil || trusted < 1 {
+				trusted = 1
+			}
+			if trusted > len(hops) {
+				trusted = len(hops)
+			}
+			return hops[len(hops)-trusted]
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitKey identifie l'appelant : clé API, sinon utilisateur connecté, sinon IP
+func rateLimitKey(r *http.Request) string {
+	if k := service.APIKeyFromContext(r.Context()); k != nil {
+		return "key:" + k.ID
+	}
+	if u := service.UserFromContext(r.Context()); u != nil {
+		return "user:" + u.ID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// rateLimitMiddleware limite le nombre de requêtes par appelant sur la route name.
+// Doit être placé après les middlewares d'authentification pour connaître la clé API ou l'utilisateur.
+func rateLimitMiddleware(name, defaultSpec string, next http.HandlerFunc) http.HandlerFunc {
+	limiter := newRateLimiter(name, defaultSpec)
+	if limiter.capacity == 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed, remaining, wait := limiter.take(rateLimitKey(r), time.Now())
+
+		// Temps avant que le seau soit de nouveau plein
+		reset := int(math.Ceil((limiter.capacity - float64(remaining)) / limiter.refill))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(int(limiter.capacity)))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(reset))
+
+		if !allowed {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			controllers.TooManyRequests(w, r, retryAfter)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name  string
+		trust string
+		hops  string
+		xff   []string
+		want  string
+	}{
+		{name: "sans proxy de confiance", xff: []string{"1.1.1.1"}, want: "192.0.2.1"},
+		{name: "un proxy", trust: "true", xff: []string{"203.0.113.7"}, want: "203.0.113.7"},
+		{name: "en-tête falsifié par le client", trust: "true", xff: []string{"6.6.6.6, 203.0.113.7"}, want: "203.0.113.7"},
+		{name: "plusieurs en-têtes", trust: "true", xff: []string{"6.6.6.6", "203.0.113.7"}, want: "203.0.113.7"},
+		{name: "deux proxys", trust: "true", hops: "2", xff: []string{"6.6.6.6, 203.0.113.7, 10.0.0.2"}, want: "203.0.113.7"},
+		{name: "moins d'adresses que de proxys", trust: "true", hops: "3", xff: []string{"203.0.113.7, 10.0.0.2"}, want: "203.0.113.7"},
+		{name: "en-tête absent", trust: "true", want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUST_PROXY", tt.trust)
+			t.Setenv("TRUST_PROXY_HOPS", tt.hops)
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, veut %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	t.Setenv("TRUST_PROXY", "true")
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "6.6.6.6, 203.0.113.7")
+	if got := rateLimitKey(r); got != "ip:203.0.113.7" {
+		t.Errorf("rateLimitKey() = %q", got)
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	l := newRateLimiter("test", "2/m")
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.take("ip:1", now); !ok {
+			t.Fatalf("requête %d refusée", i+1)
+		}
+	}
+	ok, _, wait := l.take("ip:1", now)
+	if ok || wait <= 0 {
+		t.Fatalf("3e requête : ok=%v wait=%v, veut un refus", ok, wait)
+	}
+	if ok, _, _ := l.take("ip:2", now); !ok {
+		t.Error("un autre appelant ne doit pas être limité")
+	}
+	if ok, _, _ := l.take("ip:1", now.Add(wait+time.Millisecond)); !ok {
+		t.Error("le seau doit s'être rempli après l'attente annoncée")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		spec     string
+		capacity float64
+		refill   float64
+		wantErr  bool
+	}{
+		{spec: "10/m", capacity: 10, refill: 10.0 / 60},
+		{spec: " 5/H ", capacity: 5, refill: 5.0 / 3600},
+		{spec: "2/s", capacity: 2, refill: 2},
+		{spec: "off"},
+		{spec: "0"},
+		{spec: "10", wantErr: true},
+		{spec: "x/m", wantErr: true},
+		{spec: "-1/m", wantErr: true},
+		{spec: "10/j", wantErr: true},
+	}
+	for _, tt := range tests {
+		capacity, refill, err := parseRate(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRate(%q) erreur = %v", tt.spec, err)
+			continue
+		}
+		if capacity != tt.capacity || math.Abs(refill-tt.refill) > 1e-9 {
+			t.Errorf("parseRate(%q) = %v, %v ; veut %v, %v", tt.spec, capacity, refill, tt.capacity, tt.refill)
+		}
+	}
+}
+
+func TestNewRateLimiterInvalidEnv(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	t.Setenv("RATE_LIMIT_PITCH_GENERATE", "10/j")
+	l := newRateLimiter("pitch-generate", "5/m")
+	if l.capacity != 5 || math.Abs(l.refill-5.0/60) > 1e-9 {
+		t.Errorf("limite = %v, %v ; veut la limite par défaut 5/m", l.capacity, l.refill)
+	}
+	if got := logs.String(); !strings.Contains(got, "RATE_LIMIT_PITCH_GENERATE") || !strings.Contains(got, "10/j") {
+		t.Errorf("log = %q, veut la variable et la valeur rejetée", got)
+	}
+
+	logs.Reset()
+	t.Setenv("RATE_LIMIT_PITCH_GENERATE", "20/h")
+	if l := newRateLimiter("pitch-generate", "5/m"); l.capacity != 20 || logs.Len() != 0 {
+		t.Errorf("valeur valide : capacité %v, log %q", l.capacity, logs.String())
+	}
+}
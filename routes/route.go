@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"encoding/json"
 	"net/http"
 	"pitch/controllers"
+	"pitch/service"
 )
 
 // loggingMiddleware gère les requêtes HTTP avec protection contre les panics
@@ -17,11 +19,24 @@ func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// HealthCheck endpoint pour vérifier que l'application fonctionne
+// HealthCheck endpoint pour vérifier que l'application fonctionne.
+// Le statut passe à "degraded" si le disjoncteur du fournisseur principal n'est pas fermé.
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
+	breakers := service.BreakerStatuses()
+	status := "ok"
+	if len(breakers) > 0 && breakers[0].State != service.BreakerClosed {
+		status = "degraded"
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ok","service":"pitch-ia"}`))
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":    status,
+		"service":   "pitch-ia",
+		"cache":     service.GetCacheStats(),
+		"queue":     service.GetQueueStats(),
+		"providers": breakers,
+	})
 }
 
 // Web configure toutes les routes de l'application
@@ -30,8 +45,108 @@ func Web() {
 	http.HandleFunc("/health", HealthCheck)
 
 	// Page d'accueil (GET)
-	http.HandleFunc("/", loggingMiddleware(controllers.Pitch))
+	http.HandleFunc("/", loggingMiddleware(sessionMiddleware(controllers.Pitch)))
 
 	// Traitement du formulaire (POST)
-	http.HandleFunc("/analyze-pitch", loggingMiddleware(controllers.AnalyzePitch))
+	http.HandleFunc("/analyze-pitch", loggingMiddleware(sessionMiddleware(rateLimitMiddleware("analyze-pitch", "10/m", controllers.AnalyzePitch))))
+
+	// Pitchs enregistrés
+	http.HandleFunc("GET /generations/{id}", loggingMiddleware(sessionMiddleware(controllers.ShowGeneration)))
+	http.HandleFunc("GET /pitches/{id}", loggingMiddleware(sessionMiddleware(controllers.ShowPitch)))
+	http.HandleFunc("GET /pitches/{id}/canvas/{file}", loggingMiddleware(sessionMiddleware(controllers.PitchCanvas)))
+	http.HandleFunc("GET /pitches/{id}/present", loggingMiddleware(sessionMiddleware(controllers.PresentPitch)))
+	// Co-édition en temps réel (WebSocket)
+	http.HandleFunc("GET /pitches/{id}/editer", loggingMiddleware(requireUser(controllers.EditPitch)))
+	http.HandleFunc("GET /pitches/{id}/collab", loggingMiddleware(requireUser(controllers.CollabPitch)))
+	http.HandleFunc("GET /partage/{token}/editer", loggingMiddleware(controllers.EditShared))
+	http.HandleFunc("GET /partage/{token}/collab", loggingMiddleware(rateLimitMiddleware("share-collab", "20/m", controllers.CollabShare)))
+	// Liens de partage publics
+	http.HandleFunc("GET /pitches/{id}/partages", loggingMiddleware(requireUser(controllers.SharesPage)))
+	http.HandleFunc("POST /pitches/{id}/partages", loggingMiddleware(requireUser(rateLimitMiddleware("share-create", "20/h", controllers.CreateShare))))
+	http.HandleFunc("POST /pitches/{id}/partages/{share}/revoquer", loggingMiddleware(requireUser(controllers.RevokeShare)))
+	http.HandleFunc("GET /partage/{token}", loggingMiddleware(controllers.SharedPitch))
+	http.HandleFunc("POST /partage/{token}", loggingMiddleware(rateLimitMiddleware("share-password", "10/m", controllers.UnlockShare)))
+	http.HandleFunc("POST /partage/{token}/commentaires", loggingMiddleware(rateLimitMiddleware("share-comment", "10/m", controllers.CommentShare)))
+	// Commentaires, revue par les mentors et notifications
+	http.HandleFunc("POST /pitches/{id}/commentaires", loggingMiddleware(requireUser(controllers.CommentPitch)))
+	http.HandleFunc("POST /pitches/{id}/revue", loggingMiddleware(requireUser(controllers.ReviewPitch)))
+	http.HandleFunc("POST /partage/{token}/revue", loggingMiddleware(rateLimitMiddleware("share-review", "10/m", controllers.ReviewShare)))
+	http.HandleFunc("GET /notifications", loggingMiddleware(requireUser(controllers.NotificationsPage)))
+	http.HandleFunc("GET /mes-pitchs", loggingMiddleware(requireUser(controllers.MyPitches)))
+	// Jury simulé : notation par plusieurs profils de jurés et rapport agrégé
+	http.HandleFunc("GET /pitches/{id}/jury", loggingMiddleware(requireUser(controllers.JuryPage)))
+	http.HandleFunc("POST /pitches/{id}/jury", loggingMiddleware(requireUser(rateLimitMiddleware("jury", "5/h", controllers.StartJury))))
+	http.HandleFunc("GET /jury/{id}", loggingMiddleware(requireUser(controllers.JuryReportPage)))
+
+	// Organisations : membres, rôles, invitations et espace de travail courant
+	http.HandleFunc("GET /organisations", loggingMiddleware(requireUser(controllers.OrganizationsPage)))
+	http.HandleFunc("POST /organisations", loggingMiddleware(requireUser(rateLimitMiddleware("org-create", "10/h", controllers.CreateOrganization))))
+	http.HandleFunc("GET /organisations/{id}", loggingMiddleware(requireUser(controllers.OrganizationPage)))
+	http.HandleFunc("POST /organisations/{id}/invitations", loggingMiddleware(requireUser(rateLimitMiddleware("org-invite", "20/h", controllers.CreateInvite))))
+	http.HandleFunc("POST /organisations/{id}/invitations/{invite}/revoquer", loggingMiddleware(requireUser(controllers.RevokeInvite)))
+	http.HandleFunc("POST /organisations/{id}/membres/{user}/role", loggingMiddleware(requireUser(controllers.ChangeMemberRole)))
+	http.HandleFunc("POST /organisations/{id}/membres/{user}/retirer", loggingMiddleware(requireUser(controllers.RemoveMember)))
+	http.HandleFunc("GET /invitations/{token}", loggingMiddleware(sessionMiddleware(controllers.InvitePage)))
+	http.HandleFunc("POST /invitations/{token}", loggingMiddleware(requireUser(rateLimitMiddleware("invite-accept", "10/m", controllers.AcceptInvite))))
+	http.HandleFunc("POST /espace", loggingMiddleware(requireUser(controllers.SwitchWorkspace)))
+
+	// Cohortes d'incubation : équipes, classement, progression et export CSV
+	http.HandleFunc("POST /organisations/{id}/cohortes", loggingMiddleware(requireUser(controllers.CreateCohort)))
+	http.HandleFunc("GET /cohortes/{id}", loggingMiddleware(requireUser(controllers.CohortPage)))
+	http.HandleFunc("GET /cohortes/{id}/export.csv", loggingMiddleware(requireUser(controllers.CohortExport)))
+	http.HandleFunc("POST /cohortes/{id}/supprimer", loggingMiddleware(requireUser(controllers.DeleteCohort)))
+	http.HandleFunc("POST /cohortes/{id}/equipes", loggingMiddleware(requireUser(controllers.AddCohortTeam)))
+	http.HandleFunc("POST /cohortes/{id}/equipes/{team}/retirer", loggingMiddleware(requireUser(controllers.RemoveCohortTeam)))
+	http.HandleFunc("POST /cohortes/{id}/equipes/{team}/pitchs", loggingMiddleware(requireUser(controllers.AttachTeamPitch)))
+	http.HandleFunc("POST /cohortes/{id}/equipes/{team}/pitchs/{pitch}/retirer", loggingMiddleware(requireUser(controllers.DetachTeamPitch)))
+
+	// Génération par lot (CSV/JSONL)
+	http.HandleFunc("GET /lots", loggingMiddleware(requireUser(controllers.BatchPage)))
+	http.HandleFunc("POST /lots", loggingMiddleware(requireUser(rateLimitMiddleware("batch", "5/h", controllers.CreateBatch))))
+	http.HandleFunc("GET /lots/{id}", loggingMiddleware(requireUser(controllers.ShowBatch)))
+	http.HandleFunc("GET /lots/{id}/resultats", loggingMiddleware(requireUser(controllers.BatchResults)))
+
+	// Comptes utilisateurs
+	http.HandleFunc("GET /login", loggingMiddleware(sessionMiddleware(controllers.LoginPage)))
+	http.HandleFunc("POST /login", loggingMiddleware(rateLimitMiddleware("login", "10/m", controllers.Login)))
+	http.HandleFunc("GET /register", loggingMiddleware(sessionMiddleware(controllers.RegisterPage)))
+	http.HandleFunc("POST /register", loggingMiddleware(rateLimitMiddleware("register", "10/h", controllers.Register)))
+	http.HandleFunc("POST /logout", loggingMiddleware(controllers.Logout))
+	http.HandleFunc("GET /forgot-password", loggingMiddleware(controllers.ForgotPasswordPage))
+	http.HandleFunc("POST /forgot-password", loggingMiddleware(rateLimitMiddleware("forgot-password", "5/h", controllers.ForgotPassword)))
+	http.HandleFunc("GET /reset-password", loggingMiddleware(controllers.ResetPasswordPage))
+	http.HandleFunc("POST /reset-password", loggingMiddleware(rateLimitMiddleware("reset-password", "10/m", controllers.ResetPassword)))
+
+	// Crédits et paiement
+	http.HandleFunc("GET /credits", loggingMiddleware(requireUser(controllers.Credits)))
+	http.HandleFunc("POST /credits/buy", loggingMiddleware(requireUser(controllers.BuyCredits)))
+	http.HandleFunc("GET /credits/complete", loggingMiddleware(requireUser(controllers.CompleteCredits)))
+	if _, ok := service.FakePayments(); ok {
+		// Page de paiement simulée, uniquement avec PAYMENT_PROVIDER=fake
+		http.HandleFunc("/credits/fake-checkout/{id}", loggingMiddleware(requireUser(controllers.FakeCheckout)))
+	}
+
+	// Connexion SSO (OpenID Connect)
+	http.HandleFunc("GET /auth/oidc/login", loggingMiddleware(controllers.OIDCLogin))
+	http.HandleFunc("GET /auth/oidc/callback", loggingMiddleware(controllers.OIDCCallback))
+
+	// API JSON v1 et sa spécification OpenAPI
+	http.HandleFunc("GET "+controllers.APIPrefix+"/openapi.json", controllers.OpenAPI)
+	for _, rt := range controllers.APIRoutes() {
+		// Limite par défaut plus stricte pour les routes qui déclenchent une génération
+		limit := "120/m"
+		if rt.Method == http.MethodPost {
+			limit = "30/m"
+		}
+		// Authentification, puis limite de débit, puis quota : un appel refusé par la limite n'est pas décompté
+		http.HandleFunc(rt.Pattern(), loggingMiddleware(apiKeyMiddleware(rt.Scope, rateLimitMiddleware(rt.OperationID, limit, apiQuotaMiddleware(rt.Handler)))))
+	}
+
+	// Administration des clés API
+	http.HandleFunc("GET /admin/api-keys", loggingMiddleware(adminMiddleware(controllers.AdminAPIKeys)))
+	http.HandleFunc("POST /admin/api-keys", loggingMiddleware(adminMiddleware(controllers.AdminCreateAPIKey)))
+	http.HandleFunc("POST /admin/api-keys/{id}/revoke", loggingMiddleware(adminMiddleware(controllers.AdminRevokeAPIKey)))
+
+	// Tableau de bord de consommation OpenAI
+	http.HandleFunc("GET /admin/usage", loggingMiddleware(adminMiddleware(controllers.AdminUsage)))
 }
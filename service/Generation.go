@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"pitch/models"
@@ -12,82 +13,55 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 )
 
-// GenerationwithAI appelle OpenAI et parse la réponse en PitchResponse avec retry.
-func GenerationwithAI(input string) *models.PitchResponse {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return nil
+// TokenUsage est la consommation de tokens d'une génération (toutes tentatives confondues)
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// OpenAIModel retourne le modèle utilisé pour la génération (OPENAI_MODEL, gpt-3.5-turbo par défaut)
+func OpenAIModel() string {
+	if m := os.Getenv("OPENAI_MODEL"); m != "" {
+		return m
 	}
+	return "gpt-3.5-turbo"
+}
 
-	client := openai.NewClient(apiKey)
+// newOpenAIClient crée le client du fournisseur ; BaseURL permet de viser une API compatible (proxy, serveur local)
+func newOpenAIClient(p Provider) *openai.Client {
+	config := openai.DefaultConfig(p.APIKey)
+	config.HTTPClient = providerHTTPClient
+	if p.BaseURL != "" {
+		config.BaseURL = p.BaseURL
+	}
+	return openai.NewClientWithConfig(config)
+}
 
+// generateWithOpenAI appelle le fournisseur selon la politique de retry et retourne le pitch ainsi que les tokens consommés.
+// Les tentatives s'arrêtent sur une erreur définitive, à l'épuisement du budget ou dès que le disjoncteur s'ouvre.
+func generateWithOpenAI(parent context.Context, p Provider, breaker *CircuitBreaker, input string, opts GenerateOptions) (*models.PitchResponse, TokenUsage, error) {
 	system := "Tu es un assistant spécialisé dans la création de pitchs structurés. Tu dois TOUJOURS répondre dans un format STRICT avec 6 sections numérotées en français. Chaque section doit être sur SA PROPRE LIGNE, commençant par le numéro suivi d'un point, puis le label entre crochets, puis le contenu. EXEMPLE DE FORMAT OBLIGATOIRE:\n\n1. [Problème] Texte du problème ici\n2. [Solution] Texte de la solution ici\n3. [Marché] Texte du marché ici\n4. [Valeur] Texte de la valeur ici\n5. [Canaux] Texte des canaux ici\n6. [Modèle] Texte du modèle ici\n\nIMPORTANT: Ne mets RIEN avant la première section. Ne mets RIEN après la dernière section. Une seule section par ligne. Utilise EXACTEMENT ce format avec les numéros, points, crochets et labels en français."
 
-	prompt := fmt.Sprintf("Génère un pitch structuré pour ce projet en utilisant EXACTEMENT le format ci-dessous (une ligne par section) :\n\n1. [Problème] Décris le problème spécifique que ce projet résout\n2. [Solution] Décris la solution concrète que ce projet apporte\n3. [Marché] Décris le marché cible et l'opportunité\n4. [Valeur] Décris la proposition de valeur unique\n5. [Canaux] Décris les canaux de distribution/acquisition\n6. [Modèle] Décris le modèle économique\n\nDescription du projet : %s\n\nRéponds UNIQUEMENT avec les 6 lignes au format ci-dessus, sans texte avant ou après.", input)
-
-	// Tentative avec retry (max 3 tentatives)
-	maxRetries := 3
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if attempt > 1 {
-			time.Sleep(time.Duration(attempt) * time.Second) // Délai progressif
-		}
+	prompt := fmt.Sprintf("Génère un pitch structuré pour ce projet en utilisant EXACTEMENT le format ci-dessous (une ligne par section) :\n\n1. [Problème] Décris le problème spécifique que ce projet résout\n2. [Solution] Décris la solution concrète que ce projet apporte\n3. [Marché] Décris le marché cible et l'opportunité\n4. [Valeur] Décris la proposition de valeur unique\n5. [Canaux] Décris les canaux de distribution/acquisition\n6. [Modèle] Décris le modèle économique\n\nDescription du projet : %s\n\nRéponds UNIQUEMENT avec les 6 lignes au format ci-dessus, sans texte avant ou après.", input) + opts.promptInstructions()
 
-		// Timeout réduit à 25 secondes pour éviter les timeouts Render/Vercel (qui sont souvent à 30s)
-		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
-		defer cancel()
-		
-		modelName := "gpt-3.5-turbo"
-		resp, err := client.CreateChatCompletion(
-			ctx,
-			openai.ChatCompletionRequest{
-				Model: modelName,
-				Messages: []openai.ChatCompletionMessage{
-					{
-						Role:    openai.ChatMessageRoleSystem,
-						Content: system,
-					},
-					{
-						Role:    openai.ChatMessageRoleUser,
-						Content: prompt,
-					},
-				},
-				Temperature: 0.7, // Température pour des réponses plus consistantes
-				MaxTokens:   1000, // Limiter les tokens pour des réponses plus rapides
+	req := openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: system,
 			},
-		)
-		
-		if err != nil {
-			errStr := err.Error()
-			
-			// Ne pas retry pour les erreurs d'authentification
-			if strings.Contains(errStr, "401") || strings.Contains(errStr, "unauthorized") || strings.Contains(errStr, "invalid") || strings.Contains(errStr, "authentication") {
-				return nil
-			}
-			
-			// Continuer pour retry si ce n'est pas la dernière tentative
-			if attempt < maxRetries {
-				continue
-			}
-			return nil
-		}
-
-		if len(resp.Choices) == 0 {
-			if attempt < maxRetries {
-				continue
-			}
-			return nil
-		}
-
-		content := resp.Choices[0].Message.Content
-		if content == "" {
-			if attempt < maxRetries {
-				continue
-			}
-			return nil
-		}
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.7,  // Température pour des réponses plus consistantes
+		MaxTokens:   1000, // Limiter les tokens pour des réponses plus rapides
+	}
 
-		parsed := parseAIResponse(content)
+	var parsed *models.PitchResponse
+	_, usage, err := completeWithRetry(parent, p, breaker, req, func(content string) bool {
+		parsed = parseAIResponse(content)
 
 		// Compter les sections remplies
 		filledCount := 0
@@ -111,37 +85,129 @@ func GenerationwithAI(input string) *models.PitchResponse {
 		}
 
 		// Si toutes les sections sont vides, c'est un échec de parsing - retry
-		if filledCount == 0 {
-			if attempt < maxRetries {
-				continue
-			}
-			return nil
-		}
+		return filledCount > 0
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+
+	// Si certaines sections restent vides, remplir avec une suggestion minimale basée sur l'entrée
+	if parsed.Probleme == "" {
+		parsed.Probleme = "Problème à définir basé sur votre description."
+	}
+	if parsed.Solution == "" {
+		parsed.Solution = "Solution à développer selon votre projet."
+	}
+	if parsed.Marche == "" {
+		parsed.Marche = "Marché cible à identifier."
+	}
+	if parsed.Valeur == "" {
+		parsed.Valeur = "Proposition de valeur unique à définir."
+	}
+	if parsed.Canaux == "" {
+		parsed.Canaux = "Canaux de distribution à mettre en place."
+	}
+	if parsed.Modele == "" {
+		parsed.Modele = "Modèle économique : freemium + abonnement premium ou commissions selon le service."
+	}
 
-		// Si certaines sections restent vides, remplir avec une suggestion minimale basée sur l'entrée
-		if parsed.Probleme == "" {
-			parsed.Probleme = "Problème à définir basé sur votre description."
+	return parsed, usage, nil
+}
+
+// errProviderFault est retournée quand l'échec vient du fournisseur lui-même (5xx, 429, réseau) :
+// c'est le seul échec qui compte pour son disjoncteur
+var errProviderFault = fmt.Errorf("%w : erreur du fournisseur", ErrGenerationFailed)
+
+// completeWithRetry envoie la requête au modèle du fournisseur selon la politique de retry et retourne
+// la première réponse acceptée par accept (une réponse vide ou refusée est réessayée) et les tokens consommés.
+// Les tentatives s'arrêtent sur une erreur définitive, à l'épuisement du budget ou dès que le disjoncteur s'ouvre.
+// L'erreur est errProviderFault si la dernière tentative a échoué par la faute du fournisseur, ErrGenerationFailed sinon
+// (annulation, budget épuisé, réponse vide ou refusée).
+func completeWithRetry(parent context.Context, p Provider, breaker *CircuitBreaker, req openai.ChatCompletionRequest, accept func(content string) bool) (string, TokenUsage, error) {
+	var usage TokenUsage
+	fault := false // la dernière tentative a échoué par la faute du fournisseur
+	failed := func() (string, TokenUsage, error) {
+		if fault {
+			return "", usage, errProviderFault
 		}
-		if parsed.Solution == "" {
-			parsed.Solution = "Solution à développer selon votre projet."
+		return "", usage, ErrGenerationFailed
+	}
+
+	if p.APIKey == "" {
+		return "", usage, ErrGenerationFailed
+	}
+
+	client := newOpenAIClient(p)
+	req.Model = p.Model
+
+	policy := DefaultRetryPolicy()
+	deadline := time.Now().Add(policy.Budget)
+	var hint time.Duration // délai demandé par le serveur lors de la tentative précédente
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			// Inutile d'insister si le fournisseur est déclaré en panne entre-temps
+			if state, _, _ := breaker.State(); state == BreakerOpen {
+				return "", usage, ErrGenerationFailed
+			}
+			delay := policy.Backoff(attempt - 1)
+			if hint > delay {
+				delay = hint
+			}
+			// Pas d'attente si la tentative suivante ne tiendrait plus dans le budget
+			if time.Until(deadline) < delay+time.Second {
+				return failed()
+			}
+			select {
+			case <-time.After(delay):
+			case <-parent.Done():
+				return "", usage, ErrGenerationFailed
+			}
 		}
-		if parsed.Marche == "" {
-			parsed.Marche = "Marché cible à identifier."
+		hint = 0
+
+		// Chaque tentative est bornée par PROVIDER_ATTEMPT_TIMEOUT et par le budget restant
+		// (25s par défaut pour les timeouts Render/Vercel à 30s)
+		attemptDeadline := deadline
+		if policy.AttemptTimeout > 0 {
+			if d := time.Now().Add(policy.AttemptTimeout); d.Before(attemptDeadline) {
+				attemptDeadline = d
+			}
 		}
-		if parsed.Valeur == "" {
-			parsed.Valeur = "Proposition de valeur unique à définir."
+		ctx, cancel := context.WithDeadline(parent, attemptDeadline)
+		capture := &headerCapture{}
+		ctx = context.WithValue(ctx, headerCaptureKey, capture)
+
+		resp, err := client.CreateChatCompletion(ctx, req)
+		// Un fournisseur qui ne répond pas à temps est en défaut ; l'annulation par l'appelant ne l'est pas
+		timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+		fault = err != nil && parent.Err() == nil && (timedOut || (ctx.Err() == nil && providerFault(err)))
+		cancel()
+
+		if err != nil {
+			// Ne pas retry pour les erreurs définitives (authentification, requête invalide, quota épuisé...)
+			if !retryableError(err) {
+				return failed()
+			}
+			hint, _ = retryHint(capture.get())
+			continue
 		}
-		if parsed.Canaux == "" {
-			parsed.Canaux = "Canaux de distribution à mettre en place."
+
+		// Les tokens sont facturés même si la réponse est inexploitable
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+
+		if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+			continue
 		}
-		if parsed.Modele == "" {
-			parsed.Modele = "Modèle économique : freemium + abonnement premium ou commissions selon le service."
+		content := resp.Choices[0].Message.Content
+		if !accept(content) {
+			continue
 		}
-
-		return parsed
+		return content, usage, nil
 	}
 
-	return nil
+	return failed()
 }
 
 // parseAIResponse extrait les sections françaises du texte retourné par l'IA
@@ -425,23 +491,3 @@ func parseAIResponse(content string) *models.PitchResponse {
 
 	return result
 }
-
-// GeneratePitchResponse retourne un PitchResponse mocké basé sur l'entrée.
-// Cette fonction est utilisée par le handler en mode démo (sans OpenAI).
-func GeneratePitchResponse(input string) *models.PitchResponse {
-	probleme := fmt.Sprintf("Les utilisateurs rencontrent %s, ce qui crée une friction dans le parcours.", input)
-	solution := fmt.Sprintf("Nous proposons une solution simple et intuitive basée sur %s, améliorant la conversion.", input)
-	marche := "Étudiants urbains 18-30 ans, utilisateurs mobiles cherchant commodité."
-	valeur := "Gain de temps, personnalisation et prix attractif."
-	canaux := "Réseaux sociaux, partenariats campus, campagnes locales."
-	modele := "Freemium + abonnement premium + commissions."
-
-	return &models.PitchResponse{
-		Probleme: probleme,
-		Solution: solution,
-		Marche:   marche,
-		Valeur:   valeur,
-		Canaux:   canaux,
-		Modele:   modele,
-	}
-}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// Scopes disponibles pour les clés API
+const (
+	ScopePitchesRead   = "pitches:read"
+	ScopePitchesWrite  = "pitches:write"
+	ScopePitchesDelete = "pitches:delete"
+)
+
+// AllScopes liste les scopes proposés à la création d'une clé
+var AllScopes = []string{ScopePitchesRead, ScopePitchesWrite, ScopePitchesDelete}
+
+// Erreurs d'authentification par clé API
+var (
+	ErrInvalidAPIKey = errors.New("clé API invalide ou révoquée")
+	ErrScopeDenied   = errors.New("la clé API n'a pas accès à cette opération")
+	ErrQuotaExceeded = errors.New("quota journalier de la clé API atteint")
+	ErrUnknownScope  = errors.New("scope inconnu")
+)
+
+// apiKeyPrefix préfixe les clés en clair : pk_<id>_<secret>
+const apiKeyPrefix = "pk_"
+
+var apiKeyStore = store.New[models.APIKey]("api_keys")
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey crée une clé et retourne sa valeur en clair (affichée une seule fois)
+func CreateAPIKey(name string, scopes []string, dailyQuota int) (string, *models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, errors.New("le nom de la clé est obligatoire")
+	}
+	if len(scopes) == 0 {
+		return "", nil, errors.New("au moins un scope est requis")
+	}
+	scopes, err := validScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	if dailyQuota < 0 {
+		dailyQuota = 0
+	}
+
+	id := store.NewID()
+	raw := apiKeyPrefix + id + "_" + store.RandomToken(24)
+	k := models.APIKey{
+		ID:         id,
+		Name:       name,
+		Hash:       hashAPIKey(raw),
+		Scopes:     scopes,
+		DailyQuota: dailyQuota,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := apiKeyStore.Put(k.ID, k); err != nil {
+		return "", nil, err
+	}
+	return raw, &k, nil
+}
+
+// validScopes vérifie que chaque scope fait partie de AllScopes et retire les doublons
+func validScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range scopes {
+		if !containsString(AllScopes, s) {
+			return nil, fmt.Errorf("%w : %q", ErrUnknownScope, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// ListAPIKeys retourne toutes les clés, des plus récentes aux plus anciennes
+func ListAPIKeys() []models.APIKey {
+	keys := apiKeyStore.List()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// RevokeAPIKey révoque la clé id ; une clé révoquée ne peut plus être utilisée
+func RevokeAPIKey(id string) error {
+	_, err := apiKeyStore.Update(id, func(k *models.APIKey) error {
+		if k.RevokedAt == nil {
+			now := time.Now().UTC()
+			k.RevokedAt = &now
+		}
+		return nil
+	})
+	return err
+}
+
+// AuthenticateAPIKey vérifie une clé en clair et son scope ; l'appel n'est décompté du quota
+// que par ConsumeAPIKeyQuota, une fois la requête admise par la limite de débit
+func AuthenticateAPIKey(raw, scope string) (*models.APIKey, error) {
+	rest, ok := strings.CutPrefix(raw, apiKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	k, ok := apiKeyStore.Get(id)
+	if !ok || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashAPIKey(raw))) != 1 || k.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if scope != "" && !k.HasScope(scope) {
+		return nil, ErrScopeDenied
+	}
+	return &k, nil
+}
+
+// ConsumeAPIKeyQuota comptabilise un appel de la clé id et refuse l'appel si son quota du jour est épuisé
+func ConsumeAPIKeyQuota(id string) error {
+	_, err := apiKeyStore.Update(id, func(k *models.APIKey) error {
+		if k.RevokedAt != nil {
+			return ErrInvalidAPIKey
+		}
+		now := time.Now().UTC()
+		day := now.Format("2006-01-02")
+		if k.UsageDay != day {
+			k.UsageDay = day
+			k.UsageCount = 0
+		}
+		if k.DailyQuota > 0 && k.UsageCount >= k.DailyQuota {
+			return ErrQuotaExceeded
+		}
+		k.UsageCount++
+		k.TotalUsage++
+		k.LastUsedAt = &now
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrInvalidAPIKey
+	}
+	return err
+}
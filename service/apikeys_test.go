@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		want   []string
+		err    error
+	}{
+		{name: "lecture", scopes: []string{ScopePitchesRead}, want: []string{ScopePitchesRead}},
+		{name: "tous", scopes: AllScopes, want: AllScopes},
+		{name: "doublon", scopes: []string{ScopePitchesWrite, ScopePitchesWrite}, want: []string{ScopePitchesWrite}},
+		{name: "inconnu", scopes: []string{ScopePitchesRead, "admin"}, err: ErrUnknownScope},
+		{name: "casse différente", scopes: []string{"Pitches:Read"}, err: ErrUnknownScope},
+		{name: "vide", scopes: []string{""}, err: ErrUnknownScope},
+	}
+	for _, tt := range tests {
+		_, key, err := CreateAPIKey("clé "+tt.name, tt.scopes, 0)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s : CreateAPIKey() = %v, veut %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(key.Scopes) != len(tt.want) {
+			t.Errorf("%s : scopes %v, veut %v", tt.name, key.Scopes, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if key.Scopes[i] != tt.want[i] {
+				t.Errorf("%s : scopes %v, veut %v", tt.name, key.Scopes, tt.want)
+			}
+		}
+	}
+	if _, _, err := CreateAPIKey("sans scope", nil, 0); err == nil {
+		t.Error("une clé sans scope doit être refusée")
+	}
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pitch/models"
+	"pitch/render"
+	"pitch/store"
+)
+
+// Formats de fichier acceptés pour un lot
+const (
+	BatchCSV   = "csv"
+	BatchJSONL = "jsonl"
+)
+
+// MaxBatchSize est la taille maximale d'un fichier de lot (1 Mo)
+const MaxBatchSize = 1 << 20
+
+// ErrBatchTooLarge est retournée quand le fichier de lot dépasse MaxBatchSize
+var ErrBatchTooLarge = errors.New("le fichier dépasse la taille maximale de 1 Mo")
+
+var batchStore = store.New[models.Batch]("batches")
+
+// BatchMaxRows est le nombre maximal de lignes par lot (BATCH_MAX_ROWS, 200 par défaut)
+func BatchMaxRows() int {
+	return envInt("BATCH_MAX_ROWS", 200)
+}
+
+// BatchFormat déduit le format d'un lot de son nom de fichier ou de son type MIME (CSV par défaut)
+func BatchFormat(filename, contentType string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == ".jsonl" || ext == ".ndjson" || strings.Contains(contentType, "ndjson") || strings.Contains(contentType, "jsonl") {
+		return BatchJSONL
+	}
+	return BatchCSV
+}
+
+// ParseBatch lit les descriptions d'un fichier CSV ou JSONL.
+// CSV : colonne « description » si l'en-tête existe (et « nom »/« name » en option), sinon la première colonne ;
+// le séparateur « ; » des exports Excel français est reconnu.
+// JSONL : un objet {"description": ..., "name": ...} ou une chaîne JSON par ligne.
+func ParseBatch(r io.Reader, format string) ([]models.BatchRow, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxBatchSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var rows []models.BatchRow
+	if format == BatchJSONL {
+		rows, err = parseBatchJSONL(data)
+	} else {
+		rows, err = parseBatchCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("le fichier ne contient aucune description")
+	}
+	if limit := BatchMaxRows(); len(rows) > limit {
+		return nil, fmt.Errorf("le fichier contient %d lignes, le maximum est %d", len(rows), limit)
+	}
+	return rows, nil
+}
+
+func parseBatchCSV(data []byte) ([]models.BatchRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	descCol, nameCol := 0, -1
+	var rows []models.BatchRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV invalide : %v", err)
+		}
+
+		// En-tête : repérer les colonnes description et nom
+		if first && hasDescriptionHeader(record) {
+			for i, cell := range record {
+				switch strings.ToLower(strings.TrimSpace(cell)) {
+				case "description":
+					descCol = i
+				case "nom", "name", "projet":
+					nameCol = i
+				}
+			}
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := models.BatchRow{Line: line}
+		if descCol < len(record) {
+			row.Description = strings.TrimSpace(record[descCol])
+		}
+		if nameCol >= 0 && nameCol < len(record) {
+			row.Name = strings.TrimSpace(record[nameCol])
+		}
+		if row.Description == "" && row.Name == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func hasDescriptionHeader(record []string) bool {
+	for _, cell := range record {
+		if strings.EqualFold(strings.TrimSpace(cell), "description") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseBatchJSONL(data []byte) ([]models.BatchRow, error) {
+	var rows []models.BatchRow
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), MaxBatchSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		row := models.BatchRow{Line: line}
+		if strings.HasPrefix(text, `"`) {
+			if err := json.Unmarshal([]byte(text), &row.Description); err != nil {
+				return nil, fmt.Errorf("JSONL invalide ligne %d : %v", line, err)
+			}
+		} else {
+			var obj struct {
+				Description string `json:"description"`
+				Name        string `json:"name"`
+				Nom         string `json:"nom"`
+			}
+			if err := json.Unmarshal([]byte(text), &obj); err != nil {
+				return nil, fmt.Errorf("JSONL invalide ligne %d : %v", line, err)
+			}
+			row.Description, row.Name = obj.Description, obj.Name
+			if row.Name == "" {
+				row.Name = obj.Nom
+			}
+		}
+		row.Description = strings.TrimSpace(row.Description)
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// CreateBatch valide chaque ligne avec les règles de AnalyzePitch puis lance la génération du lot
+// en arrière-plan. Les lignes invalides sont conservées avec leur message d'erreur.
+func CreateBatch(ctx context.Context, rows []models.BatchRow) (*models.Batch, error) {
+	b := models.Batch{
+		ID:        store.NewID(),
+		Status:    models.JobQueued,
+		Total:     len(rows),
+		Rows:      rows,
+		CreatedAt: time.Now().UTC(),
+	}
+	for i := range b.Rows {
+		row := &b.Rows[i]
+		row.Status = models.JobQueued
+		if err := ValidateDescription(row.Description); err != nil {
+			row.Status = models.JobFailed
+			row.Error = err.Error()
+			b.Done++
+			b.Failed++
+		}
+	}
+	if u := UserFromContext(ctx); u != nil {
+		b.OwnerID = u.ID
+	} else if k := APIKeyFromContext(ctx); k != nil {
+		b.APIKeyID = k.ID
+	}
+	if err := batchStore.Put(b.ID, b); err != nil {
+		return nil, err
+	}
+
+	go runBatch(context.WithoutCancel(ctx), b.ID)
+	return &b, nil
+}
+
+// runBatch génère les lignes valides, au plus BATCH_CONCURRENCY (3 par défaut) à la fois
+func runBatch(ctx context.Context, id string) {
+	b, _ := batchStore.Update(id, func(b *models.Batch) error {
+		b.Status = models.JobRunning
+		return nil
+	})
+
+	sem := make(chan struct{}, max(1, envInt("BATCH_CONCURRENCY", 3)))
+	var wg sync.WaitGroup
+	for i, row := range b.Rows {
+		if row.Status != models.JobQueued {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, row models.BatchRow) {
+			defer func() { <-sem; wg.Done() }()
+			updateBatchRow(id, i, func(r *models.BatchRow) { r.Status = models.JobRunning })
+
+			p, err := CreatePitch(ctx, row.Description, GenerateOptions{})
+			updateBatchRow(id, i, func(r *models.BatchRow) {
+				if err != nil {
+					r.Status = models.JobFailed
+					r.Error = err.Error()
+					return
+				}
+				r.Status = models.JobSucceeded
+				r.PitchID = p.ID
+			})
+		}(i, row)
+	}
+	wg.Wait()
+
+	batchStore.Update(id, func(b *models.Batch) error {
+		now := time.Now().UTC()
+		b.Status = models.JobSucceeded
+		b.FinishedAt = &now
+		return nil
+	})
+}
+
+// updateBatchRow modifie la ligne i du lot et tient les compteurs à jour
+func updateBatchRow(id string, i int, fn func(*models.BatchRow)) {
+	batchStore.Update(id, func(b *models.Batch) error {
+		row := &b.Rows[i]
+		fn(row)
+		if row.Status == models.JobSucceeded || row.Status == models.JobFailed {
+			b.Done++
+			if row.Status == models.JobFailed {
+				b.Failed++
+			}
+		}
+		return nil
+	})
+}
+
+// GetBatch retourne le lot id s'il appartient à l'appelant (utilisateur ou clé API)
+func GetBatch(ctx context.Context, id string) (*models.Batch, error) {
+	b, ok := batchStore.Get(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	u := UserFromContext(ctx)
+	k := APIKeyFromContext(ctx)
+	if (b.OwnerID != "" && (u == nil || u.ID != b.OwnerID)) || (b.APIKeyID != "" && (k == nil || k.ID != b.APIKeyID)) {
+		return nil, store.ErrNotFound
+	}
+	return &b, nil
+}
+
+// RecoverBatches termine les lots interrompus par un redémarrage du serveur ; les lignes non traitées passent en erreur
+func RecoverBatches() {
+	for _, b := range batchStore.Find(func(b models.Batch) bool {
+		return b.Status == models.JobQueued || b.Status == models.JobRunning
+	}) {
+		batchStore.Update(b.ID, func(b *models.Batch) error {
+			for i := range b.Rows {
+				row := &b.Rows[i]
+				if row.Status == models.JobQueued || row.Status == models.JobRunning {
+					row.Status = models.JobFailed
+					row.Error = "génération interrompue par un redémarrage du serveur"
+					b.Done++
+					b.Failed++
+				}
+			}
+			now := time.Now().UTC()
+			b.Status = models.JobSucceeded
+			b.FinishedAt = &now
+			return nil
+		})
+	}
+}
+
+// WriteBatchCSV écrit le résultat du lot : une ligne par description, avec le pitch ou l'erreur.
+// Seuls les pitchs encore accessibles à l'appelant sont exportés.
+func WriteBatchCSV(ctx context.Context, w io.Writer, b *models.Batch) error {
+	pitches := readablePitches(ctx)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"ligne", "nom", "description", "statut", "erreur", "pitch_id", "probleme", "solution", "marche", "valeur", "canaux", "modele"})
+	for _, row := range b.Rows {
+		record := []string{strconv.Itoa(row.Line), csvText(row.Name), csvText(row.Description), row.Status, csvText(row.Error), row.PitchID, "", "", "", "", "", ""}
+		if p, ok := pitches.Get(row.PitchID); ok && p.Sections != nil {
+			s := p.Sections
+			for i, text := range []string{s.Probleme, s.Solution, s.Marche, s.Valeur, s.Canaux, s.Modele} {
+				record[6+i] = csvText(text)
+			}
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// WriteBatchZIP écrit une archive contenant resultats.csv et un fichier Markdown par pitch généré
+// encore accessible à l'appelant
+func WriteBatchZIP(ctx context.Context, w io.Writer, b *models.Batch) error {
+	zw := zip.NewWriter(w)
+	modified := b.CreatedAt
+	if b.FinishedAt != nil {
+		modified = *b.FinishedAt
+	}
+	create := func(name string) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	}
+
+	f, err := create("resultats.csv")
+	if err != nil {
+		return err
+	}
+	if err := WriteBatchCSV(ctx, f, b); err != nil {
+		return err
+	}
+
+	pitches := readablePitches(ctx)
+	for _, row := range b.Rows {
+		p, ok := pitches.Get(row.PitchID)
+		if !ok || p.Sections == nil {
+			continue
+		}
+		name := fmt.Sprintf("pitchs/%03d", row.Line)
+		if slug := strings.Trim(unsafeFileChars.ReplaceAllString(row.Name, "-"), "-"); slug != "" {
+			name += "-" + slug
+		}
+		f, err := create(name + ".md")
+		if err != nil {
+			return err
+		}
+		title := row.Name
+		if title == "" {
+			title = "Pitch"
+		}
+		fmt.Fprintf(f, "# %s\n\n> %s\n\n", title, p.Description)
+		for _, section := range render.Sections(p.Sections) {
+			fmt.Fprintf(f, "## %s\n\n%s\n\n", section.Title, section.Content)
+		}
+	}
+	return zw.Close()
+}
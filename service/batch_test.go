@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pitch/models"
+)
+
+func TestParseBatch(t *testing.T) {
+	t.Setenv("BATCH_MAX_ROWS", "3")
+	tests := []struct {
+		name    string
+		format  string
+		in      string
+		want    []models.BatchRow
+		wantErr bool
+	}{
+		{
+			name:   "CSV sans en-tête",
+			format: BatchCSV,
+			in:     "Une app de covoiturage\nUne place de marché\n",
+			want:   []models.BatchRow{{Line: 1, Description: "Une app de covoiturage"}, {Line: 2, Description: "Une place de marché"}},
+		},
+		{
+			name:   "CSV avec en-tête et nom",
+			format: BatchCSV,
+			in:     "nom,description\nCovoit, Une app de covoiturage \n,\n",
+			want:   []models.BatchRow{{Line: 2, Name: "Covoit", Description: "Une app de covoiturage"}},
+		},
+		{
+			name:   "CSV Excel français avec BOM",
+			format: BatchCSV,
+			in:     "\xef\xbb\xbfDescription;Projet\r\nUne app, simple;Covoit\r\n",
+			want:   []models.BatchRow{{Line: 2, Name: "Covoit", Description: "Une app, simple"}},
+		},
+		{
+			name:   "JSONL objets et chaînes",
+			format: BatchJSONL,
+			in:     "{\"description\": \"Une app\", \"nom\": \"Covoit\"}\n\n\"Une place de marché\"\n",
+			want:   []models.BatchRow{{Line: 1, Name: "Covoit", Description: "Une app"}, {Line: 3, Description: "Une place de marché"}},
+		},
+		{name: "JSONL invalide", format: BatchJSONL, in: "{pas du json}\n", wantErr: true},
+		{name: "vide", format: BatchCSV, in: "description\n", wantErr: true},
+		{name: "trop de lignes", format: BatchCSV, in: "a\nb\nc\nd\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBatch(strings.NewReader(tt.in), tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s : ParseBatch() erreur = %v, veut une erreur : %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : ParseBatch() = %+v, veut %+v", tt.name, got, tt.want)
+		}
+	}
+
+	big := strings.Repeat("x", MaxBatchSize+1)
+	if _, err := ParseBatch(strings.NewReader(big), BatchCSV); !errors.Is(err, ErrBatchTooLarge) {
+		t.Errorf("fichier trop gros : %v, veut %v", err, ErrBatchTooLarge)
+	}
+}
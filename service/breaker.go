@@ -0,0 +1,103 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// États du disjoncteur
+const (
+	BreakerClosed   = "closed"    // fonctionnement normal
+	BreakerOpen     = "open"      // trop d'échecs : les appels échouent immédiatement
+	BreakerHalfOpen = "half-open" // un appel de test est autorisé pour vérifier le rétablissement
+)
+
+// CircuitBreaker coupe les appels à un fournisseur après Threshold échecs consécutifs,
+// puis laisse passer un appel de test après OpenDuration.
+type CircuitBreaker struct {
+	Threshold    int
+	OpenDuration time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// BreakerStatus est l'état d'un disjoncteur exposé sur /health
+type BreakerStatus struct {
+	Provider            string     `json:"provider"`
+	Model               string     `json:"model"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// NewCircuitBreaker crée un disjoncteur fermé
+func NewCircuitBreaker(threshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, OpenDuration: openDuration, state: BreakerClosed}
+}
+
+// Allow indique si un appel peut être tenté ; en état ouvert, un seul appel de test passe après OpenDuration
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.OpenDuration {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		// Un appel de test est déjà en cours
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Success referme le disjoncteur
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure comptabilise un échec et ouvre le disjoncteur au-delà du seuil (ou si l'appel de test échoue)
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// Abandon termine un appel sans conclure sur la santé du fournisseur (annulation, réponse inexploitable) :
+// les échecs ne sont pas comptés et, en état semi-ouvert, un nouvel appel de test est permis
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State retourne l'état courant, le nombre d'échecs consécutifs et la date d'ouverture
+func (b *CircuitBreaker) State() (string, int, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.state
+	if state == BreakerOpen && time.Since(b.openedAt) >= b.OpenDuration {
+		state = BreakerHalfOpen
+	}
+	return state, b.failures, b.openedAt
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	b := NewCircuitBreaker(2, 20*time.Millisecond)
+	state := func() string { s, _, _ := b.State(); return s }
+
+	b.Failure()
+	if state() != BreakerClosed || !b.Allow() {
+		t.Fatal("un échec sous le seuil doit laisser le disjoncteur fermé")
+	}
+	b.Success()
+	b.Failure()
+	if _, failures, _ := b.State(); failures != 1 {
+		t.Fatalf("Success doit remettre le compteur à zéro, %d échec(s)", failures)
+	}
+	b.Failure()
+	if state() != BreakerOpen || b.Allow() {
+		t.Fatal("le seuil atteint doit ouvrir le disjoncteur")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if state() != BreakerHalfOpen {
+		t.Fatalf("état après OpenDuration = %s, veut half-open", state())
+	}
+	if !b.Allow() {
+		t.Fatal("un appel de test doit passer après OpenDuration")
+	}
+	if b.Allow() {
+		t.Fatal("un seul appel de test à la fois")
+	}
+	b.Abandon()
+	if !b.Allow() {
+		t.Fatal("un appel de test abandonné doit pouvoir être retenté")
+	}
+	b.Failure()
+	if state() != BreakerOpen {
+		t.Fatal("l'échec de l'appel de test doit rouvrir le disjoncteur")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	b.Allow()
+	b.Success()
+	if s, failures, _ := b.State(); s != BreakerClosed || failures != 0 {
+		t.Fatalf("après un appel de test réussi : %s, %d échec(s)", s, failures)
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"pitch/models"
+	"pitch/store"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// cacheEntry est un pitch mis en cache avec l'embedding de sa description (cache sémantique)
+type cacheEntry struct {
+	Key       string               `json:"key"`
+	Scope     string               `json:"scope"` // périmètre de l'appelant (voir cacheScope)
+	Options   string               `json:"options"`
+	Response  models.PitchResponse `json:"response"`
+	Embedding []float32            `json:"embedding,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// CacheStats sont les compteurs du cache exposés sur /health
+type CacheStats struct {
+	Enabled      bool  `json:"enabled"`
+	Semantic     bool  `json:"semantic"`
+	Entries      int   `json:"entries"`
+	ExactHits    int64 `json:"exact_hits"`
+	SemanticHits int64 `json:"semantic_hits"`
+	Misses       int64 `json:"misses"`
+	Bypassed     int64 `json:"bypassed"`
+}
+
+var (
+	cacheStore = store.New[cacheEntry]("cache")
+
+	cacheExactHits    atomic.Int64
+	cacheSemanticHits atomic.Int64
+	cacheMisses       atomic.Int64
+	cacheBypassed     atomic.Int64
+)
+
+// embeddingModel est le modèle utilisé pour le cache sémantique
+const embeddingModel = string(openai.SmallEmbedding3)
+
+// cacheEnabled indique si le cache est actif (CACHE_ENABLED, activé par défaut)
+func cacheEnabled() bool {
+	return os.Getenv("CACHE_ENABLED") != "false"
+}
+
+// semanticCacheEnabled indique si la recherche par similarité d'embeddings est active (CACHE_SEMANTIC=true)
+func semanticCacheEnabled() bool {
+	return cacheEnabled() && os.Getenv("CACHE_SEMANTIC") == "true"
+}
+
+// cacheTTL retourne la durée de vie des entrées (CACHE_TTL, 24h par défaut)
+func cacheTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("CACHE_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// similarityThreshold retourne le seuil de similarité cosinus du cache sémantique (CACHE_SIMILARITY_THRESHOLD, 0.95 par défaut)
+func similarityThreshold() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("CACHE_SIMILARITY_THRESHOLD"), 64); err == nil && v > 0 && v <= 1 {
+		return v
+	}
+	return 0.95
+}
+
+// normalizeDescription rend la description insensible à la casse, aux espaces et à la ponctuation finale
+func normalizeDescription(desc string) string {
+	desc = strings.ToLower(strings.Join(strings.Fields(desc), " "))
+	return strings.TrimRightFunc(desc, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSpace(r) })
+}
+
+// anonymousCacheScope est le périmètre des visiteurs non connectés
+const anonymousCacheScope = "anonymous"
+
+// cacheScope retourne le périmètre de cache de l'appelant, le même que celui où CreatePitch enregistre
+// le pitch : un pitch généré pour une organisation, un utilisateur ou une clé API n'est jamais servi à un autre
+func cacheScope(ctx context.Context) string {
+	if u := UserFromContext(ctx); u != nil {
+		if orgID := WorkspaceFromContext(ctx); orgID != "" {
+			return "org:" + orgID
+		}
+		return "user:" + u.ID
+	}
+	if k := APIKeyFromContext(ctx); k != nil {
+		return "key:" + k.ID
+	}
+	return anonymousCacheScope
+}
+
+// cacheKey retourne l'empreinte du périmètre, de la description normalisée et des options de génération
+func cacheKey(scope, desc, options string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + normalizeDescription(desc) + "\x00" + options))
+	return hex.EncodeToString(sum[:])
+}
+
+// cosineSimilarity retourne la similarité cosinus de deux vecteurs
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// embedDescription calcule l'embedding de la description normalisée
+func embedDescription(ctx context.Context, desc string) []float32 {
+	client := newOpenAIClient(Providers()[0])
+	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{normalizeDescription(desc)},
+		Model: openai.EmbeddingModel(embeddingModel),
+	})
+	if err != nil || len(resp.Data) == 0 {
+		log.Printf("cache: embedding impossible: %v", err)
+		return nil
+	}
+	recordUsage(ctx, embeddingModel, TokenUsage{PromptTokens: resp.Usage.PromptTokens}, true)
+	return resp.Data[0].Embedding
+}
+
+// cacheLookup cherche un pitch dans le périmètre de l'appelant : correspondance exacte, puis similarité si le cache
+// sémantique est actif (pas pour les visiteurs anonymes, qui ne forment pas un même périmètre).
+// La recherche par similarité est sautée si le budget est épuisé : l'embedding est un appel payant.
+// L'embedding calculé est retourné pour être réutilisé par cacheStoreResult.
+func cacheLookup(ctx context.Context, desc, options string) (*models.PitchResponse, []float32) {
+	now := time.Now()
+	scope := cacheScope(ctx)
+	if e, ok := cacheStore.Get(cacheKey(scope, desc, options)); ok && now.Sub(e.CreatedAt) < cacheTTL() {
+		cacheExactHits.Add(1)
+		resp := e.Response
+		return &resp, nil
+	}
+
+	var embedding []float32
+	if semanticCacheEnabled() && scope != anonymousCacheScope && CheckBudget(ctx) == nil {
+		embedding = embedDescription(ctx, desc)
+		if embedding != nil {
+			best, bestScore := (*cacheEntry)(nil), similarityThreshold()
+			for _, e := range cacheStore.Find(func(e cacheEntry) bool {
+				return e.Scope == scope && e.Options == options && e.Embedding != nil && now.Sub(e.CreatedAt) < cacheTTL()
+			}) {
+				if score := cosineSimilarity(embedding, e.Embedding); score >= bestScore {
+					e := e
+					best, bestScore = &e, score
+				}
+			}
+			if best != nil {
+				cacheSemanticHits.Add(1)
+				resp := best.Response
+				return &resp, embedding
+			}
+		}
+	}
+
+	cacheMisses.Add(1)
+	return nil, embedding
+}
+
+// cacheStoreResult met un pitch en cache dans le périmètre de l'appelant
+// et évince les entrées expirées ou les plus anciennes (CACHE_MAX_ENTRIES)
+func cacheStoreResult(ctx context.Context, desc, options string, resp *models.PitchResponse, embedding []float32) {
+	scope := cacheScope(ctx)
+	e := cacheEntry{
+		Key:       cacheKey(scope, desc, options),
+		Scope:     scope,
+		Options:   options,
+		Response:  *resp,
+		Embedding: embedding,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := cacheStore.Put(e.Key, e); err != nil {
+		log.Printf("cache: écriture impossible: %v", err)
+	}
+
+	if _, err := cacheStore.DeleteMany(cacheEvictions(cacheStore.List(), envInt("CACHE_MAX_ENTRIES", 1000), time.Now())); err != nil {
+		log.Printf("cache: éviction impossible: %v", err)
+	}
+}
+
+// cacheEvictions retourne les clés des entrées expirées et des plus anciennes au-delà de maxEntries
+func cacheEvictions(entries []cacheEntry, maxEntries int, now time.Time) []string {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	var keys []string
+	for i, old := range entries {
+		if now.Sub(old.CreatedAt) >= cacheTTL() || len(entries)-i > maxEntries {
+			keys = append(keys, old.Key)
+		}
+	}
+	return keys
+}
+
+// GetCacheStats retourne les compteurs du cache
+func GetCacheStats() CacheStats {
+	return CacheStats{
+		Enabled:      cacheEnabled(),
+		Semantic:     semanticCacheEnabled(),
+		Entries:      len(cacheStore.List()),
+		ExactHits:    cacheExactHits.Load(),
+		SemanticHits: cacheSemanticHits.Load(),
+		Misses:       cacheMisses.Load(),
+		Bypassed:     cacheBypassed.Load(),
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pitch/models"
+)
+
+func TestCacheScope(t *testing.T) {
+	ana := &models.User{ID: "ana"}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "anonyme", ctx: context.Background(), want: anonymousCacheScope},
+		{name: "utilisateur", ctx: WithUser(context.Background(), ana), want: "user:ana"},
+		{name: "organisation", ctx: WithWorkspace(WithUser(context.Background(), ana), "org1"), want: "org:org1"},
+		{name: "clé API", ctx: WithAPIKey(context.Background(), &models.APIKey{ID: "k1"}), want: "key:k1"},
+		{name: "espace sans utilisateur", ctx: WithWorkspace(context.Background(), "org1"), want: anonymousCacheScope},
+	}
+	for _, tt := range tests {
+		if got := cacheScope(tt.ctx); got != tt.want {
+			t.Errorf("%s : cacheScope() = %q, veut %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	if cacheKey("org:a", "Une app  de covoiturage.", "o") != cacheKey("org:a", "une app de covoiturage", "o") {
+		t.Error("la clé doit ignorer la casse, les espaces et la ponctuation finale")
+	}
+	if cacheKey("org:a", "desc", "o") == cacheKey("org:b", "desc", "o") {
+		t.Error("deux périmètres ne doivent pas partager une clé")
+	}
+	if cacheKey("org:a", "desc", "o1") == cacheKey("org:a", "desc", "o2") {
+		t.Error("deux jeux d'options ne doivent pas partager une clé")
+	}
+}
+
+func TestCacheLookupIsolatesTenants(t *testing.T) {
+	t.Setenv("CACHE_SEMANTIC", "false")
+	ana := &models.User{ID: "ana-cache"}
+	orgA := WithWorkspace(WithUser(context.Background(), ana), "orgA-cache")
+	orgB := WithWorkspace(WithUser(context.Background(), ana), "orgB-cache")
+	desc := "Une plateforme de mise en relation pour tester le cache"
+
+	cacheStoreResult(orgA, desc, "opts", &models.PitchResponse{Probleme: "secret de A"}, nil)
+
+	if got, _ := cacheLookup(orgA, desc, "opts"); got == nil || got.Probleme != "secret de A" {
+		t.Fatalf("cacheLookup(orgA) = %v, veut le pitch en cache", got)
+	}
+	if got, _ := cacheLookup(orgB, desc, "opts"); got != nil {
+		t.Fatalf("cacheLookup(orgB) = %v, l'organisation B ne doit pas voir le pitch de A", got)
+	}
+	if got, _ := cacheLookup(WithUser(context.Background(), ana), desc, "opts"); got != nil {
+		t.Fatalf("l'espace personnel ne doit pas voir le pitch de l'organisation")
+	}
+}
+
+func TestSemanticLookupRespectsBudget(t *testing.T) {
+	var embeddings atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		embeddings.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],` +
+			`"model":"text-embedding-3-small","usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("OPENAI_BASE_URL", srv.URL)
+	t.Setenv("OPENAI_API_KEY", "test")
+	t.Setenv("CACHE_SEMANTIC", "true")
+	t.Setenv("USER_MONTHLY_BUDGET_USD", "0.00001")
+
+	ctx := WithUser(context.Background(), &models.User{ID: "budget-cache"})
+	desc := "Une application pour vérifier le budget du cache sémantique"
+	if _, embedding := cacheLookup(ctx, desc, "opts"); embedding == nil || embeddings.Load() != 1 {
+		t.Fatalf("sous le budget : embedding %v, %d appels, veut un appel", embedding, embeddings.Load())
+	}
+
+	// 1000 tokens d'embedding = 0,00002 $ : budget dépassé
+	recordUsage(ctx, embeddingModel, TokenUsage{PromptTokens: 1000}, true)
+	if _, embedding := cacheLookup(ctx, desc, "opts"); embedding != nil || embeddings.Load() != 1 {
+		t.Errorf("budget dépassé : embedding %v, %d appels, veut aucun nouvel appel", embedding, embeddings.Load())
+	}
+
+	// La correspondance exacte, gratuite, reste servie
+	cacheStoreResult(ctx, desc, "opts", &models.PitchResponse{Probleme: "en cache"}, nil)
+	if got, _ := cacheLookup(ctx, desc, "opts"); got == nil || got.Probleme != "en cache" {
+		t.Errorf("cacheLookup(exacte) = %v, veut le pitch en cache", got)
+	}
+}
+
+func TestCacheEvictions(t *testing.T) {
+	t.Setenv("CACHE_TTL", "1h")
+	now := time.Now()
+	entries := []cacheEntry{
+		{Key: "expirée", CreatedAt: now.Add(-2 * time.Hour)},
+		{Key: "ancienne", CreatedAt: now.Add(-30 * time.Minute)},
+		{Key: "récente", CreatedAt: now.Add(-10 * time.Minute)},
+		{Key: "neuve", CreatedAt: now},
+	}
+	got := cacheEvictions(entries, 2, now)
+	want := []string{"expirée", "ancienne"}
+	if len(got) != len(want) {
+		t.Fatalf("cacheEvictions() = %v, veut %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("cacheEvictions() = %v, veut %v", got, want)
+		}
+	}
+	if got := cacheEvictions(entries[2:], 10, now); len(got) != 0 {
+		t.Errorf("aucune éviction attendue, obtenu %v", got)
+	}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// ErrCohortPitch est retourné quand on rattache à une équipe un pitch d'une autre organisation
+var ErrCohortPitch = errors.New("ce pitch n'appartient pas à l'organisation de la cohorte")
+
+// MaxCohortNameLength est la longueur maximale du nom d'une cohorte ou d'une équipe
+const MaxCohortNameLength = 80
+
+var cohortStore = store.New[models.Cohort]("cohorts")
+
+// cohortName valide le nom d'une cohorte ou d'une équipe (what : « de la cohorte », « de l'équipe »)
+func cohortName(name, what string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("le nom " + what + " est obligatoire")
+	}
+	if utf8.RuneCountInString(name) > MaxCohortNameLength {
+		return "", errors.New("le nom " + what + " est trop long")
+	}
+	return name, nil
+}
+
+// canViewCohorts indique si le rôle donne accès aux tableaux de bord des cohortes.
+// Les fondateurs n'y ont pas accès : le classement compare leur startup aux autres.
+func canViewCohorts(role string) bool {
+	return role == models.OrgRoleOwner || role == models.OrgRoleMentor || role == models.OrgRoleViewer
+}
+
+// memberCohort retourne la cohorte id et le rôle de l'utilisateur connecté dans son organisation ;
+// une cohorte d'une organisation dont il n'est pas membre est introuvable
+func memberCohort(ctx context.Context, id string) (*models.Cohort, string, error) {
+	c, ok := cohortStore.Get(id)
+	if !ok {
+		return nil, "", store.ErrNotFound
+	}
+	_, role, err := memberOrg(ctx, c.OrgID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !canViewCohorts(role) {
+		return nil, "", ErrOrgForbidden
+	}
+	return &c, role, nil
+}
+
+// ownedCohort retourne la cohorte id si l'utilisateur connecté est propriétaire de son organisation
+func ownedCohort(ctx context.Context, id string) (*models.Cohort, error) {
+	c, role, err := memberCohort(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.OrgRoleOwner {
+		return nil, ErrOrgForbidden
+	}
+	return c, nil
+}
+
+// CreateCohort crée une cohorte dans l'organisation ; réservé aux propriétaires
+func CreateCohort(ctx context.Context, orgID, name string) (*models.Cohort, error) {
+	if _, err := ownedOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	name, err := cohortName(name, "de la cohorte")
+	if err != nil {
+		return nil, err
+	}
+	c := models.Cohort{
+		ID:        store.NewID(),
+		OrgID:     orgID,
+		Name:      name,
+		Teams:     []models.CohortTeam{},
+		CreatedBy: UserFromContext(ctx).ID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := cohortStore.Put(c.ID, c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCohorts retourne les cohortes de l'organisation, de la plus récente à la plus ancienne
+func ListCohorts(ctx context.Context, orgID string) ([]models.Cohort, error) {
+	_, role, err := memberOrg(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewCohorts(role) {
+		return nil, ErrOrgForbidden
+	}
+	cohorts := cohortStore.Find(func(c models.Cohort) bool { return c.OrgID == orgID })
+	sort.Slice(cohorts, func(i, j int) bool {
+		return cohorts[i].CreatedAt.After(cohorts[j].CreatedAt)
+	})
+	return cohorts, nil
+}
+
+// DeleteCohort supprime la cohorte ; les pitchs de ses équipes sont conservés
+func DeleteCohort(ctx context.Context, id string) (*models.Cohort, error) {
+	c, err := ownedCohort(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cohortStore.Delete(id); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddCohortTeam ajoute une équipe à la cohorte
+func AddCohortTeam(ctx context.Context, id, name string) (*models.CohortTeam, error) {
+	if _, err := ownedCohort(ctx, id); err != nil {
+		return nil, err
+	}
+	name, err := cohortName(name, "de l'équipe")
+	if err != nil {
+		return nil, err
+	}
+	team := models.CohortTeam{ID: store.NewID(), Name: name, PitchIDs: []string{}, AddedAt: time.Now().UTC()}
+	_, err = cohortStore.Update(id, func(c *models.Cohort) error {
+		for _, t := range c.Teams {
+			if strings.EqualFold(t.Name, name) {
+				return errors.New("une équipe porte déjà ce nom dans la cohorte")
+			}
+		}
+		c.Teams = append(c.Teams, team)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// RemoveCohortTeam retire une équipe de la cohorte
+func RemoveCohortTeam(ctx context.Context, id, teamID string) error {
+	if _, err := ownedCohort(ctx, id); err != nil {
+		return err
+	}
+	_, err := cohortStore.Update(id, func(c *models.Cohort) error {
+		for i, t := range c.Teams {
+			if t.ID == teamID {
+				c.Teams = append(c.Teams[:i], c.Teams[i+1:]...)
+				return nil
+			}
+		}
+		return store.ErrNotFound
+	})
+	return err
+}
+
+// AttachTeamPitch rattache un pitch de l'organisation à une équipe, comme nouvelle version de son pitch.
+// Un pitch n'appartient qu'à une équipe de la cohorte : il est retiré de celle qui l'avait.
+func AttachTeamPitch(ctx context.Context, id, teamID, pitchID string) error {
+	c, err := ownedCohort(ctx, id)
+	if err != nil {
+		return err
+	}
+	p, ok := readablePitches(ctx).Get(pitchID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if p.OrgID != c.OrgID {
+		return ErrCohortPitch
+	}
+	_, err = cohortStore.Update(id, func(c *models.Cohort) error {
+		team, ok := c.Team(teamID)
+		if !ok {
+			return store.ErrNotFound
+		}
+		for i := range c.Teams {
+			c.Teams[i].PitchIDs = removeID(c.Teams[i].PitchIDs, pitchID)
+		}
+		team.PitchIDs = append(team.PitchIDs, pitchID)
+		return nil
+	})
+	return err
+}
+
+// DetachTeamPitch retire un pitch d'une équipe ; le pitch lui-même est conservé
+func DetachTeamPitch(ctx context.Context, id, teamID, pitchID string) error {
+	if _, err := ownedCohort(ctx, id); err != nil {
+		return err
+	}
+	_, err := cohortStore.Update(id, func(c *models.Cohort) error {
+		team, ok := c.Team(teamID)
+		if !ok {
+			return store.ErrNotFound
+		}
+		team.PitchIDs = removeID(team.PitchIDs, pitchID)
+		return nil
+	})
+	return err
+}
+
+// removeID retourne ids sans id
+func removeID(ids []string, id string) []string {
+	out := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// GetCohortDashboard retourne le tableau de bord de la cohorte et le rôle de l'utilisateur connecté
+func GetCohortDashboard(ctx context.Context, id string) (*models.CohortDashboard, string, error) {
+	c, role, err := memberCohort(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return cohortDashboard(readablePitches(ctx), c), role, nil
+}
+
+// cohortDashboard calcule la progression de chaque équipe et le classement de la cohorte à partir des pitchs visibles
+func cohortDashboard(pitches store.Scope[models.Pitch], c *models.Cohort) *models.CohortDashboard {
+	d := &models.CohortDashboard{Cohort: *c}
+	counts := map[string]int{}
+	total := 0
+	for _, team := range c.Teams {
+		tp := teamProgress(pitches, c.OrgID, team)
+		if tp.Latest != nil {
+			counts[tp.Latest.ReviewStatus]++
+		}
+		if tp.Score > 0 {
+			d.Scored++
+			total += tp.Score
+		}
+		d.Teams = append(d.Teams, tp)
+	}
+	if d.Scored > 0 {
+		d.AverageScore = float64(total) / float64(d.Scored)
+	}
+	for _, status := range reviewOrder {
+		if counts[status] > 0 {
+			d.Statuses = append(d.Statuses, models.StatusCount{Status: status, Count: counts[status]})
+		}
+	}
+
+	// Classement par note décroissante ; à note égale, l'équipe la plus active d'abord
+	sort.SliceStable(d.Teams, func(i, j int) bool {
+		a, b := d.Teams[i], d.Teams[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.LastActivity.Equal(b.LastActivity) {
+			return a.LastActivity.After(b.LastActivity)
+		}
+		return strings.ToLower(a.Team.Name) < strings.ToLower(b.Team.Name)
+	})
+	for i := range d.Teams {
+		switch {
+		case d.Teams[i].Score == 0:
+			// Les équipes non notées ne sont pas classées
+		case i > 0 && d.Teams[i].Score == d.Teams[i-1].Score:
+			d.Teams[i].Rank = d.Teams[i-1].Rank
+		default:
+			d.Teams[i].Rank = i + 1
+		}
+	}
+	return d
+}
+
+// teamProgress retrace les versions du pitch d'une équipe. Les pitchs supprimés ou sortis de l'organisation
+// sont ignorés.
+func teamProgress(pitches store.Scope[models.Pitch], orgID string, team models.CohortTeam) models.TeamProgress {
+	tp := models.TeamProgress{Team: team, LastActivity: team.AddedAt}
+	for _, id := range team.PitchIDs {
+		p, ok := pitches.Get(id)
+		if !ok || p.OrgID != orgID {
+			continue
+		}
+		tp.Points = append(tp.Points, models.ProgressPoint{
+			PitchID:      p.ID,
+			Description:  p.Description,
+			CreatedAt:    p.CreatedAt,
+			ReviewStatus: p.Review(),
+			Score:        p.Score(),
+		})
+		if at := lastActivity(p); at.After(tp.LastActivity) {
+			tp.LastActivity = at
+		}
+	}
+	sort.Slice(tp.Points, func(i, j int) bool {
+		return tp.Points[i].CreatedAt.Before(tp.Points[j].CreatedAt)
+	})
+	for _, pt := range tp.Points {
+		if pt.Score == 0 {
+			continue
+		}
+		if tp.FirstScore == 0 {
+			tp.FirstScore = pt.Score
+		}
+		tp.Score = pt.Score
+	}
+	if n := len(tp.Points); n > 0 {
+		tp.Latest = &tp.Points[n-1]
+	}
+	return tp
+}
+
+// lastActivity retourne la date du dernier changement du pitch : création, co-édition ou revue
+func lastActivity(p models.Pitch) time.Time {
+	at := p.CreatedAt
+	if p.UpdatedAt != nil && p.UpdatedAt.After(at) {
+		at = *p.UpdatedAt
+	}
+	if n := len(p.ReviewHistory); n > 0 && p.ReviewHistory[n-1].At.After(at) {
+		at = p.ReviewHistory[n-1].At
+	}
+	return at
+}
+
+// WriteCohortCSV écrit le tableau de bord de la cohorte : une ligne par équipe, dans l'ordre du classement
+func WriteCohortCSV(w io.Writer, d *models.CohortDashboard) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rang", "equipe", "pitch_id", "description", "date_pitch", "versions", "statut_revue", "note", "premiere_note", "progression", "notes_successives", "derniere_activite"})
+	for _, t := range d.Teams {
+		record := []string{"", csvText(t.Team.Name), "", "", "", strconv.Itoa(len(t.Points)), "", "", "", "", "", t.LastActivity.Format(time.RFC3339)}
+		if t.Rank > 0 {
+			record[0] = strconv.Itoa(t.Rank)
+		}
+		if t.Latest != nil {
+			record[2], record[3] = t.Latest.PitchID, csvText(t.Latest.Description)
+			record[4] = t.Latest.CreatedAt.Format(time.RFC3339)
+			record[6] = t.Latest.ReviewStatus
+		}
+		if t.Score > 0 {
+			record[7] = strconv.Itoa(t.Score)
+			record[8] = strconv.Itoa(t.FirstScore)
+			record[9] = strconv.Itoa(t.ScoreDelta())
+		}
+		var scores []string
+		for _, pt := range t.Points {
+			if pt.Score > 0 {
+				scores = append(scores, strconv.Itoa(pt.Score))
+			}
+		}
+		record[10] = strings.Join(scores, " > ")
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
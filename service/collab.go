@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"pitch/models"
+	"pitch/render"
+	"pitch/store"
+)
+
+// Erreurs de la co-édition
+var (
+	ErrCollabForbidden = errors.New("ce lien ne permet pas de modifier le pitch")
+	ErrSectionLocked   = errors.New("cette section est en cours de modification par un autre participant")
+	ErrLockRequired    = errors.New("prenez la main sur la section avant de la modifier")
+	ErrStaleVersion    = errors.New("la section a changé entre-temps : la dernière version a été rechargée")
+	ErrSectionTooLong  = errors.New("la section est trop longue")
+	ErrCollabMessage   = errors.New("message de co-édition invalide")
+)
+
+const (
+	// MaxSectionLength est la longueur maximale (en caractères) d'une section modifiée
+	MaxSectionLength = 10000
+	// collabLockTTL est la durée après laquelle un verrou sans activité peut être repris par un autre participant
+	collabLockTTL = 30 * time.Second
+	// collabSendBuffer est le nombre de messages en attente au-delà duquel un participant trop lent est déconnecté
+	collabSendBuffer = 64
+)
+
+// collabColors sont les couleurs attribuées tour à tour aux participants
+var collabColors = []string{"#2563eb", "#dc2626", "#16a34a", "#d97706", "#9333ea", "#0891b2", "#db2777", "#4b5563"}
+
+// CollabPeer est un participant connecté à la co-édition d'un pitch
+type CollabPeer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Color   string `json:"color"`
+	Section string `json:"section,omitempty"` // section où se trouve son curseur
+	Cursor  int    `json:"cursor"`            // position du curseur dans la section
+}
+
+// CollabSection est l'état d'une section envoyé à un participant qui rejoint la co-édition
+type CollabSection struct {
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	Version  int    `json:"version"`
+	LockedBy string `json:"locked_by,omitempty"`
+}
+
+// collabRequest est un message d'un participant :
+// lock / unlock (prendre ou rendre la main sur une section), edit (nouveau contenu), cursor (position du curseur)
+type collabRequest struct {
+	Type    string  `json:"type"`
+	Section string  `json:"section"`
+	Version int     `json:"version"`
+	Content *string `json:"content"`
+	Cursor  int     `json:"cursor"`
+}
+
+// collabEvent est un message diffusé aux participants
+type collabEvent struct {
+	Type     string          `json:"type"` // welcome, presence, lock, unlock, edit, error
+	You      string          `json:"you,omitempty"`
+	Section  string          `json:"section,omitempty"`
+	Version  int             `json:"version,omitempty"`
+	Content  *string         `json:"content,omitempty"`
+	By       string          `json:"by,omitempty"`
+	Peers    []CollabPeer    `json:"peers,omitempty"`
+	Sections []CollabSection `json:"sections,omitempty"`
+	Message  string          `json:"message,omitempty"`
+}
+
+// collabSection est l'état partagé d'une section : version courante et verrou
+type collabSection struct {
+	version  int
+	lockedBy *CollabClient
+	activeAt time.Time // dernière activité du détenteur du verrou
+}
+
+// collabRoom réunit les participants qui modifient un même pitch
+type collabRoom struct {
+	pitchID  string
+	mu       sync.Mutex
+	clients  map[*CollabClient]bool
+	sections map[string]*collabSection
+	joined   int // nombre de participants accueillis (choix de la couleur)
+}
+
+// CollabClient est la connexion d'un participant à la co-édition d'un pitch
+type CollabClient struct {
+	room *collabRoom
+	peer CollabPeer
+	seq  int // ordre d'arrivée dans la salle
+	send chan []byte
+	// allowed vérifie à chaque modification que le participant y est toujours autorisé (lien non révoqué…)
+	allowed func() bool
+}
+
+var collabRooms = struct {
+	sync.Mutex
+	m map[string]*collabRoom
+}{m: make(map[string]*collabRoom)}
+
+// JoinPitchCollab fait rejoindre la co-édition du pitch à un de ses auteurs connecté ;
+// un auteur retiré de l'organisation ou rétrogradé en cours de session ne peut plus modifier le pitch
+func JoinPitchCollab(ctx context.Context, pitchID string) (*CollabClient, error) {
+	p, err := authoredPitch(ctx, pitchID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := func() bool {
+		p, ok := pitchStore.Get(pitchID)
+		return ok && canAuthor(tenantOf(ctx).pitchRole(p))
+	}
+	return joinCollab(p.ID, AuthorActor(UserFromContext(ctx)).Name, allowed)
+}
+
+// JoinShareCollab fait rejoindre la co-édition à un co-fondateur venu par un lien de partage « co-édition »
+func JoinShareCollab(s *models.ShareLink, name string) (*CollabClient, error) {
+	if !s.CanEdit() {
+		return nil, ErrCollabForbidden
+	}
+	actor := ShareActor(s, name)
+	if utf8.RuneCountInString(actor.Name) > MaxAuthorLength {
+		return nil, ErrAuthorTooLong
+	}
+	id := s.ID
+	allowed := func() bool {
+		s, ok := shareStore.Get(id)
+		return ok && s.Active() && s.CanEdit()
+	}
+	return joinCollab(s.PitchID, actor.Name, allowed)
+}
+
+// joinCollab ajoute un participant à la salle du pitch et lui envoie l'état courant
+func joinCollab(pitchID, name string, allowed func() bool) (*CollabClient, error) {
+	collabRooms.Lock()
+	defer collabRooms.Unlock()
+
+	p, ok := pitchStore.Get(pitchID)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	room := collabRooms.m[pitchID]
+	if room == nil {
+		room = &collabRoom{pitchID: pitchID, clients: make(map[*CollabClient]bool), sections: make(map[string]*collabSection)}
+		for _, s := range render.Sections(nil) {
+			room.sections[s.Key] = &collabSection{version: 1}
+		}
+		collabRooms.m[pitchID] = room
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	c := &CollabClient{
+		room: room,
+		peer: CollabPeer{
+			ID:    store.RandomToken(4),
+			Name:  name,
+			Color: collabColors[room.joined%len(collabColors)],
+		},
+		seq:     room.joined,
+		send:    make(chan []byte, collabSendBuffer),
+		allowed: allowed,
+	}
+	room.joined++
+	room.clients[c] = true
+
+	welcome := collabEvent{Type: "welcome", You: c.peer.ID, Peers: room.peers()}
+	for _, s := range render.Sections(p.Sections) {
+		cs := CollabSection{Key: s.Key, Title: s.Title, Content: s.Content, Version: room.sections[s.Key].version}
+		if holder := room.lockHolder(s.Key); holder != nil {
+			cs.LockedBy = holder.peer.ID
+		}
+		welcome.Sections = append(welcome.Sections, cs)
+	}
+	room.sendTo(c, welcome)
+	room.broadcast(collabEvent{Type: "presence", Peers: room.peers()})
+	return c, nil
+}
+
+// Outbox retourne les messages (JSON) à transmettre au participant ; le canal est fermé quand il quitte la salle
+func (c *CollabClient) Outbox() <-chan []byte {
+	return c.send
+}
+
+// Leave retire le participant de la salle et libère ses verrous
+func (c *CollabClient) Leave() {
+	collabRooms.Lock()
+	defer collabRooms.Unlock()
+	room := c.room
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.remove(c)
+	if len(room.clients) == 0 && collabRooms.m[room.pitchID] == room {
+		delete(collabRooms.m, room.pitchID)
+	}
+}
+
+// Handle traite un message du participant ; les erreurs lui sont renvoyées sous forme d'événement « error »
+func (c *CollabClient) Handle(data []byte) {
+	room := c.room
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if !room.clients[c] {
+		return
+	}
+
+	var req collabRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		room.sendTo(c, collabEvent{Type: "error", Message: ErrCollabMessage.Error()})
+		return
+	}
+	sec := room.sections[req.Section]
+	if sec == nil && req.Type != "cursor" {
+		room.sendTo(c, collabEvent{Type: "error", Message: ErrUnknownSection.Error()})
+		return
+	}
+
+	var err error
+	switch req.Type {
+	case "lock":
+		err = room.lock(c, req.Section)
+	case "unlock":
+		if sec.lockedBy == c {
+			room.unlock(req.Section)
+		}
+	case "edit":
+		err = room.edit(c, req)
+	case "cursor":
+		c.peer.Section, c.peer.Cursor = "", 0
+		if sec != nil {
+			c.peer.Section, c.peer.Cursor = req.Section, max(req.Cursor, 0)
+			if sec.lockedBy == c {
+				sec.activeAt = time.Now()
+			}
+		}
+		room.broadcast(collabEvent{Type: "presence", Peers: room.peers()})
+	default:
+		err = ErrCollabMessage
+	}
+	if err != nil {
+		room.sendTo(c, collabEvent{Type: "error", Section: req.Section, Message: err.Error()})
+	}
+}
+
+// lockHolder retourne le détenteur actif du verrou de la section, nil si elle est libre ou si le verrou a expiré
+func (r *collabRoom) lockHolder(key string) *CollabClient {
+	sec := r.sections[key]
+	if sec.lockedBy == nil || time.Since(sec.activeAt) > collabLockTTL {
+		return nil
+	}
+	return sec.lockedBy
+}
+
+// lock donne la main sur la section au participant ; il rend celle qu'il détenait éventuellement
+func (r *collabRoom) lock(c *CollabClient, key string) error {
+	if holder := r.lockHolder(key); holder != nil && holder != c {
+		return ErrSectionLocked
+	}
+	for k, sec := range r.sections {
+		if k != key && sec.lockedBy == c {
+			r.unlock(k)
+		}
+	}
+	sec := r.sections[key]
+	sec.lockedBy = c
+	sec.activeAt = time.Now()
+	r.broadcast(collabEvent{Type: "lock", Section: key, By: c.peer.ID})
+	return nil
+}
+
+// unlock libère la section
+func (r *collabRoom) unlock(key string) {
+	r.sections[key].lockedBy = nil
+	r.broadcast(collabEvent{Type: "unlock", Section: key})
+}
+
+// edit enregistre le nouveau contenu d'une section verrouillée par le participant et le diffuse.
+// La version envoyée doit être la version courante : une modification partie d'un contenu périmé est refusée.
+func (r *collabRoom) edit(c *CollabClient, req collabRequest) error {
+	sec := r.sections[req.Section]
+	if sec.lockedBy != c {
+		return ErrLockRequired
+	}
+	if !c.allowed() {
+		r.unlock(req.Section)
+		return ErrCollabForbidden
+	}
+	if req.Content == nil {
+		return ErrCollabMessage
+	}
+	if req.Version != sec.version {
+		r.resync(c, req.Section)
+		return ErrStaleVersion
+	}
+	content := strings.ReplaceAll(*req.Content, "\r\n", "\n")
+	if utf8.RuneCountInString(content) > MaxSectionLength {
+		return ErrSectionTooLong
+	}
+
+	now := time.Now().UTC()
+	_, err := pitchStore.Update(r.pitchID, func(p *models.Pitch) error {
+		if p.Sections == nil {
+			p.Sections = &models.PitchResponse{}
+		}
+		p.Sections.SetSection(req.Section, content)
+		p.UpdatedAt = &now
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sec.version++
+	sec.activeAt = time.Now()
+	r.broadcast(collabEvent{Type: "edit", Section: req.Section, Version: sec.version, Content: &content, By: c.peer.ID})
+	return nil
+}
+
+// resync renvoie au participant le contenu enregistré et la version courante de la section
+func (r *collabRoom) resync(c *CollabClient, key string) {
+	p, ok := pitchStore.Get(r.pitchID)
+	if !ok || p.Sections == nil {
+		return
+	}
+	content, _ := p.Sections.Section(key)
+	r.sendTo(c, collabEvent{Type: "edit", Section: key, Version: r.sections[key].version, Content: &content})
+}
+
+// peers retourne les participants connectés, par ordre d'arrivée
+func (r *collabRoom) peers() []CollabPeer {
+	clients := make([]*CollabClient, 0, len(r.clients))
+	for c := range r.clients {
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].seq < clients[j].seq })
+	peers := make([]CollabPeer, len(clients))
+	for i, c := range clients {
+		peers[i] = c.peer
+	}
+	return peers
+}
+
+// sendTo met un événement en file pour un participant ; un participant dont la file est pleine est déconnecté
+func (r *collabRoom) sendTo(c *CollabClient, ev collabEvent) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		r.remove(c)
+	}
+}
+
+// broadcast envoie un événement à tous les participants
+func (r *collabRoom) broadcast(ev collabEvent) {
+	for c := range r.clients {
+		r.sendTo(c, ev)
+	}
+}
+
+// remove retire un participant, ferme sa file et libère ses verrous (r.mu doit être détenu)
+func (r *collabRoom) remove(c *CollabClient) {
+	if !r.clients[c] {
+		return
+	}
+	delete(r.clients, c)
+	close(c.send)
+	for k, sec := range r.sections {
+		if sec.lockedBy == c {
+			r.unlock(k)
+		}
+	}
+	r.broadcast(collabEvent{Type: "presence", Peers: r.peers()})
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+func TestJoinShareCollabAuthorLength(t *testing.T) {
+	p := models.Pitch{ID: store.NewID(), Description: "Une place de marché pour les artisans", CreatedAt: time.Now().UTC()}
+	if err := pitchStore.Put(p.ID, p); err != nil {
+		t.Fatal(err)
+	}
+	link := models.ShareLink{ID: store.NewID(), PitchID: p.ID, Permission: models.SharePermissionEdit, CreatedAt: time.Now().UTC()}
+	if err := shareStore.Put(link.ID, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		nom  string
+		want error
+	}{
+		{name: "nom vide", nom: "", want: nil},
+		{name: "nom à la limite", nom: strings.Repeat("é", MaxAuthorLength), want: nil},
+		{name: "nom trop long", nom: strings.Repeat("é", MaxAuthorLength+1), want: ErrAuthorTooLong},
+		{name: "espaces ignorés", nom: "  " + strings.Repeat("a", MaxAuthorLength) + "  ", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := JoinShareCollab(&link, tt.nom)
+			if c != nil {
+				defer c.Leave()
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("JoinShareCollab(%d runes) = %v, veut %v", len([]rune(tt.nom)), err, tt.want)
+			}
+		})
+	}
+
+	// Un lien sans droit d'édition ne rejoint pas la co-édition, quel que soit le nom
+	read := link
+	read.Permission = models.SharePermissionRead
+	if _, err := JoinShareCollab(&read, "Alice"); !errors.Is(err, ErrCollabForbidden) {
+		t.Errorf("JoinShareCollab(lecture) = %v, veut %v", err, ErrCollabForbidden)
+	}
+}
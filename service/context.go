@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"pitch/models"
+)
+
+type contextKey string
+
+const apiKeyContextKey contextKey = "api_key"
+
+// WithAPIKey retourne un contexte portant la clé API authentifiée
+func WithAPIKey(ctx context.Context, k *models.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, k)
+}
+
+// APIKeyFromContext retourne la clé API authentifiée, ou nil
+func APIKeyFromContext(ctx context.Context) *models.APIKey {
+	k, _ := ctx.Value(apiKeyContextKey).(*models.APIKey)
+	return k
+}
+
+const userContextKey contextKey = "user"
+
+// WithUser retourne un contexte portant l'utilisateur connecté
+func WithUser(ctx context.Context, u *models.User) context.Context {
+	return context.WithValue(ctx, userContextKey, u)
+}
+
+// UserFromContext retourne l'utilisateur connecté, ou nil
+func UserFromContext(ctx context.Context) *models.User {
+	u, _ := ctx.Value(userContextKey).(*models.User)
+	return u
+}
+
+const workspaceContextKey contextKey = "workspace"
+
+// WithWorkspace retourne un contexte portant l'organisation choisie comme espace de travail
+func WithWorkspace(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, workspaceContextKey, orgID)
+}
+
+// WorkspaceFromContext retourne l'organisation de l'espace de travail courant, vide pour l'espace personnel
+func WorkspaceFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(workspaceContextKey).(string)
+	return id
+}
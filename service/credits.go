@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// Coût en crédits des opérations facturées
+const (
+	CreditCostGeneration = 1
+	CreditCostExport     = 1
+	CreditCostJudge      = 1 // par juré d'un jury simulé
+)
+
+// Erreurs du système de crédits
+var (
+	ErrOutOfCredits    = errors.New("crédits épuisés")
+	ErrAccountRequired = errors.New("un compte est nécessaire pour générer un pitch")
+)
+
+var (
+	creditStore = store.New[models.CreditEntry]("credits")
+	orderStore  = store.New[models.Order]("orders")
+
+	// creditMu sérialise les opérations sur les soldes (vérification puis débit)
+	creditMu sync.Mutex
+)
+
+// CreditsEnabled indique si les générations sont décomptées des crédits (CREDITS_ENABLED=true)
+func CreditsEnabled() bool {
+	return os.Getenv("CREDITS_ENABLED") == "true"
+}
+
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// Plans retourne les offres disponibles (crédits configurables par FREE_MONTHLY_CREDITS et PREMIUM_MONTHLY_CREDITS)
+func Plans() []models.Plan {
+	return []models.Plan{
+		{ID: "free", Name: "Gratuit", MonthlyCredits: envInt("FREE_MONTHLY_CREDITS", 5)},
+		{ID: "premium", Name: "Premium", MonthlyCredits: envInt("PREMIUM_MONTHLY_CREDITS", 100), PriceCents: envInt("PREMIUM_PRICE_CENTS", 990)},
+	}
+}
+
+// CreditPacks retourne les lots de crédits achetables
+func CreditPacks() []models.CreditPack {
+	return []models.CreditPack{
+		{ID: "pack10", Credits: 10, PriceCents: 500},
+		{ID: "pack50", Credits: 50, PriceCents: 2000},
+	}
+}
+
+// UserPlan retourne le plan de l'utilisateur (gratuit par défaut)
+func UserPlan(u *models.User) models.Plan {
+	plans := Plans()
+	for _, p := range plans {
+		if p.ID == u.Plan {
+			return p
+		}
+	}
+	return plans[0]
+}
+
+func addCreditEntry(userID, kind string, amount int, reason, ref string) (models.CreditEntry, error) {
+	e := models.CreditEntry{
+		ID:        store.NewID(),
+		UserID:    userID,
+		Kind:      kind,
+		Amount:    amount,
+		Reason:    reason,
+		Reference: ref,
+		CreatedAt: time.Now().UTC(),
+	}
+	return e, creditStore.Put(e.ID, e)
+}
+
+// creditEntries retourne les écritures de l'utilisateur, de la plus ancienne à la plus récente
+func creditEntries(userID string) []models.CreditEntry {
+	entries := creditStore.Find(func(e models.CreditEntry) bool { return e.UserID == userID })
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries
+}
+
+func balanceOf(entries []models.CreditEntry) int {
+	total := 0
+	for _, e := range entries {
+		total += e.Amount
+	}
+	return total
+}
+
+// ensureMonthlyGrant crédite les crédits mensuels du plan une fois par mois et fait expirer
+// les crédits mensuels non consommés du mois précédent (appelé avec creditMu pris)
+func ensureMonthlyGrant(u *models.User) error {
+	ref := "monthly:" + time.Now().UTC().Format("2006-01")
+	entries := creditEntries(u.ID)
+
+	var last *models.CreditEntry
+	for i := range entries {
+		if entries[i].Kind == models.CreditGrant && strings.HasPrefix(entries[i].Reference, "monthly:") {
+			if entries[i].Reference == ref {
+				return nil
+			}
+			last = &entries[i]
+		}
+	}
+
+	// Les débits consomment d'abord les crédits mensuels : le reliquat expire
+	if last != nil {
+		remaining := last.Amount
+		for _, e := range entries {
+			if e.CreatedAt.After(last.CreatedAt) && (e.Kind == models.CreditDebit || e.Kind == models.CreditRefund) {
+				remaining += e.Amount
+			}
+		}
+		if balance := balanceOf(entries); remaining > balance {
+			remaining = balance
+		}
+		if remaining > 0 {
+			if _, err := addCreditEntry(u.ID, models.CreditExpire, -remaining, "Crédits mensuels non utilisés", last.Reference); err != nil {
+				return err
+			}
+		}
+	}
+
+	plan := UserPlan(u)
+	_, err := addCreditEntry(u.ID, models.CreditGrant, plan.MonthlyCredits, "Crédits mensuels du plan "+plan.Name, ref)
+	return err
+}
+
+// CreditBalance retourne le solde de crédits de l'utilisateur
+func CreditBalance(u *models.User) (int, error) {
+	creditMu.Lock()
+	defer creditMu.Unlock()
+	if err := ensureMonthlyGrant(u); err != nil {
+		return 0, err
+	}
+	return balanceOf(creditEntries(u.ID)), nil
+}
+
+// CreditHistory retourne les écritures de l'utilisateur, de la plus récente à la plus ancienne
+func CreditHistory(u *models.User) []models.CreditEntry {
+	entries := creditEntries(u.ID)
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// SpendCredits débite cost crédits pour l'appelant du contexte et retourne une fonction de remboursement.
+// Sans crédits activés, ou pour une clé API (limitée par son quota), aucun débit n'est effectué.
+func SpendCredits(ctx context.Context, cost int, reason string) (refund func(), err error) {
+	noop := func() {}
+	if !CreditsEnabled() || APIKeyFromContext(ctx) != nil {
+		return noop, nil
+	}
+	u := UserFromContext(ctx)
+	if u == nil {
+		return noop, ErrAccountRequired
+	}
+
+	creditMu.Lock()
+	defer creditMu.Unlock()
+	if err := ensureMonthlyGrant(u); err != nil {
+		return noop, err
+	}
+	if balanceOf(creditEntries(u.ID)) < cost {
+		return noop, ErrOutOfCredits
+	}
+	debit, err := addCreditEntry(u.ID, models.CreditDebit, -cost, reason, "")
+	if err != nil {
+		return noop, err
+	}
+
+	return func() {
+		creditMu.Lock()
+		defer creditMu.Unlock()
+		addCreditEntry(u.ID, models.CreditRefund, cost, "Remboursement : "+reason, debit.ID)
+	}, nil
+}
+
+// StartPurchase crée une commande (lot de crédits ou plan premium) et retourne l'URL de paiement
+func StartPurchase(ctx context.Context, u *models.User, itemID, returnURL string) (string, error) {
+	if !PaymentsEnabled() {
+		return "", ErrPaymentsDisabled
+	}
+	order := models.Order{ID: store.NewID(), UserID: u.ID, CreatedAt: time.Now().UTC()}
+	for _, p := range CreditPacks() {
+		if p.ID == itemID {
+			order.PackID = p.ID
+			order.AmountCents = p.PriceCents
+			order.Description = fmt.Sprintf("%d crédits Pitch IA", p.Credits)
+		}
+	}
+	for _, p := range Plans() {
+		if p.ID == itemID && p.PriceCents > 0 {
+			order.PlanID = p.ID
+			order.AmountCents = p.PriceCents
+			order.Description = "Abonnement " + p.Name
+		}
+	}
+	if order.Description == "" {
+		return "", errors.New("offre inconnue")
+	}
+	if err := orderStore.Put(order.ID, order); err != nil {
+		return "", err
+	}
+	return Payments.CreateCheckout(ctx, order, returnURL)
+}
+
+// errOrderAlreadyPaid signale qu'un autre retour du prestataire a déjà finalisé la commande
+var errOrderAlreadyPaid = errors.New("commande déjà payée")
+
+// CompletePurchase confirme le paiement d'une commande et crédite le compte (une seule fois)
+func CompletePurchase(ctx context.Context, u *models.User, orderID string) error {
+	stored, ok := orderStore.Get(orderID)
+	if !ok || stored.UserID != u.ID {
+		return errors.New("commande introuvable")
+	}
+	if stored.PaidAt != nil {
+		return nil
+	}
+	if !PaymentsEnabled() {
+		return ErrPaymentsDisabled
+	}
+
+	paid, err := Payments.CompleteCheckout(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	// Deux retours simultanés du prestataire peuvent arriver jusqu'ici : seul le premier marque
+	// la commande payée et crédite le compte
+	_, err = orderStore.Update(orderID, func(o *models.Order) error {
+		if o.PaidAt != nil {
+			return errOrderAlreadyPaid
+		}
+		o.PaidAt = paid.PaidAt
+		return nil
+	})
+	if errors.Is(err, errOrderAlreadyPaid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	creditMu.Lock()
+	defer creditMu.Unlock()
+	for _, p := range CreditPacks() {
+		if p.ID == stored.PackID {
+			_, err := addCreditEntry(u.ID, models.CreditPurchase, p.Credits, stored.Description, orderID)
+			return err
+		}
+	}
+	if stored.PlanID != "" {
+		if _, err := userStore.Update(u.ID, func(usr *models.User) error {
+			usr.Plan = stored.PlanID
+			return nil
+		}); err != nil {
+			return err
+		}
+		// Le passage en premium crédite immédiatement la différence avec le plan actuel
+		newPlan := UserPlan(&models.User{Plan: stored.PlanID})
+		if diff := newPlan.MonthlyCredits - UserPlan(u).MonthlyCredits; diff > 0 {
+			_, err := addCreditEntry(u.ID, models.CreditGrant, diff, "Passage au plan "+newPlan.Name, "upgrade:"+orderID)
+			return err
+		}
+	}
+	return nil
+}
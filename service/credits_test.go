@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"pitch/models"
+)
+
+func TestCompletePurchase(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER", "fake")
+	t.Setenv("FREE_MONTHLY_CREDITS", "5")
+	t.Setenv("PREMIUM_MONTHLY_CREDITS", "100")
+	ConfigurePayments()
+	t.Cleanup(func() { Payments = nil })
+	fake, _ := FakePayments()
+
+	tests := []struct {
+		name        string
+		item        string
+		pay         bool
+		err         error
+		wantBalance int
+		wantPlan    string
+	}{
+		{name: "lot payé", item: "pack10", pay: true, wantBalance: 5 + 10, wantPlan: "free"},
+		{name: "lot non payé", item: "pack50", err: ErrPaymentNotCompleted, wantBalance: 5, wantPlan: "free"},
+		{name: "passage en premium", item: "premium", pay: true, wantBalance: 5 + 95, wantPlan: "premium"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &models.User{ID: "acheteur-" + tt.item, Plan: "free"}
+			if err := userStore.Put(u.ID, *u); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := CreditBalance(u); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := StartPurchase(context.Background(), u, tt.item, "/credits"); err != nil {
+				t.Fatal(err)
+			}
+			orders := orderStore.Find(func(o models.Order) bool { return o.UserID == u.ID })
+			if len(orders) != 1 {
+				t.Fatalf("%d commandes, veut 1", len(orders))
+			}
+			orderID := orders[0].ID
+			if tt.pay {
+				fake.Pay(orderID)
+			}
+
+			if err := CompletePurchase(context.Background(), u, orderID); !errors.Is(err, tt.err) {
+				t.Fatalf("CompletePurchase() = %v, veut %v", err, tt.err)
+			}
+			// Un second retour du prestataire ne crédite pas deux fois
+			if tt.err == nil {
+				if err := CompletePurchase(context.Background(), u, orderID); err != nil {
+					t.Errorf("second CompletePurchase() = %v", err)
+				}
+			}
+			if got, _ := CreditBalance(u); got != tt.wantBalance {
+				t.Errorf("solde = %d, veut %d", got, tt.wantBalance)
+			}
+			if got, _ := userStore.Get(u.ID); UserPlan(&got).ID != tt.wantPlan {
+				t.Errorf("plan = %q, veut %q", got.Plan, tt.wantPlan)
+			}
+		})
+	}
+
+	// La commande d'un autre utilisateur est introuvable
+	u := &models.User{ID: "acheteur-pack10"}
+	intrus := &models.User{ID: "intrus-achat"}
+	for _, o := range orderStore.Find(func(o models.Order) bool { return o.UserID == u.ID }) {
+		if err := CompletePurchase(context.Background(), intrus, o.ID); err == nil {
+			t.Error("CompletePurchase accepté pour la commande d'un autre utilisateur")
+		}
+	}
+
+	Payments = nil
+	if _, err := StartPurchase(context.Background(), intrus, "pack10", "/credits"); !errors.Is(err, ErrPaymentsDisabled) {
+		t.Errorf("StartPurchase sans prestataire = %v, veut %v", err, ErrPaymentsDisabled)
+	}
+}
+
+// idempotentPayments confirme toujours la commande, comme un prestataire réel interrogé plusieurs fois ;
+// chaque confirmation attend que callers appels soient en cours pour forcer leur chevauchement
+type idempotentPayments struct {
+	callers int
+	mu      sync.Mutex
+	waiting int
+	ready   chan struct{}
+}
+
+func (p *idempotentPayments) CreateCheckout(ctx context.Context, order models.Order, returnURL string) (string, error) {
+	p.mu.Lock()
+	p.waiting = 0
+	p.ready = make(chan struct{})
+	p.mu.Unlock()
+	return "/paiement/" + order.ID, nil
+}
+
+func (p *idempotentPayments) CompleteCheckout(ctx context.Context, orderID string) (models.Order, error) {
+	p.mu.Lock()
+	if p.waiting++; p.waiting == p.callers {
+		close(p.ready)
+	}
+	ready := p.ready
+	p.mu.Unlock()
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+	}
+	now := time.Now().UTC()
+	return models.Order{ID: orderID, PaidAt: &now}, nil
+}
+
+func TestConcurrentPurchaseCompletion(t *testing.T) {
+	t.Setenv("FREE_MONTHLY_CREDITS", "5")
+	t.Setenv("PREMIUM_MONTHLY_CREDITS", "100")
+	const callers = 8
+	Payments = &idempotentPayments{callers: callers}
+	t.Cleanup(func() { Payments = nil })
+
+	for _, tt := range []struct {
+		item        string
+		wantBalance int
+	}{
+		{item: "pack10", wantBalance: 5 + 10},
+		{item: "premium", wantBalance: 5 + 95},
+	} {
+		u := &models.User{ID: "retours-" + tt.item, Plan: "free"}
+		userStore.Put(u.ID, *u)
+		if _, err := CreditBalance(u); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := StartPurchase(context.Background(), u, tt.item, "/credits"); err != nil {
+			t.Fatal(err)
+		}
+		orderID := orderStore.Find(func(o models.Order) bool { return o.UserID == u.ID })[0].ID
+
+		// Le retour navigateur et la notification du prestataire arrivent en même temps
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := CompletePurchase(context.Background(), u, orderID); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if got, _ := CreditBalance(u); got != tt.wantBalance {
+			t.Errorf("%s : solde = %d après retours simultanés, veut %d", tt.item, got, tt.wantBalance)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package service
+
+import "strings"
+
+// csvFormulaPrefixes sont les premiers caractères qu'un tableur interprète comme une formule
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText neutralise un texte libre qu'un tableur exécuterait comme une formule (injection CSV)
+// en le préfixant d'une apostrophe. Les colonnes numériques, qui peuvent être négatives, n'y passent pas.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+
+	"pitch/models"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{in: "=HYPERLINK(\"http://x\")", want: "'=HYPERLINK(\"http://x\")"},
+		{in: "+33 6 12 34 56 78", want: "'+33 6 12 34 56 78"},
+		{in: "-2+3", want: "'-2+3"},
+		{in: "@SUM(A1)", want: "'@SUM(A1)"},
+		{in: "\t=1", want: "'\t=1"},
+		{in: "Une app = utile", want: "Une app = utile"},
+		{in: "42", want: "42"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, veut %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBatchCSVEscapesFormulas(t *testing.T) {
+	b := &models.Batch{ID: "lot-csv", Rows: []models.BatchRow{{Line: 1, Name: "=cmd|' /C calc'!A0", Description: "@SUM(1+1)", Status: models.JobFailed}}}
+	var out bytes.Buffer
+	if err := WriteBatchCSV(context.Background(), &out, b); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := records[1][1]; got != "'=cmd|' /C calc'!A0" {
+		t.Errorf("nom exporté = %q", got)
+	}
+	if got := records[1][2]; got != "'@SUM(1+1)" {
+		t.Errorf("description exportée = %q", got)
+	}
+}
+
+func TestCohortCSVEscapesFormulas(t *testing.T) {
+	d := &models.CohortDashboard{Teams: []models.TeamProgress{{
+		Team:       models.CohortTeam{Name: "=WEBSERVICE(\"http://x\")"},
+		Rank:       1,
+		Latest:     &models.ProgressPoint{PitchID: "p1", Description: "+ une app"},
+		Points:     []models.ProgressPoint{{Score: 7}, {Score: 5}},
+		Score:      5,
+		FirstScore: 7,
+	}}}
+	var out bytes.Buffer
+	if err := WriteCohortCSV(&out, d); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := records[1]
+	if row[1] != "'=WEBSERVICE(\"http://x\")" || row[3] != "'+ une app" {
+		t.Errorf("équipe et description exportées = %q, %q", row[1], row[3])
+	}
+	if row[9] != "-2" {
+		t.Errorf("progression = %q, une valeur numérique négative ne doit pas être préfixée", row[9])
+	}
+}
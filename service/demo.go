@@ -0,0 +1,181 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"pitch/models"
+)
+
+// demoTemplate décrit un pitch type pour un secteur donné.
+// Les champs Probleme et Solution sont des formats qui reçoivent la description de l'utilisateur.
+type demoTemplate struct {
+	Industrie string
+	MotsCles  []string
+	Probleme  string
+	Solution  string
+	Marche    string
+	Valeur    string
+	Canaux    string
+	Modele    string
+}
+
+// demoTemplates contient les modèles de pitch par secteur, le dernier sert de modèle générique
+var demoTemplates = []demoTemplate{
+	{
+		Industrie: "Alimentation",
+		MotsCles:  []string{"repas", "restaurant", "livraison", "food", "cuisine", "nourriture", "healthy", "traiteur"},
+		Probleme:  "Trouver une offre de repas de qualité, rapide et abordable reste difficile : « %s ». Les options existantes sont chères ou peu équilibrées.",
+		Solution:  "Une plateforme qui simplifie l'accès à des repas sains autour de « %s », avec commande en quelques clics et suivi en temps réel.",
+		Marche:    "Actifs urbains et étudiants de 18-35 ans, marché de la livraison de repas en croissance de plus de 10 % par an.",
+		Valeur:    "Des repas équilibrés livrés en moins de 30 minutes, à un prix inférieur aux concurrents grâce aux circuits courts.",
+		Canaux:    "Application mobile, partenariats avec les campus et entreprises, réseaux sociaux et programme de parrainage.",
+		Modele:    "Commission sur chaque commande + abonnement mensuel à livraison illimitée.",
+	},
+	{
+		Industrie: "Santé",
+		MotsCles:  []string{"santé", "sante", "médecin", "medecin", "patient", "hôpital", "hopital", "soin", "pharmacie", "bien-être"},
+		Probleme:  "L'accès aux soins reste lent et fragmenté : « %s ». Les patients perdent du temps et le suivi est discontinu.",
+		Solution:  "Un service numérique autour de « %s » qui connecte patients et professionnels et centralise le suivi.",
+		Marche:    "Patients, cliniques et professionnels de santé indépendants ; marché de la e-santé en forte croissance.",
+		Valeur:    "Réduction des délais de prise en charge et meilleure continuité des soins, dans le respect de la confidentialité.",
+		Canaux:    "Partenariats avec les cliniques et mutuelles, recommandations des professionnels, référencement local.",
+		Modele:    "Abonnement SaaS pour les professionnels + frais de service par consultation.",
+	},
+	{
+		Industrie: "Éducation",
+		MotsCles:  []string{"école", "ecole", "étudiant", "etudiant", "élève", "eleve", "formation", "cours", "apprentissage", "éducation", "education"},
+		Probleme:  "Les apprenants manquent d'accompagnement personnalisé : « %s ». Les contenus sont peu adaptés à leur niveau.",
+		Solution:  "Une plateforme d'apprentissage autour de « %s » avec parcours personnalisés et suivi des progrès.",
+		Marche:    "Élèves, étudiants et établissements scolaires ; marché de l'EdTech soutenu par la digitalisation de l'enseignement.",
+		Valeur:    "Un apprentissage adapté au rythme de chacun, mesurable et accessible sur mobile.",
+		Canaux:    "Partenariats avec les écoles, ambassadeurs étudiants, contenus gratuits sur les réseaux sociaux.",
+		Modele:    "Freemium pour les apprenants + licences pour les établissements.",
+	},
+	{
+		Industrie: "Finance",
+		MotsCles:  []string{"paiement", "banque", "crédit", "credit", "épargne", "epargne", "finance", "mobile money", "assurance", "transfert"},
+		Probleme:  "Les services financiers restent peu accessibles et coûteux : « %s ».",
+		Solution:  "Une solution financière simple autour de « %s », accessible depuis un téléphone et sans frais cachés.",
+		Marche:    "Particuliers et petites entreprises sous-bancarisés ; forte adoption du paiement mobile.",
+		Valeur:    "Des transactions rapides, sécurisées et transparentes à moindre coût.",
+		Canaux:    "Réseau d'agents locaux, partenariats avec les opérateurs télécoms, marketing digital.",
+		Modele:    "Frais par transaction + offres premium pour les professionnels.",
+	},
+	{
+		Industrie: "Agriculture",
+		MotsCles:  []string{"agricole", "agriculteur", "ferme", "récolte", "recolte", "culture", "élevage", "elevage", "agritech"},
+		Probleme:  "Les producteurs manquent d'outils et de débouchés fiables : « %s ».",
+		Solution:  "Une plateforme autour de « %s » qui relie producteurs, acheteurs et conseils agronomiques.",
+		Marche:    "Petits et moyens exploitants, coopératives et acheteurs agroalimentaires.",
+		Valeur:    "Meilleurs rendements et meilleurs prix de vente grâce à la suppression des intermédiaires.",
+		Canaux:    "Coopératives, SMS et applications mobiles, partenaires institutionnels.",
+		Modele:    "Commission sur les ventes + abonnement aux services de conseil.",
+	},
+	{
+		Industrie: "Mobilité",
+		MotsCles:  []string{"transport", "taxi", "voiture", "moto", "vélo", "velo", "trajet", "logistique", "mobilité", "mobilite"},
+		Probleme:  "Se déplacer ou transporter des marchandises reste coûteux et imprévisible : « %s ».",
+		Solution:  "Un service de mobilité autour de « %s » avec réservation instantanée et tarification transparente.",
+		Marche:    "Citadins, commerçants et entreprises ayant des besoins de transport réguliers.",
+		Valeur:    "Des trajets fiables, suivis en temps réel et moins chers que les alternatives.",
+		Canaux:    "Application mobile, partenariats avec les commerces, campagnes locales.",
+		Modele:    "Commission par course + abonnement pour les professionnels.",
+	},
+	{
+		Industrie: "E-commerce",
+		MotsCles:  []string{"boutique", "vente", "e-commerce", "ecommerce", "marketplace", "produit", "commerce", "mode"},
+		Probleme:  "Les commerçants peinent à vendre en ligne et les clients à trouver des produits fiables : « %s ».",
+		Solution:  "Une boutique en ligne autour de « %s » avec paiement sécurisé et livraison simplifiée.",
+		Marche:    "Consommateurs connectés et petits commerçants souhaitant se digitaliser.",
+		Valeur:    "Une expérience d'achat simple, des produits vérifiés et une livraison rapide.",
+		Canaux:    "Réseaux sociaux, influenceurs, référencement et marketplaces partenaires.",
+		Modele:    "Marge sur les ventes + commissions des vendeurs tiers.",
+	},
+	{
+		Industrie: "Général",
+		Probleme:  "Les utilisateurs rencontrent un problème concret : « %s », ce qui crée une friction dans leur quotidien.",
+		Solution:  "Nous proposons une solution simple et intuitive basée sur « %s », qui améliore l'expérience et la conversion.",
+		Marche:    "Jeunes actifs et étudiants urbains de 18-35 ans, utilisateurs mobiles cherchant la commodité.",
+		Valeur:    "Gain de temps, personnalisation et prix attractif.",
+		Canaux:    "Réseaux sociaux, partenariats locaux, campagnes ciblées.",
+		Modele:    "Freemium + abonnement premium + commissions.",
+	},
+}
+
+// DemoModeEnabled indique si les pitchs doivent être générés sans OpenAI.
+// DEMO_MODE=true force le mode démo, DEMO_MODE=false le désactive ;
+// sinon il est activé automatiquement quand aucune clé API n'est configurée.
+func DemoModeEnabled() bool {
+	if v := os.Getenv("DEMO_MODE"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			return enabled
+		}
+	}
+	return os.Getenv("OPENAI_API_KEY") == ""
+}
+
+// detectDemoTemplate choisit le modèle dont les mots-clés correspondent le mieux à la description
+// (mots entiers : « mode » ne doit pas reconnaître « modèle »)
+func detectDemoTemplate(input string) demoTemplate {
+	text := strings.ToLower(input)
+	best := demoTemplates[len(demoTemplates)-1]
+	bestScore := 0
+	for _, t := range demoTemplates {
+		score := 0
+		for _, mot := range t.MotsCles {
+			if containsWord(text, mot) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best = t
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// containsWord indique si mot apparaît dans text comme mot entier, éventuellement au pluriel (-s, -x)
+func containsWord(text, mot string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], mot)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(mot)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		rest := strings.TrimLeft(text[end:], "sx")
+		after, _ := utf8.DecodeRuneInString(rest)
+		if (start == 0 || !isWordRune(before)) && (rest == "" || !isWordRune(after)) && len(text[end:])-len(rest) <= 1 {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// GeneratePitchResponse retourne un PitchResponse de démonstration basé sur l'entrée.
+// Cette fonction est utilisée en mode démo (sans OpenAI).
+func GeneratePitchResponse(input string) *models.PitchResponse {
+	input = strings.TrimSpace(input)
+	t := detectDemoTemplate(input)
+
+	return &models.PitchResponse{
+		Probleme:  fmt.Sprintf(t.Probleme, input),
+		Solution:  fmt.Sprintf(t.Solution, input),
+		Marche:    t.Marche,
+		Valeur:    t.Valeur,
+		Canaux:    t.Canaux,
+		Modele:    t.Modele,
+		Demo:      true,
+		Industrie: t.Industrie,
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDemoModeEnabled(t *testing.T) {
+	tests := []struct {
+		demo, key string
+		want      bool
+	}{
+		{demo: "", key: "", want: true},
+		{demo: "", key: "sk-test", want: false},
+		{demo: "true", key: "sk-test", want: true},
+		{demo: "false", key: "", want: false},
+		{demo: "1", key: "sk-test", want: true},
+		{demo: "peut-être", key: "sk-test", want: false},
+		{demo: "peut-être", key: "", want: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("DEMO_MODE", tt.demo)
+		t.Setenv("OPENAI_API_KEY", tt.key)
+		if got := DemoModeEnabled(); got != tt.want {
+			t.Errorf("DemoModeEnabled(DEMO_MODE=%q, clé=%q) = %v, veut %v", tt.demo, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestDetectDemoTemplate(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{desc: "Livraison de repas sains pour les étudiants pressés", want: "Alimentation"},
+		{desc: "Prise de rendez-vous chez le médecin pour les patients", want: "Santé"},
+		{desc: "Des cours de soutien en ligne pour les élèves", want: "Éducation"},
+		{desc: "Paiement par mobile money pour les commerçants", want: "Finance"},
+		{desc: "Vendre les récoltes des agriculteurs en direct", want: "Agriculture"},
+		{desc: "Réservation de taxi-moto à Cotonou", want: "Mobilité"},
+		{desc: "Une boutique de mode éthique", want: "E-commerce"},
+		{desc: "Des produits locaux vendus sur une marketplace", want: "E-commerce"},
+		// « mode » ne doit pas être reconnu dans « modèle » ou « modeste »
+		{desc: "Un modèle d'abonnement modeste pour les artisans", want: "Général"},
+		{desc: "Une application qui aide à ranger son garage", want: "Général"},
+		{desc: "", want: "Général"},
+	}
+	for _, tt := range tests {
+		if got := detectDemoTemplate(tt.desc).Industrie; got != tt.want {
+			t.Errorf("detectDemoTemplate(%q) = %s, veut %s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratePitchResponse(t *testing.T) {
+	resp := GeneratePitchResponse("  Livraison de repas sains  ")
+	if resp.Probleme == "" || resp.Solution == "" || resp.Marche == "" || resp.Valeur == "" || resp.Canaux == "" || resp.Modele == "" {
+		t.Fatalf("section vide : %+v", resp)
+	}
+	// La description, débarrassée de ses espaces, est citée dans le problème et la solution
+	for _, section := range []string{resp.Probleme, resp.Solution} {
+		if !strings.Contains(section, "« Livraison de repas sains »") {
+			t.Errorf("%q ne cite pas la description", section)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// ErrInvalidCallbackURL est retournée quand l'URL de callback n'est pas une URL http(s) absolue
+var ErrInvalidCallbackURL = errors.New("URL de callback invalide (http ou https attendu)")
+
+// WebhookSignatureHeader porte la signature HMAC-SHA256 des webhooks : "t=<timestamp unix>,v1=<hex>".
+// La signature porte sur "<timestamp>.<corps>" avec le secret retourné à la création de la tâche.
+const WebhookSignatureHeader = "X-Pitch-Signature"
+
+var jobStore = store.New[models.Job]("jobs")
+
+// CreateJob enregistre une génération asynchrone et la lance en arrière-plan.
+// La description est validée immédiatement et une place est réservée dans la file de génération
+// (ErrQueueFull si elle est pleine) ; le job retourné contient le secret de signature du webhook.
+func CreateJob(ctx context.Context, desc string, noCache bool, callbackURL string) (*models.Job, error) {
+	desc = strings.TrimSpace(desc)
+	if err := ValidateDescription(desc); err != nil {
+		return nil, err
+	}
+
+	job := models.Job{
+		ID:          store.NewID(),
+		Status:      models.JobQueued,
+		Description: desc,
+		NoCache:     noCache,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if callbackURL != "" {
+		u, err := url.Parse(callbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, ErrInvalidCallbackURL
+		}
+		job.CallbackURL = callbackURL
+		job.WebhookSecret = "whsec_" + store.RandomToken(24)
+	}
+	if k := APIKeyFromContext(ctx); k != nil {
+		job.APIKeyID = k.ID
+	}
+	if u := UserFromContext(ctx); u != nil {
+		job.OwnerID = u.ID
+	}
+	reservation, err := generationQueue.Reserve()
+	if err != nil {
+		return nil, err
+	}
+	if err := jobStore.Put(job.ID, job); err != nil {
+		reservation.Cancel()
+		return nil, err
+	}
+
+	// La tâche survit à la requête HTTP mais garde l'identité de l'appelant (clé API, utilisateur)
+	go runJob(reservation.WithReservation(context.WithoutCancel(ctx)), job.ID, reservation)
+	return &job, nil
+}
+
+// GetJob retourne la tâche id si elle appartient à l'appelant ; le secret du webhook n'est pas renvoyé
+func GetJob(ctx context.Context, id string) (*models.Job, error) {
+	job, ok := jobStore.Get(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if job.APIKeyID != "" {
+		k := APIKeyFromContext(ctx)
+		if k == nil || k.ID != job.APIKeyID {
+			return nil, store.ErrNotFound
+		}
+	}
+	if job.OwnerID != "" {
+		u := UserFromContext(ctx)
+		if u == nil || u.ID != job.OwnerID {
+			return nil, store.ErrNotFound
+		}
+	}
+	job.WebhookSecret = ""
+	return &job, nil
+}
+
+// RecoverJobs marque en échec les tâches interrompues par un redémarrage du serveur
+func RecoverJobs() {
+	for _, job := range jobStore.Find(func(j models.Job) bool {
+		return j.Status == models.JobQueued || j.Status == models.JobRunning
+	}) {
+		jobStore.Update(job.ID, func(j *models.Job) error {
+			now := time.Now().UTC()
+			j.Status = models.JobFailed
+			j.Error = "tâche interrompue par un redémarrage du serveur"
+			j.FinishedAt = &now
+			return nil
+		})
+	}
+}
+
+// runJob exécute la génération puis notifie le webhook éventuel
+func runJob(ctx context.Context, id string, reservation *QueueReservation) {
+	setRunning := func() {
+		jobStore.Update(id, func(j *models.Job) error {
+			now := time.Now().UTC()
+			j.Status = models.JobRunning
+			j.QueuePosition = 0
+			j.StartedAt = &now
+			return nil
+		})
+	}
+	job, _ := jobStore.Get(id)
+	opts := GenerateOptions{
+		NoCache: job.NoCache,
+		// Appelée à chaque avancée dans la file : GET /api/v1/jobs/{id} expose la position courante
+		OnQueued: func(position int) {
+			jobStore.Update(id, func(j *models.Job) error {
+				if j.Status == models.JobQueued {
+					j.QueuePosition = position
+				}
+				return nil
+			})
+		},
+		OnStarted: setRunning,
+	}
+
+	p, err := CreatePitch(ctx, job.Description, opts)
+	// Place inutilisée si la génération n'a pas atteint la file (cache, mode démo, crédits épuisés...)
+	reservation.Cancel()
+	job, _ = jobStore.Update(id, func(j *models.Job) error {
+		now := time.Now().UTC()
+		if j.StartedAt == nil {
+			// Réponse servie sans appel au fournisseur (cache ou mode démo)
+			j.StartedAt = &now
+		}
+		j.FinishedAt = &now
+		j.QueuePosition = 0
+		if err != nil {
+			j.Status = models.JobFailed
+			j.Error = err.Error()
+			return nil
+		}
+		j.Status = models.JobSucceeded
+		j.PitchID = p.ID
+		j.Result = p
+		return nil
+	})
+
+	if job.CallbackURL != "" {
+		deliverWebhook(job)
+	}
+}
+
+// SignWebhook calcule la valeur de l'en-tête X-Pitch-Signature pour un corps et un instant donnés
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// deliverWebhook envoie la tâche terminée à son URL de callback, avec 3 tentatives espacées
+func deliverWebhook(job models.Job) {
+	secret := job.WebhookSecret
+	job.WebhookSecret = ""
+	body, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(1<<(2*(attempt-2))) * time.Second) // 1s puis 4s
+		}
+		if lastErr = postWebhook(job.CallbackURL, secret, body); lastErr == nil {
+			break
+		}
+	}
+
+	jobStore.Update(job.ID, func(j *models.Job) error {
+		if lastErr != nil {
+			j.WebhookError = lastErr.Error()
+			return nil
+		}
+		now := time.Now().UTC()
+		j.WebhookDelivered = &now
+		j.WebhookError = ""
+		return nil
+	})
+}
+
+// sharedAddressSpace est la plage 100.64.0.0/10 du NAT des opérateurs (RFC 6598), interne aux hébergeurs
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// internalIP indique si l'adresse ne doit pas recevoir de webhook : loopback, réseau privé, NAT opérateur,
+// link-local (dont les métadonnées cloud 169.254.169.254), multicast, broadcast ou non spécifiée
+func internalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() ||
+		ip.IsMulticast() || ip.Equal(net.IPv4bcast) || sharedAddressSpace.Contains(ip)
+}
+
+func postWebhook(callbackURL, secret string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pitch-ia-webhook/1")
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, time.Now().Unix(), body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("webhook: statut HTTP " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+// webhookClient refuse de se connecter aux adresses internes (voir internalIP),
+// sauf si WEBHOOK_ALLOW_PRIVATE=true (développement local). Aucun proxy n'est utilisé :
+// le contrôle à la connexion porterait sinon sur l'adresse du proxy et non sur celle du destinataire.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				if os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true" {
+					return nil
+				}
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || internalIP(ip) {
+					return errors.New("webhook: adresse interne refusée")
+				}
+				return nil
+			},
+		}).DialContext,
+	},
+	// Ne pas suivre les redirections : la cible doit être l'URL déclarée
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
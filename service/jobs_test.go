@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+func TestGetJobScope(t *testing.T) {
+	jobs := []models.Job{
+		{ID: "job-site", OwnerID: "ana-job", Status: models.JobQueued},
+		{ID: "job-api", APIKeyID: "k-job", Status: models.JobQueued, WebhookSecret: "whsec_x"},
+		{ID: "job-anonyme", Status: models.JobQueued},
+	}
+	for _, j := range jobs {
+		if err := jobStore.Put(j.ID, j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ana := WithUser(context.Background(), &models.User{ID: "ana-job"})
+	ben := WithUser(context.Background(), &models.User{ID: "ben-job"})
+	key := WithAPIKey(context.Background(), &models.APIKey{ID: "k-job"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		id   string
+		ok   bool
+	}{
+		{name: "auteur", ctx: ana, id: "job-site", ok: true},
+		{name: "autre utilisateur", ctx: ben, id: "job-site"},
+		{name: "visiteur", ctx: context.Background(), id: "job-site"},
+		{name: "clé API", ctx: key, id: "job-api", ok: true},
+		{name: "utilisateur sans la clé", ctx: ana, id: "job-api"},
+		{name: "tâche anonyme", ctx: ben, id: "job-anonyme", ok: true},
+		{name: "inconnue", ctx: ana, id: "absente"},
+	}
+	for _, tt := range tests {
+		job, err := GetJob(tt.ctx, tt.id)
+		if tt.ok && err != nil {
+			t.Errorf("%s : GetJob() = %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("%s : GetJob() = %v, veut ErrNotFound", tt.name, err)
+		}
+		if job != nil && job.WebhookSecret != "" {
+			t.Errorf("%s : le secret du webhook ne doit pas être renvoyé", tt.name)
+		}
+	}
+}
+
+func TestWebhookInternalIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "127.0.0.1", want: true},
+		{ip: "::1", want: true},
+		{ip: "10.1.2.3", want: true},
+		{ip: "172.16.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "fd00::1", want: true},
+		{ip: "169.254.169.254", want: true},
+		{ip: "fe80::1", want: true},
+		{ip: "100.64.0.1", want: true},
+		{ip: "100.127.255.254", want: true},
+		{ip: "224.0.0.1", want: true},
+		{ip: "239.255.255.250", want: true},
+		{ip: "ff02::1", want: true},
+		{ip: "255.255.255.255", want: true},
+		{ip: "0.0.0.0", want: true},
+		{ip: "::ffff:127.0.0.1", want: true},
+		{ip: "100.128.0.1", want: false},
+		{ip: "93.184.216.34", want: false},
+		{ip: "2606:2800:220:1::1", want: false},
+	}
+	for _, tt := range tests {
+		if got := internalIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("internalIP(%s) = %v, veut %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookIgnoresProxy(t *testing.T) {
+	// Avec un proxy, le contrôle des adresses porterait sur le proxy et non sur le destinataire
+	t.Setenv("HTTPS_PROXY", "http://proxy.example:3128")
+	t.Setenv("HTTP_PROXY", "http://proxy.example:3128")
+	if tr, ok := webhookClient.Transport.(*http.Transport); !ok || tr.Proxy != nil {
+		t.Fatal("le client des webhooks ne doit passer par aucun proxy")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("webhook livré à une adresse interne")
+	}))
+	defer srv.Close()
+	if err := postWebhook(srv.URL, "whsec_test", []byte("{}")); err == nil {
+		t.Error("postWebhook(loopback) doit échouer")
+	}
+}
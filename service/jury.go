@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pitch/models"
+	"pitch/render"
+	"pitch/store"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Erreurs du jury simulé
+var (
+	ErrUnknownJudge  = errors.New("juré inconnu")
+	ErrTooManyJudges = errors.New("trop de jurés pour un même passage")
+	ErrJudgeFailed   = errors.New("réponse du juré inexploitable")
+)
+
+// MaxJudges est le nombre maximal de jurés par passage
+const MaxJudges = 8
+
+var juryStore = store.New[models.JuryRun]("jury_runs")
+
+// defaultRubric est la grille d'évaluation du concours, commune à tous les jurés
+var defaultRubric = []models.RubricCriterion{
+	{Key: "probleme", Label: "Problème", Description: "Le problème est-il réel, précis et douloureux pour une cible identifiée ?", Weight: 1},
+	{Key: "solution", Label: "Solution", Description: "La solution répond-elle au problème de façon crédible et réalisable ?", Weight: 1},
+	{Key: "marche", Label: "Marché", Description: "Le marché est-il assez grand et bien compris, l'opportunité est-elle démontrée ?", Weight: 1},
+	{Key: "differenciation", Label: "Différenciation", Description: "La proposition de valeur se distingue-t-elle nettement des alternatives existantes ?", Weight: 1},
+	{Key: "acquisition", Label: "Acquisition", Description: "Les canaux pour atteindre et convaincre les clients sont-ils réalistes ?", Weight: 1},
+	{Key: "modele", Label: "Modèle économique", Description: "Le modèle de revenus est-il cohérent et peut-il devenir rentable ?", Weight: 1},
+}
+
+// defaultJudges sont les jurés proposés quand JURY_CONFIG ne les redéfinit pas
+var defaultJudges = []models.JudgePersona{
+	{ID: "vc", Name: "Investisseuse en capital-risque", Persona: "Tu es associée dans un fonds d'amorçage. Tu cherches des marchés très larges, une croissance rapide et un potentiel de sortie ; tu es sévère sur la taille de marché et le modèle économique."},
+	{ID: "angel", Name: "Business angel ancien entrepreneur", Persona: "Tu as créé et revendu deux entreprises. Tu juges avant tout la faisabilité, la simplicité de l'exécution et la capacité à trouver les premiers clients."},
+	{ID: "corporate", Name: "Directeur innovation d'un grand groupe", Persona: "Tu diriges l'innovation d'un groupe industriel. Tu évalues la différenciation, les partenariats possibles et la capacité à vendre à de grands comptes."},
+	{ID: "impact", Name: "Chargée d'affaires d'un financeur public", Persona: "Tu finances des projets à impact pour une banque publique. Tu regardes l'utilité sociale et environnementale, la création d'emplois et la solidité du projet."},
+	{ID: "client", Name: "Client cible", Persona: "Tu fais partie de la clientèle visée par le projet. Tu juges si le problème te parle, si tu paierais pour la solution et si l'offre est claire."},
+}
+
+// juryConfig est le contenu du fichier JURY_CONFIG ; une partie absente reprend la configuration par défaut
+type juryConfig struct {
+	Rubric []models.RubricCriterion `json:"rubric"`
+	Judges []models.JudgePersona    `json:"judges"`
+}
+
+// juryCfg est la configuration du jury en vigueur, chargée au démarrage par ConfigureJury
+var juryCfg = juryConfig{Rubric: defaultRubric, Judges: defaultJudges}
+
+// ConfigureJury charge la grille et les jurés de JURY_CONFIG ; à appeler une fois au démarrage
+func ConfigureJury() {
+	juryCfg = loadJuryConfig()
+}
+
+// loadJuryConfig lit la grille et les jurés depuis le fichier JSON JURY_CONFIG s'il est défini.
+// Un fichier illisible ou invalide est signalé dans les logs et la configuration par défaut est utilisée.
+func loadJuryConfig() juryConfig {
+	cfg := juryConfig{Rubric: defaultRubric, Judges: defaultJudges}
+	path := os.Getenv("JURY_CONFIG")
+	if path == "" {
+		return cfg
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("jury: configuration %s illisible: %v", path, err)
+		return cfg
+	}
+	var custom juryConfig
+	if err := json.Unmarshal(data, &custom); err != nil {
+		log.Printf("jury: configuration %s invalide: %v", path, err)
+		return cfg
+	}
+	if err := custom.validate(); err != nil {
+		log.Printf("jury: configuration %s invalide: %v", path, err)
+		return cfg
+	}
+	if len(custom.Rubric) > 0 {
+		cfg.Rubric = custom.Rubric
+	}
+	if len(custom.Judges) > 0 {
+		cfg.Judges = custom.Judges
+	}
+	return cfg
+}
+
+// validate vérifie les identifiants des critères et des jurés et donne un poids de 1 aux critères qui n'en ont pas
+func (c *juryConfig) validate() error {
+	keys := map[string]bool{}
+	for i := range c.Rubric {
+		cr := &c.Rubric[i]
+		if cr.Key == "" || cr.Label == "" || keys[cr.Key] {
+			return fmt.Errorf("critère %d : clé et libellé obligatoires et uniques", i+1)
+		}
+		if cr.Weight < 0 {
+			return fmt.Errorf("critère %q : poids négatif", cr.Key)
+		}
+		if cr.Weight == 0 {
+			cr.Weight = 1
+		}
+		keys[cr.Key] = true
+	}
+	ids := map[string]bool{}
+	for i, j := range c.Judges {
+		if j.ID == "" || j.Name == "" || j.Persona == "" || ids[j.ID] {
+			return fmt.Errorf("juré %d : identifiant unique, nom et profil obligatoires", i+1)
+		}
+		ids[j.ID] = true
+	}
+	return nil
+}
+
+// CompetitionRubric retourne la grille d'évaluation du concours
+func CompetitionRubric() []models.RubricCriterion {
+	return juryCfg.Rubric
+}
+
+// JudgePanel retourne les jurés disponibles
+func JudgePanel() []models.JudgePersona {
+	return juryCfg.Judges
+}
+
+// selectJudges retourne les jurés demandés, dans l'ordre du panel ; aucun identifiant = tout le panel
+func selectJudges(panel []models.JudgePersona, ids []string) ([]models.JudgePersona, error) {
+	if len(ids) == 0 {
+		if len(panel) > MaxJudges {
+			return panel[:MaxJudges], nil
+		}
+		return panel, nil
+	}
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var judges []models.JudgePersona
+	for _, j := range panel {
+		if wanted[j.ID] {
+			judges = append(judges, j)
+			delete(wanted, j.ID)
+		}
+	}
+	if len(wanted) > 0 {
+		return nil, ErrUnknownJudge
+	}
+	if len(judges) > MaxJudges {
+		return nil, ErrTooManyJudges
+	}
+	return judges, nil
+}
+
+// CreateJuryRun fait passer le pitch devant les jurés choisis (tout le panel si judgeIDs est vide).
+// Réservé aux auteurs et aux mentors du pitch ; chaque juré coûte CreditCostJudge crédits, remboursés s'il échoue.
+// Les jurés délibèrent en parallèle, en arrière-plan.
+func CreateJuryRun(ctx context.Context, pitchID string, judgeIDs []string) (*models.JuryRun, error) {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return nil, ErrAccountRequired
+	}
+	p, err := GetPitch(ctx, pitchID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := PitchActor(ctx, p); !ok {
+		return nil, ErrOrgForbidden
+	}
+	cfg := juryCfg
+	judges, err := selectJudges(cfg.Judges, judgeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	run := models.JuryRun{
+		ID:        store.NewID(),
+		PitchID:   p.ID,
+		OwnerID:   u.ID,
+		Status:    models.JobQueued,
+		Rubric:    cfg.Rubric,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, j := range judges {
+		run.Sheets = append(run.Sheets, models.ScoreSheet{JudgeID: j.ID, JudgeName: j.Name, Status: models.JobQueued})
+	}
+
+	refunds := make([]func(), len(judges))
+	for i, j := range judges {
+		refund := func() {}
+		if !DemoModeEnabled() {
+			if refund, err = SpendCredits(ctx, CreditCostJudge, "Jury simulé : "+j.Name); err != nil {
+				for _, r := range refunds[:i] {
+					r()
+				}
+				return nil, err
+			}
+		}
+		refunds[i] = refund
+	}
+	if err := juryStore.Put(run.ID, run); err != nil {
+		for _, r := range refunds {
+			r()
+		}
+		return nil, err
+	}
+
+	go runJury(context.WithoutCancel(ctx), run.ID, *p, judges, refunds)
+	return &run, nil
+}
+
+// runJury fait délibérer les jurés en parallèle ; le nombre d'appels simultanés au fournisseur reste
+// borné par la file d'attente de génération
+func runJury(ctx context.Context, id string, p models.Pitch, judges []models.JudgePersona, refunds []func()) {
+	run, _ := juryStore.Update(id, func(r *models.JuryRun) error {
+		r.Status = models.JobRunning
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i, judge := range judges {
+		wg.Add(1)
+		go func(i int, judge models.JudgePersona) {
+			defer wg.Done()
+			sheet := judgePitch(ctx, id, i, judge, run.Rubric, &p)
+			if sheet.Status == models.JobFailed {
+				refunds[i]()
+			}
+			juryStore.Update(id, func(r *models.JuryRun) error {
+				r.Sheets[i] = sheet
+				return nil
+			})
+		}(i, judge)
+	}
+	wg.Wait()
+
+	juryStore.Update(id, func(r *models.JuryRun) error {
+		now := time.Now().UTC()
+		r.Status = models.JobFailed
+		for _, s := range r.Sheets {
+			if s.Status == models.JobSucceeded {
+				r.Status = models.JobSucceeded
+			}
+		}
+		r.FinishedAt = &now
+		return nil
+	})
+}
+
+// judgePitch obtient la feuille de notes d'un juré
+func judgePitch(ctx context.Context, runID string, i int, judge models.JudgePersona, rubric []models.RubricCriterion, p *models.Pitch) models.ScoreSheet {
+	sheet := models.ScoreSheet{JudgeID: judge.ID, JudgeName: judge.Name}
+	fail := func(err error) models.ScoreSheet {
+		sheet.Status = models.JobFailed
+		sheet.Error = err.Error()
+		return sheet
+	}
+
+	if DemoModeEnabled() {
+		sheet.Scores, sheet.Verdict = demoScoreSheet(judge, rubric, p)
+		sheet.Demo = true
+	} else {
+		if err := CheckBudget(ctx); err != nil {
+			return fail(err)
+		}
+		release, err := generationQueue.Acquire(ctx, generationPriority(ctx), nil)
+		if err != nil {
+			if !errors.Is(err, ErrQueueFull) {
+				err = ErrGenerationFailed
+			}
+			return fail(err)
+		}
+		juryStore.Update(runID, func(r *models.JuryRun) error {
+			r.Sheets[i].Status = models.JobRunning
+			return nil
+		})
+		sheet.Scores, sheet.Verdict, err = judgeWithFailover(ctx, judge, rubric, p)
+		release()
+		if err != nil {
+			return fail(err)
+		}
+	}
+	sheet.Status = models.JobSucceeded
+	sheet.Total = weightedTotal(rubric, sheet.Scores)
+	return sheet
+}
+
+// judgeWithFailover fait noter le pitch par le juré avec le premier fournisseur disponible ;
+// une feuille inexploitable est réessayée mais ne compte pas comme une panne du fournisseur
+func judgeWithFailover(ctx context.Context, judge models.JudgePersona, rubric []models.RubricCriterion, p *models.Pitch) ([]models.CriterionScore, string, error) {
+	var scores []models.CriterionScore
+	var verdict string
+	err := withFailover(ctx, Providers(), func(provider Provider, b *CircuitBreaker) (TokenUsage, error) {
+		_, usage, err := completeWithRetry(ctx, provider, b, judgeRequest(judge, rubric, p), func(content string) bool {
+			var err error
+			scores, verdict, err = parseScoreSheet(content, rubric)
+			return err == nil
+		})
+		return usage, err
+	})
+	if errors.Is(err, ErrGenerationFailed) {
+		return nil, "", ErrJudgeFailed
+	}
+	return scores, verdict, err
+}
+
+// judgeRequest construit la demande d'évaluation du pitch par le juré : son profil, la grille et le pitch
+func judgeRequest(judge models.JudgePersona, rubric []models.RubricCriterion, p *models.Pitch) openai.ChatCompletionRequest {
+	var grid, example strings.Builder
+	for i, c := range rubric {
+		fmt.Fprintf(&grid, "- %s (%s) : %s\n", c.Key, c.Label, c.Description)
+		if i > 0 {
+			example.WriteString(", ")
+		}
+		fmt.Fprintf(&example, `"%s": {"note": 6, "commentaire": "..."}`, c.Key)
+	}
+	var pitch strings.Builder
+	render.Text(&pitch, p)
+
+	system := judge.Persona + "\n\nTu es membre du jury d'un concours de pitchs. Tu notes chaque critère de la grille de 1 (très insuffisant) à 10 (exceptionnel), selon ton propre point de vue, et tu justifies chaque note en une phrase. Tu réponds UNIQUEMENT avec un objet JSON, sans texte avant ou après."
+	prompt := fmt.Sprintf("Grille d'évaluation :\n%s\nPitch à évaluer :\n\n%s\nRéponds avec ce JSON : {\"notes\": {%s}, \"verdict\": \"ton avis global en deux phrases\"}", grid.String(), pitch.String(), example.String())
+
+	return openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: system},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.8, // chaque juré garde sa personnalité
+		MaxTokens:   1000,
+	}
+}
+
+// parseScoreSheet extrait les notes de la réponse d'un juré ; chaque critère de la grille doit être noté de 1 à 10
+func parseScoreSheet(content string, rubric []models.RubricCriterion) ([]models.CriterionScore, string, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, "", errors.New("réponse du juré sans JSON")
+	}
+	var raw struct {
+		Notes map[string]struct {
+			Note        json.Number `json:"note"`
+			Commentaire string      `json:"commentaire"`
+		} `json:"notes"`
+		Verdict string `json:"verdict"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &raw); err != nil {
+		return nil, "", err
+	}
+	scores := make([]models.CriterionScore, 0, len(rubric))
+	for _, c := range rubric {
+		n, ok := raw.Notes[c.Key]
+		if !ok {
+			return nil, "", fmt.Errorf("critère %q non noté", c.Key)
+		}
+		f, err := n.Note.Float64()
+		if err != nil || f < models.MinReviewScore || f > models.MaxReviewScore {
+			return nil, "", fmt.Errorf("note invalide pour %q", c.Key)
+		}
+		scores = append(scores, models.CriterionScore{Key: c.Key, Score: int(math.Round(f)), Comment: strings.TrimSpace(n.Commentaire)})
+	}
+	return scores, strings.TrimSpace(raw.Verdict), nil
+}
+
+// demoScoreSheet simule la feuille d'un juré sans IA : des notes stables de 4 à 9, propres au juré et au pitch
+func demoScoreSheet(judge models.JudgePersona, rubric []models.RubricCriterion, p *models.Pitch) ([]models.CriterionScore, string) {
+	scores := make([]models.CriterionScore, 0, len(rubric))
+	for _, c := range rubric {
+		h := fnv.New32a()
+		h.Write([]byte(judge.ID + "/" + c.Key + "/" + p.ID))
+		scores = append(scores, models.CriterionScore{Key: c.Key, Score: 4 + int(h.Sum32()%6), Comment: "Note simulée (mode démo)."})
+	}
+	return scores, "Évaluation simulée en mode démo : configurez OPENAI_API_KEY pour une délibération réelle."
+}
+
+// weightedTotal retourne la moyenne des notes pondérée par la grille
+func weightedTotal(rubric []models.RubricCriterion, scores []models.CriterionScore) float64 {
+	var sum, weights float64
+	for _, c := range rubric {
+		for _, s := range scores {
+			if s.Key == c.Key {
+				sum += float64(s.Score) * c.Weight
+				weights += c.Weight
+			}
+		}
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Round(sum/weights*10) / 10
+}
+
+// GetJuryRun retourne le passage id si son pitch est accessible à l'utilisateur connecté
+func GetJuryRun(ctx context.Context, id string) (*models.JuryRun, error) {
+	run, ok := juryStore.Get(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if _, err := GetPitch(ctx, run.PitchID); err != nil {
+		return nil, store.ErrNotFound
+	}
+	return &run, nil
+}
+
+// ListJuryRuns retourne les passages du pitch devant le jury, du plus récent au plus ancien
+func ListJuryRuns(ctx context.Context, pitchID string) ([]models.JuryRun, error) {
+	if _, err := GetPitch(ctx, pitchID); err != nil {
+		return nil, err
+	}
+	runs := juryStore.Find(func(r models.JuryRun) bool { return r.PitchID == pitchID })
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	return runs, nil
+}
+
+// JuryDisagreementThreshold est l'écart de notes entre jurés signalé comme un désaccord (JURY_DISAGREEMENT, 3 par défaut)
+func JuryDisagreementThreshold() int {
+	if t := envInt("JURY_DISAGREEMENT", 3); t > 0 {
+		return t
+	}
+	return 3
+}
+
+// BuildJuryReport agrège les feuilles rendues : jurés classés du plus au moins favorable, critères classés
+// des points forts aux points faibles, désaccords entre jurés mis en évidence
+func BuildJuryReport(run *models.JuryRun) *models.JuryReport {
+	report := &models.JuryReport{Run: *run, Threshold: JuryDisagreementThreshold()}
+	var sheets []models.ScoreSheet
+	for _, s := range run.Sheets {
+		switch s.Status {
+		case models.JobSucceeded:
+			sheets = append(sheets, s)
+		case models.JobFailed:
+			report.Failed = append(report.Failed, s)
+		}
+	}
+	report.Completed = len(sheets)
+	if len(sheets) == 0 {
+		return report
+	}
+
+	var sum float64
+	report.Min, report.Max = sheets[0].Total, sheets[0].Total
+	for _, s := range sheets {
+		sum += s.Total
+		report.Min = math.Min(report.Min, s.Total)
+		report.Max = math.Max(report.Max, s.Total)
+	}
+	report.Average = math.Round(sum/float64(len(sheets))*10) / 10
+
+	sort.SliceStable(sheets, func(i, j int) bool { return sheets[i].Total > sheets[j].Total })
+	for i, s := range sheets {
+		jr := models.JudgeResult{Rank: i + 1, Sheet: s, Deviation: math.Round((s.Total-report.Average)*10) / 10}
+		if i > 0 && s.Total == sheets[i-1].Total {
+			jr.Rank = report.Judges[i-1].Rank
+		}
+		// Un juré à plus de la moitié du seuil de la moyenne du jury se démarque nettement des autres
+		jr.Outlier = len(sheets) > 2 && math.Abs(jr.Deviation) >= float64(report.Threshold)/2
+		report.Judges = append(report.Judges, jr)
+	}
+
+	for _, c := range run.Rubric {
+		cr := models.CriterionResult{Criterion: c, Min: models.MaxReviewScore, Max: models.MinReviewScore}
+		var total, n int
+		for _, s := range sheets {
+			score := s.Score(c.Key)
+			if score == 0 {
+				continue
+			}
+			total += score
+			n++
+			cr.Min, cr.Max = min(cr.Min, score), max(cr.Max, score)
+		}
+		if n == 0 {
+			continue
+		}
+		cr.Average = math.Round(float64(total)/float64(n)*10) / 10
+		cr.Disagreement = n > 1 && cr.Spread() >= report.Threshold
+		if cr.Disagreement {
+			report.Disagreements++
+		}
+		report.Criteria = append(report.Criteria, cr)
+	}
+	sort.SliceStable(report.Criteria, func(i, j int) bool { return report.Criteria[i].Average > report.Criteria[j].Average })
+	return report
+}
+
+// RecoverJuryRuns termine les passages interrompus par un redémarrage du serveur ; les jurés sans feuille passent en erreur
+func RecoverJuryRuns() {
+	for _, run := range juryStore.Find(func(r models.JuryRun) bool {
+		return r.Status == models.JobQueued || r.Status == models.JobRunning
+	}) {
+		juryStore.Update(run.ID, func(r *models.JuryRun) error {
+			r.Status = models.JobFailed
+			for i := range r.Sheets {
+				s := &r.Sheets[i]
+				switch s.Status {
+				case models.JobQueued, models.JobRunning:
+					s.Status = models.JobFailed
+					s.Error = "délibération interrompue par un redémarrage du serveur"
+				case models.JobSucceeded:
+					r.Status = models.JobSucceeded
+				}
+			}
+			now := time.Now().UTC()
+			r.FinishedAt = &now
+			return nil
+		})
+	}
+}
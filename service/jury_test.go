@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"pitch/models"
+)
+
+func TestLoadJuryConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name       string
+		path       string
+		rubricKeys []string
+		judges     int
+	}{
+		{name: "sans fichier", rubricKeys: criterionKeys(defaultRubric), judges: len(defaultJudges)},
+		{name: "fichier absent", path: filepath.Join(dir, "absent.json"), rubricKeys: criterionKeys(defaultRubric), judges: len(defaultJudges)},
+		{name: "JSON invalide", path: write("invalide.json", "{"), rubricKeys: criterionKeys(defaultRubric), judges: len(defaultJudges)},
+		{name: "critères en double", path: write("double.json", `{"rubric":[{"key":"a","label":"A"},{"key":"a","label":"B"}]}`), rubricKeys: criterionKeys(defaultRubric), judges: len(defaultJudges)},
+		{name: "grille seule", path: write("grille.json", `{"rubric":[{"key":"impact","label":"Impact"}]}`), rubricKeys: []string{"impact"}, judges: len(defaultJudges)},
+		{name: "jurés seuls", path: write("jures.json", `{"judges":[{"id":"x","name":"X","persona":"Tu es X."}]}`), rubricKeys: criterionKeys(defaultRubric), judges: 1},
+	}
+	for _, tt := range tests {
+		t.Setenv("JURY_CONFIG", tt.path)
+		cfg := loadJuryConfig()
+		if got := criterionKeys(cfg.Rubric); len(got) != len(tt.rubricKeys) || got[0] != tt.rubricKeys[0] {
+			t.Errorf("%s : critères %v, veut %v", tt.name, got, tt.rubricKeys)
+		}
+		if len(cfg.Judges) != tt.judges {
+			t.Errorf("%s : %d juré(s), veut %d", tt.name, len(cfg.Judges), tt.judges)
+		}
+	}
+	t.Setenv("JURY_CONFIG", tests[4].path)
+	if cfg := loadJuryConfig(); cfg.Rubric[0].Weight != 1 {
+		t.Errorf("un critère sans poids doit valoir 1, obtenu %v", cfg.Rubric[0].Weight)
+	}
+}
+
+// criterionKeys retourne les clés des critères de la grille
+func criterionKeys(rubric []models.RubricCriterion) []string {
+	var out []string
+	for _, c := range rubric {
+		out = append(out, c.Key)
+	}
+	return out
+}
+
+func TestJudgeRejectionDoesNotTripBreaker(t *testing.T) {
+	fakeProvider(t, http.StatusOK, `{"notes": {}, "verdict": "incomplet"}`)
+	judge := defaultJudges[0]
+	p := &models.Pitch{Description: "projet", Sections: &models.PitchResponse{}}
+
+	_, _, err := judgeWithFailover(context.Background(), judge, defaultRubric, p)
+	if !errors.Is(err, ErrJudgeFailed) {
+		t.Fatalf("judgeWithFailover() = %v, veut ErrJudgeFailed", err)
+	}
+	if _, failures, _ := breakerFor(Providers()[0]).State(); failures != 0 {
+		t.Errorf("une feuille incomplète ne doit pas compter comme une panne, %d échec(s)", failures)
+	}
+}
+
+func TestParseScoreSheet(t *testing.T) {
+	rubric := []models.RubricCriterion{{Key: "marche"}, {Key: "equipe"}}
+	tests := []struct {
+		name        string
+		content     string
+		want        []models.CriterionScore
+		wantVerdict string
+		wantErr     bool
+	}{
+		{
+			name:        "JSON entouré de texte",
+			content:     "Voici ma feuille :\n```json\n{\"notes\": {\"marche\": {\"note\": 7, \"commentaire\": \" Large \"}, \"equipe\": {\"note\": 4.6}}, \"verdict\": \" Prometteur \"}\n```",
+			want:        []models.CriterionScore{{Key: "marche", Score: 7, Comment: "Large"}, {Key: "equipe", Score: 5}},
+			wantVerdict: "Prometteur",
+		},
+		{
+			name:    "critère ignoré en trop",
+			content: `{"notes": {"marche": {"note": 1}, "equipe": {"note": 10}, "prix": {"note": 3}}}`,
+			want:    []models.CriterionScore{{Key: "marche", Score: 1}, {Key: "equipe", Score: 10}},
+		},
+		{name: "sans JSON", content: "Je ne peux pas noter ce pitch.", wantErr: true},
+		{name: "JSON invalide", content: `{"notes": {`, wantErr: true},
+		{name: "critère manquant", content: `{"notes": {"marche": {"note": 7}}}`, wantErr: true},
+		{name: "note trop basse", content: `{"notes": {"marche": {"note": 0}, "equipe": {"note": 5}}}`, wantErr: true},
+		{name: "note trop haute", content: `{"notes": {"marche": {"note": 11}, "equipe": {"note": 5}}}`, wantErr: true},
+		{name: "note absente", content: `{"notes": {"marche": {}, "equipe": {"note": 5}}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, verdict, err := parseScoreSheet(tt.content, rubric)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s : parseScoreSheet() erreur = %v, veut une erreur : %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || verdict != tt.wantVerdict {
+			t.Errorf("%s : parseScoreSheet() = %+v, %q ; veut %+v, %q", tt.name, got, verdict, tt.want, tt.wantVerdict)
+		}
+	}
+}
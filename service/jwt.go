@@ -0,0 +1,136 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// JWK est une clé publique au format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet est un jeu de clés publiques (jwks_uri)
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwtHeader est l'en-tête d'un JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// publicKey convertit la JWK en clé publique RSA ou ECDSA P-256
+func (k JWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("courbe %s non supportée", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("type de clé %s non supporté", k.Kty)
+}
+
+// VerifyJWT vérifie la signature d'un JWT (RS256 ou ES256) avec les clés fournies
+// et décode ses claims dans claims. Les claims (iss, aud, exp...) restent à vérifier par l'appelant.
+func VerifyJWT(token string, keys JWKSet, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("jwt: format invalide")
+	}
+
+	rawHeader, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return errors.New("jwt: en-tête invalide")
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return errors.New("jwt: en-tête invalide")
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("jwt: signature invalide")
+	}
+
+	var key *JWK
+	for i := range keys.Keys {
+		if header.Kid == "" || keys.Keys[i].Kid == header.Kid {
+			key = &keys.Keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("jwt: clé %q inconnue", header.Kid)
+	}
+	pub, err := key.publicKey()
+	if err != nil {
+		return fmt.Errorf("jwt: %w", err)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch header.Alg {
+	case "RS256":
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], sig) != nil {
+			return errors.New("jwt: signature invalide")
+		}
+	case "ES256":
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return errors.New("jwt: signature invalide")
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("jwt: signature invalide")
+		}
+	default:
+		// "none" et les algorithmes HMAC sont refusés volontairement
+		return fmt.Errorf("jwt: algorithme %q non supporté", header.Alg)
+	}
+
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return errors.New("jwt: contenu invalide")
+	}
+	return json.Unmarshal(payload, claims)
+}
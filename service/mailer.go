@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoBaseURL est retourné quand un lien destiné à être envoyé ou partagé doit être construit sans BASE_URL
+var ErrNoBaseURL = errors.New("BASE_URL n'est pas configurée")
+
+// PublicBaseURL retourne l'URL publique du service (BASE_URL). Les liens envoyés par email ou
+// partagés n'en sont jamais déduits de la requête : l'en-tête Host est choisi par le client.
+func PublicBaseURL() (string, error) {
+	u := strings.TrimRight(os.Getenv("BASE_URL"), "/")
+	if u == "" {
+		return "", ErrNoBaseURL
+	}
+	return u, nil
+}
+
+// Mail est un email à envoyer
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envoie des emails ; l'implémentation est choisie par la variable MAILER
+type Mailer interface {
+	Send(m Mail) error
+}
+
+// LogMailer écrit les emails dans les logs du serveur (MAILER=log, par défaut)
+type LogMailer struct{}
+
+// Send journalise l'email
+func (LogMailer) Send(m Mail) error {
+	log.Printf("mail: à=%s sujet=%q\n%s", m.To, m.Subject, m.Body)
+	return nil
+}
+
+// FileMailer écrit chaque email dans un fichier .eml de Dir (MAILER=file, MAILER_DIR)
+type FileMailer struct {
+	Dir string
+}
+
+// Send écrit l'email sur le disque
+func (f FileMailer) Send(m Mail) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitizeFileName(m.To))
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		m.To, m.Subject, time.Now().UTC().Format(time.RFC1123Z), m.Body)
+	return os.WriteFile(filepath.Join(f.Dir, name), []byte(content), 0o600)
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// DefaultMailer retourne le mailer configuré par MAILER (log ou file)
+func DefaultMailer() Mailer {
+	if os.Getenv("MAILER") == "file" {
+		dir := os.Getenv("MAILER_DIR")
+		if dir == "" {
+			dir = "mails"
+		}
+		return FileMailer{Dir: dir}
+	}
+	return LogMailer{}
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// OIDCConfig est la configuration du fournisseur d'identité, lue depuis l'environnement
+type OIDCConfig struct {
+	Issuer       string // OIDC_ISSUER
+	ClientID     string // OIDC_CLIENT_ID
+	ClientSecret string // OIDC_CLIENT_SECRET (optionnel pour un client public avec PKCE)
+	RedirectURL  string // OIDC_REDIRECT_URL (par défaut <BASE_URL>/auth/oidc/callback)
+	Scopes       string // OIDC_SCOPES (par défaut "openid email profile")
+	ProviderName string // OIDC_PROVIDER_NAME, libellé du bouton de connexion
+}
+
+// LoadOIDCConfig lit la configuration OIDC ; ok vaut false si le SSO n'est pas configuré
+func LoadOIDCConfig() (OIDCConfig, bool) {
+	cfg := OIDCConfig{
+		Issuer:       strings.TrimRight(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       os.Getenv("OIDC_SCOPES"),
+		ProviderName: os.Getenv("OIDC_PROVIDER_NAME"),
+	}
+	if cfg.Scopes == "" {
+		cfg.Scopes = "openid email profile"
+	}
+	if cfg.ProviderName == "" {
+		cfg.ProviderName = "SSO"
+	}
+	return cfg, cfg.Issuer != "" && cfg.ClientID != ""
+}
+
+// oidcDiscovery est le sous-ensemble utilisé du document /.well-known/openid-configuration
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDTokenClaims sont les claims de l'ID token utilisés pour identifier l'utilisateur
+type IDTokenClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expiry        int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// audience accepte un claim aud sous forme de chaîne ou de tableau
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// oidcLogin est une tentative de connexion en cours, indexée par son paramètre state
+type oidcLogin struct {
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+var (
+	oidcMu        sync.Mutex
+	oidcLogins    = map[string]oidcLogin{}
+	oidcProviders = map[string]oidcDiscovery{}
+	oidcHTTP      = &http.Client{Timeout: 10 * time.Second}
+)
+
+// oidcGetJSON récupère un document JSON du fournisseur
+func oidcGetJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := oidcHTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s a répondu %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// discover charge (et met en cache) le document de découverte de l'émetteur
+func discover(ctx context.Context, issuer string) (oidcDiscovery, error) {
+	oidcMu.Lock()
+	d, ok := oidcProviders[issuer]
+	oidcMu.Unlock()
+	if ok {
+		return d, nil
+	}
+
+	if err := oidcGetJSON(ctx, issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return d, err
+	}
+	if strings.TrimRight(d.Issuer, "/") != issuer {
+		return d, fmt.Errorf("oidc: émetteur %q différent de celui configuré", d.Issuer)
+	}
+
+	oidcMu.Lock()
+	oidcProviders[issuer] = d
+	oidcMu.Unlock()
+	return d, nil
+}
+
+// pkceChallenge calcule le code_challenge S256 du verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return b64.EncodeToString(sum[:])
+}
+
+// OIDCAuthURL prépare une connexion et retourne l'URL du fournisseur ainsi que le state à lier au navigateur
+func OIDCAuthURL(ctx context.Context, cfg OIDCConfig) (string, string, error) {
+	d, err := discover(ctx, cfg.Issuer)
+	if err != nil {
+		return "", "", err
+	}
+
+	state := store.RandomToken(16)
+	login := oidcLogin{
+		Nonce:        store.RandomToken(16),
+		CodeVerifier: b64.EncodeToString([]byte(store.RandomToken(32))),
+		ExpiresAt:    time.Now().Add(10 * time.Minute),
+	}
+
+	oidcMu.Lock()
+	for s, l := range oidcLogins {
+		if time.Now().After(l.ExpiresAt) {
+			delete(oidcLogins, s)
+		}
+	}
+	oidcLogins[state] = login
+	oidcMu.Unlock()
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {cfg.RedirectURL},
+		"scope":                 {cfg.Scopes},
+		"state":                 {state},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {pkceChallenge(login.CodeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), state, nil
+}
+
+// OIDCCallback échange le code d'autorisation, valide l'ID token et retourne l'utilisateur local correspondant
+func OIDCCallback(ctx context.Context, cfg OIDCConfig, state, code string) (*models.User, error) {
+	oidcMu.Lock()
+	login, ok := oidcLogins[state]
+	delete(oidcLogins, state)
+	oidcMu.Unlock()
+	if !ok || time.Now().After(login.ExpiresAt) {
+		return nil, errors.New("oidc: état de connexion inconnu ou expiré")
+	}
+
+	d, err := discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Échange du code (avec le code_verifier PKCE)
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"client_id":     {cfg.ClientID},
+		"code_verifier": {login.CodeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	resp, err := oidcHTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: échange du code refusé (%s)", resp.Status)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil || tokens.IDToken == "" {
+		return nil, errors.New("oidc: réponse du token endpoint sans id_token")
+	}
+
+	claims, err := verifyIDToken(ctx, cfg, d, tokens.IDToken, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return userFromClaims(cfg.Issuer, claims)
+}
+
+// verifyIDToken vérifie la signature et les claims standards de l'ID token
+func verifyIDToken(ctx context.Context, cfg OIDCConfig, d oidcDiscovery, token, nonce string) (*IDTokenClaims, error) {
+	var keys JWKSet
+	if err := oidcGetJSON(ctx, d.JWKSURI, &keys); err != nil {
+		return nil, err
+	}
+
+	var claims IDTokenClaims
+	if err := VerifyJWT(token, keys, &claims); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	const leeway = 60 // tolérance d'horloge en secondes
+	switch {
+	case strings.TrimRight(claims.Issuer, "/") != cfg.Issuer:
+		return nil, errors.New("oidc: émetteur de l'ID token invalide")
+	case !containsString(claims.Audience, cfg.ClientID):
+		return nil, errors.New("oidc: audience de l'ID token invalide")
+	case claims.Expiry == 0 || now > claims.Expiry+leeway:
+		return nil, errors.New("oidc: ID token expiré")
+	case claims.IssuedAt > now+leeway:
+		return nil, errors.New("oidc: ID token émis dans le futur")
+	case claims.Nonce != nonce:
+		return nil, errors.New("oidc: nonce invalide")
+	case claims.Subject == "":
+		return nil, errors.New("oidc: claim sub manquant")
+	}
+	return &claims, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// userFromClaims retrouve l'utilisateur local lié au couple (émetteur, sub).
+// À la première connexion, un compte existant est lié si son email est vérifié par le fournisseur,
+// sinon un nouveau compte sans mot de passe est créé.
+func userFromClaims(issuer string, claims *IDTokenClaims) (*models.User, error) {
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	linked := userStore.Find(func(u models.User) bool {
+		return u.OIDCIssuer == issuer && u.OIDCSubject == claims.Subject
+	})
+	if len(linked) > 0 {
+		return &linked[0], nil
+	}
+
+	if claims.Email != "" && claims.EmailVerified {
+		if u, ok := FindUserByEmail(claims.Email); ok {
+			updated, err := userStore.Update(u.ID, func(u *models.User) error {
+				u.OIDCIssuer = issuer
+				u.OIDCSubject = claims.Subject
+				if u.Name == "" {
+					u.Name = claims.Name
+				}
+				return nil
+			})
+			return &updated, err
+		}
+	}
+
+	email := normalizeEmail(claims.Email)
+	if _, taken := FindUserByEmail(email); taken || !claims.EmailVerified {
+		// Email non vérifié ou déjà utilisé : on ne le reprend pas pour éviter toute prise de compte
+		email = ""
+	}
+	u := models.User{
+		ID:          store.NewID(),
+		Email:       email,
+		Name:        claims.Name,
+		OIDCIssuer:  issuer,
+		OIDCSubject: claims.Subject,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := userStore.Put(u.ID, u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// Erreurs des organisations
+var (
+	ErrOrgForbidden  = errors.New("votre rôle dans l'organisation ne permet pas cette action")
+	ErrInvalidRole   = errors.New("rôle inconnu")
+	ErrLastOwner     = errors.New("l'organisation doit garder au moins un propriétaire")
+	ErrInviteInvalid = errors.New("invitation invalide")
+	ErrInviteExpired = errors.New("cette invitation a expiré ou a été révoquée")
+	ErrOwnerInvite   = errors.New("le rôle propriétaire ne peut pas être donné par un lien d'invitation : invitez la personne, puis changez son rôle")
+)
+
+const (
+	// MaxOrgNameLength est la longueur maximale du nom d'une organisation
+	MaxOrgNameLength = 80
+	// InviteValidity est la durée de validité d'un lien d'invitation
+	InviteValidity = 7 * 24 * time.Hour
+	// inviteTokenPrefix préfixe les jetons d'invitation : inv_<id>_<secret>
+	inviteTokenPrefix = "inv_"
+)
+
+var (
+	orgStore    = store.New[models.Organization]("organizations")
+	memberStore = store.New[models.Membership]("memberships")
+	inviteStore = store.New[models.OrgInvite]("invites")
+
+	// ownersMu sérialise les changements de rôle et les départs : le contrôle du dernier propriétaire
+	// et l'écriture doivent se faire sans qu'un autre propriétaire soit rétrogradé ou retiré entre les deux
+	ownersMu sync.Mutex
+)
+
+// membershipID retourne l'identifiant de l'appartenance d'un utilisateur à une organisation
+func membershipID(orgID, userID string) string {
+	return orgID + "_" + userID
+}
+
+// memberRoles retourne le rôle de l'utilisateur dans chacune de ses organisations
+func memberRoles(userID string) map[string]string {
+	roles := map[string]string{}
+	for _, m := range memberStore.Find(func(m models.Membership) bool { return m.UserID == userID }) {
+		roles[m.OrgID] = m.Role
+	}
+	return roles
+}
+
+// validRole indique si role est un rôle d'organisation
+func validRole(role string) bool {
+	for _, r := range models.OrgRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMember indique si l'utilisateur est membre de l'organisation
+func IsMember(userID, orgID string) bool {
+	_, ok := memberStore.Get(membershipID(orgID, userID))
+	return ok
+}
+
+// memberOrg retourne l'organisation id et le rôle de l'utilisateur connecté ;
+// une organisation dont il n'est pas membre est introuvable
+func memberOrg(ctx context.Context, id string) (*models.Organization, string, error) {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return nil, "", ErrAccountRequired
+	}
+	m, ok := memberStore.Get(membershipID(id, u.ID))
+	if !ok {
+		return nil, "", store.ErrNotFound
+	}
+	org, ok := orgStore.Get(id)
+	if !ok {
+		return nil, "", store.ErrNotFound
+	}
+	return &org, m.Role, nil
+}
+
+// ownedOrg retourne l'organisation id si l'utilisateur connecté en est propriétaire
+func ownedOrg(ctx context.Context, id string) (*models.Organization, error) {
+	org, role, err := memberOrg(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.OrgRoleOwner {
+		return nil, ErrOrgForbidden
+	}
+	return org, nil
+}
+
+// CreateOrganization crée une organisation dont l'utilisateur connecté est le propriétaire
+func CreateOrganization(ctx context.Context, name string) (*models.Organization, error) {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return nil, ErrAccountRequired
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("le nom de l'organisation est obligatoire")
+	}
+	if utf8.RuneCountInString(name) > MaxOrgNameLength {
+		return nil, errors.New("le nom de l'organisation est trop long")
+	}
+
+	now := time.Now().UTC()
+	org := models.Organization{ID: store.NewID(), Name: name, CreatedBy: u.ID, CreatedAt: now}
+	if err := orgStore.Put(org.ID, org); err != nil {
+		return nil, err
+	}
+	m := models.Membership{ID: membershipID(org.ID, u.ID), OrgID: org.ID, UserID: u.ID, Role: models.OrgRoleOwner, CreatedAt: now}
+	if err := memberStore.Put(m.ID, m); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrganization retourne l'organisation id et le rôle de l'utilisateur connecté, s'il en est membre
+func GetOrganization(ctx context.Context, id string) (*models.Organization, string, error) {
+	return memberOrg(ctx, id)
+}
+
+// ListOrganizations retourne les organisations de l'utilisateur connecté avec son rôle, par nom
+func ListOrganizations(ctx context.Context) []models.OrgMembership {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return nil
+	}
+	var out []models.OrgMembership
+	for orgID, role := range memberRoles(u.ID) {
+		if org, ok := orgStore.Get(orgID); ok {
+			out = append(out, models.OrgMembership{Organization: org, Role: role})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out
+}
+
+// ListMembers retourne les membres de l'organisation, par rôle puis par nom
+func ListMembers(ctx context.Context, orgID string) ([]models.Member, error) {
+	if _, _, err := memberOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	rank := map[string]int{}
+	for i, r := range models.OrgRoles {
+		rank[r] = i
+	}
+	var members []models.Member
+	for _, m := range memberStore.Find(func(m models.Membership) bool { return m.OrgID == orgID }) {
+		member := models.Member{Membership: m}
+		if u, ok := GetUser(m.UserID); ok {
+			member.Name, member.Email = u.Name, u.Email
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if rank[members[i].Role] != rank[members[j].Role] {
+			return rank[members[i].Role] < rank[members[j].Role]
+		}
+		return strings.ToLower(members[i].Name) < strings.ToLower(members[j].Name)
+	})
+	return members, nil
+}
+
+// countOwners retourne le nombre de propriétaires de l'organisation
+func countOwners(orgID string) int {
+	return len(memberStore.Find(func(m models.Membership) bool {
+		return m.OrgID == orgID && m.Role == models.OrgRoleOwner
+	}))
+}
+
+// ChangeMemberRole change le rôle d'un membre ; réservé aux propriétaires
+func ChangeMemberRole(ctx context.Context, orgID, userID, role string) error {
+	if _, err := ownedOrg(ctx, orgID); err != nil {
+		return err
+	}
+	if !validRole(role) {
+		return ErrInvalidRole
+	}
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+	owners := countOwners(orgID) // hors de Update, qui verrouille la collection
+	_, err := memberStore.Update(membershipID(orgID, userID), func(m *models.Membership) error {
+		if m.Role == models.OrgRoleOwner && role != models.OrgRoleOwner && owners <= 1 {
+			return ErrLastOwner
+		}
+		m.Role = role
+		return nil
+	})
+	return err
+}
+
+// RemoveMember retire un membre de l'organisation ; un propriétaire peut retirer n'importe quel membre,
+// les autres membres peuvent seulement quitter l'organisation
+func RemoveMember(ctx context.Context, orgID, userID string) error {
+	_, role, err := memberOrg(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if role != models.OrgRoleOwner && UserFromContext(ctx).ID != userID {
+		return ErrOrgForbidden
+	}
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+	m, ok := memberStore.Get(membershipID(orgID, userID))
+	if !ok {
+		return store.ErrNotFound
+	}
+	if m.Role == models.OrgRoleOwner && countOwners(orgID) <= 1 {
+		return ErrLastOwner
+	}
+	_, err = memberStore.Delete(m.ID)
+	return err
+}
+
+// CreateInvite crée un lien d'invitation donnant le rôle role et retourne son jeton en clair (affiché une seule fois).
+// Un lien peut être transféré et utilisé plusieurs fois : il ne donne jamais le rôle de propriétaire.
+func CreateInvite(ctx context.Context, orgID, role string) (string, *models.OrgInvite, error) {
+	org, err := ownedOrg(ctx, orgID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !validRole(role) {
+		return "", nil, ErrInvalidRole
+	}
+	if role == models.OrgRoleOwner {
+		return "", nil, ErrOwnerInvite
+	}
+
+	id := store.NewID()
+	raw := inviteTokenPrefix + id + "_" + store.RandomToken(24)
+	now := time.Now().UTC()
+	inv := models.OrgInvite{
+		ID:        id,
+		OrgID:     org.ID,
+		Hash:      hashToken(raw),
+		Role:      role,
+		CreatedBy: UserFromContext(ctx).ID,
+		ExpiresAt: now.Add(InviteValidity),
+		CreatedAt: now,
+	}
+	if err := inviteStore.Put(inv.ID, inv); err != nil {
+		return "", nil, err
+	}
+	return raw, &inv, nil
+}
+
+// ListInvites retourne les invitations de l'organisation, des plus récentes aux plus anciennes ; réservé aux propriétaires
+func ListInvites(ctx context.Context, orgID string) ([]models.OrgInvite, error) {
+	if _, err := ownedOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	invites := inviteStore.Find(func(i models.OrgInvite) bool { return i.OrgID == orgID })
+	sort.Slice(invites, func(i, j int) bool {
+		return invites[i].CreatedAt.After(invites[j].CreatedAt)
+	})
+	return invites, nil
+}
+
+// RevokeInvite révoque l'invitation id de l'organisation
+func RevokeInvite(ctx context.Context, orgID, id string) error {
+	if _, err := ownedOrg(ctx, orgID); err != nil {
+		return err
+	}
+	_, err := inviteStore.Update(id, func(i *models.OrgInvite) error {
+		if i.OrgID != orgID {
+			return store.ErrNotFound
+		}
+		if i.RevokedAt == nil {
+			now := time.Now().UTC()
+			i.RevokedAt = &now
+		}
+		return nil
+	})
+	return err
+}
+
+// OpenInvite retrouve l'invitation d'un jeton en clair et son organisation, et vérifie qu'elle est encore valable
+func OpenInvite(raw string) (*models.OrgInvite, *models.Organization, error) {
+	rest, ok := strings.CutPrefix(raw, inviteTokenPrefix)
+	if !ok {
+		return nil, nil, ErrInviteInvalid
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, nil, ErrInviteInvalid
+	}
+	inv, ok := inviteStore.Get(id)
+	if !ok || subtle.ConstantTimeCompare([]byte(inv.Hash), []byte(hashToken(raw))) != 1 {
+		return nil, nil, ErrInviteInvalid
+	}
+	org, ok := orgStore.Get(inv.OrgID)
+	if !ok {
+		return nil, nil, ErrInviteInvalid
+	}
+	if !inv.Active() {
+		return nil, nil, ErrInviteExpired
+	}
+	if inv.Role == models.OrgRoleOwner {
+		// Liens créés avant que le rôle de propriétaire ne soit exclu des invitations
+		return nil, nil, ErrInviteExpired
+	}
+	return &inv, &org, nil
+}
+
+// AcceptInvite fait entrer l'utilisateur connecté dans l'organisation de l'invitation.
+// Un membre existant garde son rôle.
+func AcceptInvite(ctx context.Context, raw string) (*models.Organization, error) {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return nil, ErrAccountRequired
+	}
+	inv, org, err := OpenInvite(raw)
+	if err != nil {
+		return nil, err
+	}
+	if IsMember(u.ID, org.ID) {
+		return org, nil
+	}
+
+	m := models.Membership{ID: membershipID(org.ID, u.ID), OrgID: org.ID, UserID: u.ID, Role: inv.Role, CreatedAt: time.Now().UTC()}
+	if err := memberStore.Put(m.ID, m); err != nil {
+		return nil, err
+	}
+	inviteStore.Update(inv.ID, func(i *models.OrgInvite) error {
+		i.Uses++
+		return nil
+	})
+	return org, nil
+}
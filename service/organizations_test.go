@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pitch/models"
+)
+
+// newTestOrg crée une organisation dont userID est propriétaire et retourne le contexte de ce propriétaire
+func newTestOrg(t *testing.T, userID string) (*models.Organization, context.Context) {
+	t.Helper()
+	ctx := WithUser(context.Background(), &models.User{ID: userID, Name: userID})
+	org, err := CreateOrganization(ctx, "Incubateur "+userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return org, ctx
+}
+
+func TestCreateInviteRoles(t *testing.T) {
+	org, ctx := newTestOrg(t, "owner-invite")
+	tests := []struct {
+		role string
+		err  error
+	}{
+		{role: models.OrgRoleFounder},
+		{role: models.OrgRoleMentor},
+		{role: models.OrgRoleViewer},
+		{role: models.OrgRoleOwner, err: ErrOwnerInvite},
+		{role: "admin", err: ErrInvalidRole},
+	}
+	for _, tt := range tests {
+		_, _, err := CreateInvite(ctx, org.ID, tt.role)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("CreateInvite(%q) = %v, veut %v", tt.role, err, tt.err)
+		}
+	}
+
+	mentor := WithUser(context.Background(), &models.User{ID: "mentor-invite"})
+	addMember(t, org.ID, "mentor-invite", models.OrgRoleMentor)
+	if _, _, err := CreateInvite(mentor, org.ID, models.OrgRoleViewer); !errors.Is(err, ErrOrgForbidden) {
+		t.Errorf("un mentor ne doit pas pouvoir inviter : %v", err)
+	}
+}
+
+func TestOpenInvite(t *testing.T) {
+	org, ctx := newTestOrg(t, "owner-open")
+	raw, inv, err := CreateInvite(ctx, org.ID, models.OrgRoleFounder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, gotOrg, err := OpenInvite(raw); err != nil || got.ID != inv.ID || gotOrg.ID != org.ID {
+		t.Fatalf("OpenInvite(jeton valide) = %v, %v, %v", got, gotOrg, err)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		err  error
+	}{
+		{name: "secret modifié", raw: raw[:len(raw)-1] + "x", err: ErrInviteInvalid},
+		{name: "sans préfixe", raw: raw[len(inviteTokenPrefix):], err: ErrInviteInvalid},
+		{name: "identifiant inconnu", raw: inviteTokenPrefix + "inconnu_secret", err: ErrInviteInvalid},
+		{name: "vide", raw: "", err: ErrInviteInvalid},
+	}
+	for _, tt := range tests {
+		if _, _, err := OpenInvite(tt.raw); !errors.Is(err, tt.err) {
+			t.Errorf("%s : OpenInvite() = %v, veut %v", tt.name, err, tt.err)
+		}
+	}
+
+	inviteStore.Update(inv.ID, func(i *models.OrgInvite) error {
+		i.ExpiresAt = time.Now().Add(-time.Minute)
+		return nil
+	})
+	if _, _, err := OpenInvite(raw); !errors.Is(err, ErrInviteExpired) {
+		t.Errorf("invitation expirée : %v", err)
+	}
+
+	raw, inv, _ = CreateInvite(ctx, org.ID, models.OrgRoleViewer)
+	if err := RevokeInvite(ctx, org.ID, inv.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := OpenInvite(raw); !errors.Is(err, ErrInviteExpired) {
+		t.Errorf("invitation révoquée : %v", err)
+	}
+
+	// Une invitation propriétaire créée avant l'interdiction n'est plus utilisable
+	raw, inv, _ = CreateInvite(ctx, org.ID, models.OrgRoleFounder)
+	inviteStore.Update(inv.ID, func(i *models.OrgInvite) error {
+		i.Role = models.OrgRoleOwner
+		return nil
+	})
+	if _, err := AcceptInvite(WithUser(context.Background(), &models.User{ID: "intrus"}), raw); !errors.Is(err, ErrInviteExpired) {
+		t.Errorf("invitation propriétaire : %v", err)
+	}
+	if IsMember("intrus", org.ID) {
+		t.Error("l'invitation propriétaire ne doit faire entrer personne")
+	}
+}
+
+func TestAcceptInvite(t *testing.T) {
+	org, ctx := newTestOrg(t, "owner-accept")
+	raw, inv, _ := CreateInvite(ctx, org.ID, models.OrgRoleMentor)
+
+	ben := WithUser(context.Background(), &models.User{ID: "ben-accept"})
+	if _, err := AcceptInvite(ben, raw); err != nil {
+		t.Fatal(err)
+	}
+	if role := memberRoles("ben-accept")[org.ID]; role != models.OrgRoleMentor {
+		t.Errorf("rôle = %q, veut mentor", role)
+	}
+	if _, err := AcceptInvite(context.Background(), raw); !errors.Is(err, ErrAccountRequired) {
+		t.Errorf("invitation sans compte : %v", err)
+	}
+	if got, _ := inviteStore.Get(inv.ID); got.Uses != 1 {
+		t.Errorf("Uses = %d, veut 1", got.Uses)
+	}
+}
+
+func TestConcurrentOwnerDemotion(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		a, b := fmt.Sprintf("owner-a-%d", i), fmt.Sprintf("owner-b-%d", i)
+		org, ctxA := newTestOrg(t, a)
+		addMember(t, org.ID, b, models.OrgRoleOwner)
+		ctxB := WithUser(context.Background(), &models.User{ID: b})
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, 2)
+		wg.Add(2)
+		go func() { defer wg.Done(); <-start; errs[0] = ChangeMemberRole(ctxA, org.ID, b, models.OrgRoleViewer) }()
+		go func() { defer wg.Done(); <-start; errs[1] = ChangeMemberRole(ctxB, org.ID, a, models.OrgRoleViewer) }()
+		close(start)
+		wg.Wait()
+
+		if n := countOwners(org.ID); n != 1 {
+			t.Fatalf("%d propriétaire(s) après deux rétrogradations simultanées (erreurs %v), veut 1", n, errs)
+		}
+	}
+}
+
+func TestRemoveLastOwner(t *testing.T) {
+	org, ctx := newTestOrg(t, "owner-last")
+	if err := RemoveMember(ctx, org.ID, "owner-last"); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("le dernier propriétaire ne doit pas pouvoir partir : %v", err)
+	}
+	if err := ChangeMemberRole(ctx, org.ID, "owner-last", models.OrgRoleFounder); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("le dernier propriétaire ne doit pas pouvoir être rétrogradé : %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// Vecteurs de la RFC 7914 (section 11) et vecteur usuel à 4096 itérations
+	tests := []struct {
+		password, salt string
+		iter, keyLen   int
+		want           string
+	}{
+		{password: "passwd", salt: "salt", iter: 1, keyLen: 64,
+			want: "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{password: "Password", salt: "NaCl", iter: 80000, keyLen: 64,
+			want: "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+		{password: "password", salt: "salt", iter: 4096, keyLen: 32,
+			want: "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		// Clé tronquée : préfixe de la clé complète
+		{password: "passwd", salt: "salt", iter: 1, keyLen: 20,
+			want: "55ac046e56e3089fec1691c22544b605f9418521"},
+	}
+	for _, tt := range tests {
+		want, _ := hex.DecodeString(tt.want)
+		if got := pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iter, tt.keyLen); !bytes.Equal(got, want) {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d, %d) = %x, veut %s", tt.password, tt.salt, tt.iter, tt.keyLen, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := HashPassword("correct horse"); other == hash {
+		t.Error("deux empreintes du même mot de passe doivent différer (sel aléatoire)")
+	}
+	parts := strings.Split(hash, "$")
+	tests := []struct {
+		name, hash, password string
+		want                 bool
+	}{
+		{name: "bon mot de passe", hash: hash, password: "correct horse", want: true},
+		{name: "mauvais mot de passe", hash: hash, password: "correct horsE"},
+		{name: "vide", hash: hash, password: ""},
+		{name: "algorithme inconnu", hash: "bcrypt$" + strings.Join(parts[1:], "$"), password: "correct horse"},
+		{name: "itérations invalides", hash: strings.Join([]string{parts[0], "0", parts[2], parts[3]}, "$"), password: "correct horse"},
+		{name: "sel invalide", hash: strings.Join([]string{parts[0], parts[1], "!!", parts[3]}, "$"), password: "correct horse"},
+		{name: "format tronqué", hash: strings.Join(parts[:3], "$"), password: "correct horse"},
+		{name: "empreinte vide", hash: "", password: ""},
+	}
+	for _, tt := range tests {
+		if got := CheckPassword(tt.hash, tt.password); got != tt.want {
+			t.Errorf("%s : CheckPassword() = %v, veut %v", tt.name, got, tt.want)
+		}
+	}
+}
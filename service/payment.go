@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"pitch/models"
+)
+
+// Erreurs de paiement
+var (
+	ErrPaymentNotCompleted = errors.New("paiement non finalisé")
+	ErrPaymentsDisabled    = errors.New("le paiement en ligne n'est pas disponible")
+)
+
+// PaymentProvider abstrait le prestataire de paiement (Stripe, PayDunya, CinetPay...).
+// CreateCheckout retourne l'URL vers laquelle rediriger le client ; au retour,
+// CompleteCheckout confirme auprès du prestataire que la commande est payée.
+type PaymentProvider interface {
+	CreateCheckout(ctx context.Context, order models.Order, returnURL string) (checkoutURL string, err error)
+	CompleteCheckout(ctx context.Context, orderID string) (models.Order, error)
+}
+
+// FakePaymentProvider simule un prestataire : la page /credits/fake-checkout/{id} permet de « payer » la commande
+type FakePaymentProvider struct {
+	mu     sync.Mutex
+	orders map[string]models.Order
+	paid   map[string]bool
+}
+
+// NewFakePaymentProvider crée un prestataire factice en mémoire
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{orders: map[string]models.Order{}, paid: map[string]bool{}}
+}
+
+// CreateCheckout enregistre la commande et retourne l'URL de la page de paiement factice
+func (f *FakePaymentProvider) CreateCheckout(ctx context.Context, order models.Order, returnURL string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[order.ID] = order
+	return "/credits/fake-checkout/" + order.ID, nil
+}
+
+// Pay marque la commande comme payée (action de la page factice)
+func (f *FakePaymentProvider) Pay(orderID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.orders[orderID]; !ok {
+		return errors.New("commande inconnue")
+	}
+	f.paid[orderID] = true
+	return nil
+}
+
+// Order retourne une commande en attente
+func (f *FakePaymentProvider) Order(orderID string) (models.Order, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.orders[orderID]
+	return o, ok
+}
+
+// CompleteCheckout retourne la commande si elle a été payée
+func (f *FakePaymentProvider) CompleteCheckout(ctx context.Context, orderID string) (models.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.orders[orderID]
+	if !ok || !f.paid[orderID] {
+		return o, ErrPaymentNotCompleted
+	}
+	now := time.Now().UTC()
+	o.PaidAt = &now
+	delete(f.orders, orderID)
+	delete(f.paid, orderID)
+	return o, nil
+}
+
+// Payments est le prestataire de paiement utilisé par l'application ;
+// nil tant qu'aucun prestataire n'est configuré, les achats sont alors refusés
+var Payments PaymentProvider
+
+// ConfigurePayments choisit le prestataire de paiement selon PAYMENT_PROVIDER.
+// Seul le prestataire simulé existe pour l'instant (PAYMENT_PROVIDER=fake) : il valide
+// n'importe quelle commande et ne doit être activé qu'en développement.
+func ConfigurePayments() {
+	switch provider := os.Getenv("PAYMENT_PROVIDER"); provider {
+	case "":
+		Payments = nil
+	case "fake":
+		log.Println("paiements : prestataire simulé activé (PAYMENT_PROVIDER=fake), à ne pas utiliser en production")
+		Payments = NewFakePaymentProvider()
+	default:
+		log.Printf("paiements : prestataire %q inconnu, achats désactivés", provider)
+		Payments = nil
+	}
+}
+
+// FakePayments retourne le prestataire simulé s'il est activé
+func FakePayments() (*FakePaymentProvider, bool) {
+	fake, ok := Payments.(*FakePaymentProvider)
+	return fake, ok
+}
+
+// PaymentsEnabled indique si un prestataire de paiement est configuré
+func PaymentsEnabled() bool {
+	return Payments != nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// Longueurs autorisées pour la description d'un projet
+const (
+	MinDescriptionLength = 10
+	MaxDescriptionLength = 2000
+)
+
+// ErrGenerationFailed est retourné quand aucun pitch n'a pu être généré
+var ErrGenerationFailed = errors.New("génération du pitch impossible")
+
+var pitchStore = store.New[models.Pitch]("pitches")
+
+// ValidateDescription vérifie la description d'un projet et retourne un message d'erreur lisible
+func ValidateDescription(desc string) error {
+	if desc == "" {
+		return errors.New("Veuillez décrire votre projet.")
+	}
+	if len(desc) < MinDescriptionLength {
+		return errors.New("La description doit contenir au moins 10 caractères.")
+	}
+	if len(desc) > MaxDescriptionLength {
+		return errors.New("La description ne doit pas dépasser 2000 caractères.")
+	}
+	return nil
+}
+
+// GenerateOptions sont les options d'une génération
+type GenerateOptions struct {
+	// NoCache force un appel au fournisseur même si un résultat est en cache
+	NoCache bool
+	// Model remplace le modèle du fournisseur principal (OPENAI_MODEL)
+	Model string
+	// Language est la langue de rédaction (voir Languages, français par défaut)
+	Language string
+	// Framework oriente le contenu des sections (voir Frameworks, « classique » par défaut)
+	Framework string
+	// OnQueued est appelée avec la position dans la file d'attente si la génération doit attendre un slot,
+	// puis à chaque changement de position
+	OnQueued func(position int)
+	// OnStarted est appelée quand l'appel au fournisseur commence (après l'attente éventuelle)
+	OnStarted func()
+}
+
+// cacheOptions retourne la partie des options qui distingue deux résultats en cache
+func (o GenerateOptions) cacheOptions() string {
+	model := o.Model
+	if model == "" {
+		model = OpenAIModel()
+	}
+	return "model=" + model + ";lang=" + o.language() + ";framework=" + o.framework()
+}
+
+// Generate produit un pitch pour la description, en mode démo ou via OpenAI.
+// Les appels OpenAI sont décomptés des crédits, servis depuis le cache si possible,
+// soumis aux budgets mensuels, limités en concurrence par la file d'attente et leur consommation est enregistrée.
+func Generate(ctx context.Context, desc string, opts GenerateOptions) (*models.PitchResponse, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if DemoModeEnabled() {
+		return GeneratePitchResponse(desc), nil
+	}
+
+	refund, err := SpendCredits(ctx, CreditCostGeneration, "Génération d'un pitch")
+	if err != nil {
+		return nil, err
+	}
+
+	var embedding []float32
+	if cacheEnabled() {
+		if opts.NoCache {
+			cacheBypassed.Add(1)
+		} else {
+			var cached *models.PitchResponse
+			if cached, embedding = cacheLookup(ctx, desc, opts.cacheOptions()); cached != nil {
+				return cached, nil
+			}
+		}
+	}
+
+	if err := CheckBudget(ctx); err != nil {
+		refund()
+		return nil, err
+	}
+
+	release, err := generationQueue.Acquire(ctx, generationPriority(ctx), opts.OnQueued)
+	if err != nil {
+		refund()
+		if errors.Is(err, ErrQueueFull) {
+			return nil, err
+		}
+		return nil, ErrGenerationFailed
+	}
+	if opts.OnStarted != nil {
+		opts.OnStarted()
+	}
+	resp, err := generateWithFailover(ctx, desc, opts)
+	release()
+	if err != nil {
+		// Une génération échouée n'est pas facturée à l'utilisateur
+		refund()
+		return nil, err
+	}
+
+	if cacheEnabled() {
+		cacheStoreResult(ctx, desc, opts.cacheOptions(), resp, embedding)
+	}
+	return resp, nil
+}
+
+// GenerationFailureMessage explique à l'utilisateur pourquoi la génération a échoué
+func GenerationFailureMessage() string {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return "⚠️ La clé API OpenAI n'est pas configurée. Veuillez définir la variable d'environnement OPENAI_API_KEY dans les paramètres de votre service."
+	}
+	// Vérifier le format de la clé (doit commencer par sk-)
+	if !strings.HasPrefix(apiKey, "sk-") {
+		return "⚠️ Format de clé API invalide. La clé OpenAI doit commencer par 'sk-'. Vérifiez votre configuration."
+	}
+	return "⚠️ Impossible de générer le pitch après plusieurs tentatives.\n\nCauses possibles :\n• Problème réseau temporaire\n• Timeout de l'API OpenAI (>25s)\n• Quota/rate limit atteint\n• Service OpenAI temporairement indisponible\n\nVeuillez réessayer dans quelques instants."
+}
+
+// CreatePitch valide la description, génère le pitch et l'enregistre au nom de l'appelant,
+// dans l'organisation de son espace de travail courant s'il en a choisi une
+func CreatePitch(ctx context.Context, desc string, opts GenerateOptions) (*models.Pitch, error) {
+	desc = strings.TrimSpace(desc)
+	if err := ValidateDescription(desc); err != nil {
+		return nil, err
+	}
+	orgID := WorkspaceFromContext(ctx)
+	if orgID != "" && !canAuthor(tenantOf(ctx).roles[orgID]) {
+		return nil, ErrOrgForbidden
+	}
+
+	resp, err := Generate(ctx, desc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := models.Pitch{
+		ID:          store.NewID(),
+		Description: desc,
+		Sections:    resp,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if u := UserFromContext(ctx); u != nil {
+		p.OwnerID = u.ID
+		p.OrgID = orgID
+	} else if k := APIKeyFromContext(ctx); k != nil {
+		p.APIKeyID = k.ID
+	}
+	if err := readablePitches(ctx).Put(p.ID, p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetPitch retourne le pitch id s'il est accessible à l'appelant
+func GetPitch(ctx context.Context, id string) (*models.Pitch, error) {
+	p, ok := readablePitches(ctx).Get(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &p, nil
+}
+
+// ListPitches retourne les pitchs de l'espace de travail de l'appelant (pitchs personnels de l'utilisateur,
+// pitchs de l'organisation choisie ou pitchs de la clé API), du plus récent au plus ancien
+func ListPitches(ctx context.Context, offset, limit int) ([]models.Pitch, int) {
+	u := UserFromContext(ctx)
+	k := APIKeyFromContext(ctx)
+	orgID := WorkspaceFromContext(ctx)
+	all := readablePitches(ctx).Find(func(p models.Pitch) bool {
+		switch {
+		case u != nil && orgID != "":
+			return p.OrgID == orgID
+		case u != nil:
+			return p.OwnerID == u.ID && p.OrgID == ""
+		case k != nil:
+			return p.APIKeyID == k.ID
+		}
+		return false
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+	total := len(all)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return all[offset:end], total
+}
+
+// DeletePitch supprime le pitch id si l'appelant peut le modifier
+func DeletePitch(ctx context.Context, id string) error {
+	found, err := writablePitches(ctx).Delete(id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Langues de rédaction disponibles (code -> nom utilisé dans le prompt)
+var languages = map[string]string{
+	"fr": "français",
+	"en": "anglais",
+	"es": "espagnol",
+	"de": "allemand",
+	"pt": "portugais",
+}
+
+// Frameworks de pitch disponibles (identifiant -> consigne ajoutée au prompt).
+// Les 6 sections restent les mêmes ; le framework oriente leur contenu.
+var frameworks = map[string]string{
+	"classique": "",
+	"lean":      "Suis la logique du Lean Canvas : insiste sur les hypothèses à valider, les indicateurs clés et l'avantage déloyal.",
+	"yc":        "Adopte le style d'une candidature Y Combinator : phrases courtes et factuelles, traction et taille de marché chiffrées quand c'est possible.",
+	"elevator":  "Rédige un elevator pitch : une seule phrase percutante par section, compréhensible en 30 secondes au total.",
+	"investor":  "Adresse-toi à des investisseurs : mets en avant le potentiel de croissance, les revenus et l'utilisation des fonds.",
+}
+
+// DefaultLanguage et DefaultFramework sont utilisés quand l'option n'est pas précisée
+const (
+	DefaultLanguage  = "fr"
+	DefaultFramework = "classique"
+)
+
+// Languages retourne les codes de langue acceptés, triés
+func Languages() []string {
+	return sortedKeys(languages)
+}
+
+// Frameworks retourne les frameworks de pitch acceptés, triés
+func Frameworks() []string {
+	return sortedKeys(frameworks)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate vérifie que la langue et le framework demandés existent
+func (o GenerateOptions) Validate() error {
+	if _, ok := languages[o.language()]; !ok {
+		return fmt.Errorf("langue inconnue %q (disponibles : %v)", o.Language, Languages())
+	}
+	if _, ok := frameworks[o.framework()]; !ok {
+		return fmt.Errorf("framework inconnu %q (disponibles : %v)", o.Framework, Frameworks())
+	}
+	return nil
+}
+
+func (o GenerateOptions) language() string {
+	if o.Language == "" {
+		return DefaultLanguage
+	}
+	return o.Language
+}
+
+func (o GenerateOptions) framework() string {
+	if o.Framework == "" {
+		return DefaultFramework
+	}
+	return o.Framework
+}
+
+// promptInstructions retourne les consignes de langue et de framework à ajouter au prompt.
+// Les libellés des sections restent en français pour que la réponse reste analysable.
+func (o GenerateOptions) promptInstructions() string {
+	var s string
+	if lang := o.language(); lang != DefaultLanguage {
+		s += fmt.Sprintf("\n\nRédige le contenu de chaque section en %s, mais garde les numéros et les libellés entre crochets en français.", languages[lang])
+	}
+	if hint := frameworks[o.framework()]; hint != "" {
+		s += "\n\n" + hint
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"pitch/models"
+)
+
+// ErrProvidersUnavailable est retourné quand tous les fournisseurs ont leur disjoncteur ouvert
+var ErrProvidersUnavailable = errors.New("aucun fournisseur IA disponible")
+
+// Provider est un point d'accès compatible OpenAI (URL, clé et modèle)
+type Provider struct {
+	Name    string
+	BaseURL string // vide = API OpenAI officielle
+	APIKey  string
+	Model   string
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*CircuitBreaker{}
+)
+
+// Providers retourne le fournisseur principal puis, si FALLBACK_MODEL est défini, le fournisseur de secours
+// (FALLBACK_BASE_URL et FALLBACK_API_KEY, par défaut ceux du principal)
+func Providers() []Provider {
+	primary := Provider{
+		Name:    "primary",
+		BaseURL: os.Getenv("OPENAI_BASE_URL"),
+		APIKey:  os.Getenv("OPENAI_API_KEY"),
+		Model:   OpenAIModel(),
+	}
+	list := []Provider{primary}
+
+	if model := os.Getenv("FALLBACK_MODEL"); model != "" {
+		secondary := Provider{
+			Name:    "secondary",
+			BaseURL: os.Getenv("FALLBACK_BASE_URL"),
+			APIKey:  os.Getenv("FALLBACK_API_KEY"),
+			Model:   model,
+		}
+		if secondary.BaseURL == "" {
+			secondary.BaseURL = primary.BaseURL
+		}
+		if secondary.APIKey == "" {
+			secondary.APIKey = primary.APIKey
+		}
+		list = append(list, secondary)
+	}
+	return list
+}
+
+// breakerFor retourne le disjoncteur du fournisseur (BREAKER_THRESHOLD échecs, ouvert BREAKER_OPEN_DURATION)
+func breakerFor(p Provider) *CircuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	key := p.Name + "/" + p.Model
+	b, ok := breakers[key]
+	if !ok {
+		openFor, err := time.ParseDuration(os.Getenv("BREAKER_OPEN_DURATION"))
+		if err != nil || openFor <= 0 {
+			openFor = 30 * time.Second
+		}
+		b = NewCircuitBreaker(envInt("BREAKER_THRESHOLD", 5), openFor)
+		breakers[key] = b
+	}
+	return b
+}
+
+// generateWithFailover génère le pitch avec le premier fournisseur disponible.
+// opts.Model ne remplace que le modèle du fournisseur principal.
+func generateWithFailover(ctx context.Context, input string, opts GenerateOptions) (*models.PitchResponse, error) {
+	providers := Providers()
+	if opts.Model != "" {
+		providers[0].Model = opts.Model
+	}
+	var resp *models.PitchResponse
+	err := withFailover(ctx, providers, func(p Provider, b *CircuitBreaker) (usage TokenUsage, err error) {
+		resp, usage, err = generateWithOpenAI(ctx, p, b, input, opts)
+		return usage, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// withFailover appelle call sur chaque fournisseur dans l'ordre, en sautant ceux dont le disjoncteur est ouvert,
+// jusqu'au premier succès. Seules les défaillances du fournisseur (errProviderFault) comptent pour son disjoncteur ;
+// l'annulation de la requête arrête le basculement. La consommation de chaque fournisseur appelé est enregistrée.
+// Retourne ErrProvidersUnavailable si aucun fournisseur n'a pu être appelé, ErrGenerationFailed si tous ont échoué.
+func withFailover(ctx context.Context, providers []Provider, call func(p Provider, b *CircuitBreaker) (TokenUsage, error)) error {
+	tried := false
+	for _, p := range providers {
+		b := breakerFor(p)
+		if !b.Allow() {
+			continue
+		}
+		tried = true
+
+		usage, err := call(p, b)
+		recordUsage(ctx, p.Model, usage, err == nil)
+		switch {
+		case err == nil:
+			b.Success()
+			return nil
+		case errors.Is(err, errProviderFault):
+			b.Failure()
+		default:
+			b.Abandon()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if !tried {
+		return ErrProvidersUnavailable
+	}
+	return ErrGenerationFailed
+}
+
+// BreakerStatuses retourne l'état des disjoncteurs de chaque fournisseur
+func BreakerStatuses() []BreakerStatus {
+	var out []BreakerStatus
+	for _, p := range Providers() {
+		state, failures, openedAt := breakerFor(p).State()
+		s := BreakerStatus{Provider: p.Name, Model: p.Model, State: state, ConsecutiveFailures: failures}
+		if state != BreakerClosed {
+			s.OpenedAt = &openedAt
+		}
+		out = append(out, s)
+	}
+	return out
+}
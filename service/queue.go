@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrQueueFull est retournée quand la file d'attente de génération est pleine
+var ErrQueueFull = errors.New("file d'attente de génération pleine")
+
+// Priorités de passage dans la file d'attente (la plus haute passe en premier)
+const (
+	PriorityLow    = 0 // visiteurs anonymes et plan gratuit
+	PriorityNormal = 1 // clés API
+	PriorityHigh   = 2 // plan Premium
+)
+
+// GenerationQueue limite le nombre d'appels simultanés au fournisseur.
+// Au-delà de Concurrency, les demandes attendent dans une file bornée à MaxQueue,
+// triée par priorité puis par ordre d'arrivée.
+type GenerationQueue struct {
+	Concurrency int
+	MaxQueue    int
+
+	mu       sync.Mutex
+	running  int
+	reserved int // places promises aux tâches en arrière-plan qui n'ont pas encore appelé Acquire
+	seq      uint64
+	waiting  []*queueTicket
+	// notifyMu garantit que les positions sont communiquées dans l'ordre des changements de la file
+	notifyMu sync.Mutex
+}
+
+type queueTicket struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	onQueued func(position int)
+	position int // dernière position communiquée
+}
+
+// queueUpdate est une nouvelle position à communiquer à une demande en attente
+type queueUpdate struct {
+	onQueued func(position int)
+	position int
+}
+
+// QueueReservation est une place promise dans la file à une génération lancée en arrière-plan.
+// Elle est consommée par le premier Acquire dont le contexte la porte (voir WithReservation)
+// et doit être rendue par Cancel si la génération n'atteint jamais la file (cache, erreur...).
+type QueueReservation struct {
+	q    *GenerationQueue
+	done bool // consommée ou rendue (protégé par q.mu)
+}
+
+const queueReservationKey contextKey = "queue_reservation"
+
+// QueueStats est l'état de la file d'attente exposé sur /health
+type QueueStats struct {
+	Concurrency int `json:"concurrency"`
+	Running     int `json:"running"`
+	Waiting     int `json:"waiting"`
+	MaxQueue    int `json:"max_queue"`
+}
+
+// NewGenerationQueue crée une file avec au moins un slot d'exécution
+func NewGenerationQueue(concurrency, maxQueue int) *GenerationQueue {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &GenerationQueue{Concurrency: concurrency, MaxQueue: maxQueue}
+}
+
+// generationQueue est configurée par GENERATION_CONCURRENCY et GENERATION_QUEUE_SIZE
+var generationQueue = newConfiguredQueue()
+
+func newConfiguredQueue() *GenerationQueue {
+	return NewGenerationQueue(envInt("GENERATION_CONCURRENCY", 4), envInt("GENERATION_QUEUE_SIZE", 50))
+}
+
+// ConfigureGenerationQueue relit GENERATION_CONCURRENCY et GENERATION_QUEUE_SIZE ;
+// à appeler au démarrage, avant toute génération
+func ConfigureGenerationQueue() {
+	generationQueue = newConfiguredQueue()
+}
+
+// Acquire réserve un slot d'exécution, en attendant si nécessaire.
+// onQueued reçoit la position dans la file (1 = prochain servi) si la demande doit attendre,
+// puis chaque nouvelle position quand la file avance ou qu'une demande prioritaire la double.
+// release doit être appelée à la fin de l'appel au fournisseur.
+// Une demande qui porte une réservation (voir Reserve) n'est jamais refusée.
+func (q *GenerationQueue) Acquire(ctx context.Context, priority int, onQueued func(position int)) (release func(), err error) {
+	q.mu.Lock()
+	reserved := false
+	if r, ok := ctx.Value(queueReservationKey).(*QueueReservation); ok && r.q == q {
+		reserved = r.take()
+	}
+	if q.running < q.Concurrency && len(q.waiting) == 0 {
+		q.running++
+		q.mu.Unlock()
+		return q.release, nil
+	}
+	if !reserved && len(q.waiting)+q.reserved >= q.MaxQueue {
+		q.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	q.seq++
+	t := &queueTicket{priority: priority, seq: q.seq, ready: make(chan struct{}), onQueued: onQueued}
+	// Insérer après toutes les demandes de priorité supérieure ou égale
+	i := sort.Search(len(q.waiting), func(i int) bool { return q.waiting[i].priority < priority })
+	q.waiting = append(q.waiting, nil)
+	copy(q.waiting[i+1:], q.waiting[i:])
+	q.waiting[i] = t
+	q.unlockAndNotify()
+
+	select {
+	case <-t.ready:
+		return q.release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		for j, w := range q.waiting {
+			if w == t {
+				q.waiting = append(q.waiting[:j], q.waiting[j+1:]...)
+				q.unlockAndNotify()
+				return nil, ctx.Err()
+			}
+		}
+		q.mu.Unlock()
+		// Le slot a été attribué entre-temps : le rendre
+		q.release()
+		return nil, ctx.Err()
+	}
+}
+
+// Reserve promet une place (slot ou file) à une génération qui appellera Acquire plus tard ;
+// ErrQueueFull si toutes les places sont déjà occupées ou promises
+func (q *GenerationQueue) Reserve() (*QueueReservation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running+len(q.waiting)+q.reserved >= q.Concurrency+q.MaxQueue {
+		return nil, ErrQueueFull
+	}
+	q.reserved++
+	return &QueueReservation{q: q}, nil
+}
+
+// WithReservation retourne un contexte dont le prochain Acquire consomme la réservation
+func (r *QueueReservation) WithReservation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queueReservationKey, r)
+}
+
+// Cancel rend la place si elle n'a pas été consommée ; sans effet sinon
+func (r *QueueReservation) Cancel() {
+	r.q.mu.Lock()
+	r.take()
+	r.q.mu.Unlock()
+}
+
+// take retire la réservation du décompte (q.mu pris) et indique si elle était encore valable
+func (r *QueueReservation) take() bool {
+	if r.done {
+		return false
+	}
+	r.done = true
+	r.q.reserved--
+	return true
+}
+
+// release libère un slot ou le transmet directement à la demande suivante
+func (q *GenerationQueue) release() {
+	q.mu.Lock()
+	if len(q.waiting) > 0 {
+		next := q.waiting[0]
+		q.waiting = q.waiting[1:]
+		close(next.ready)
+		q.unlockAndNotify()
+		return
+	}
+	q.running--
+	q.mu.Unlock()
+}
+
+// unlockAndNotify libère le verrou de la file (pris par l'appelant) puis communique
+// leur nouvelle position aux demandes en attente dont la place a changé
+func (q *GenerationQueue) unlockAndNotify() {
+	var updates []queueUpdate
+	for i, t := range q.waiting {
+		if t.onQueued != nil && t.position != i+1 {
+			t.position = i + 1
+			updates = append(updates, queueUpdate{onQueued: t.onQueued, position: i + 1})
+		}
+	}
+	q.notifyMu.Lock()
+	defer q.notifyMu.Unlock()
+	q.mu.Unlock()
+	for _, u := range updates {
+		u.onQueued(u.position)
+	}
+}
+
+// Busy indique si une nouvelle demande devrait attendre dans la file
+func (q *GenerationQueue) Busy() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running+q.reserved >= q.Concurrency || len(q.waiting) > 0
+}
+
+// Stats retourne l'état courant de la file
+func (q *GenerationQueue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return QueueStats{Concurrency: q.Concurrency, Running: q.running, Waiting: len(q.waiting), MaxQueue: q.MaxQueue}
+}
+
+// GenerationQueueBusy indique si une génération lancée maintenant attendrait dans la file
+func GenerationQueueBusy() bool {
+	return generationQueue.Busy()
+}
+
+// GetQueueStats retourne l'état de la file d'attente de génération
+func GetQueueStats() QueueStats {
+	return generationQueue.Stats()
+}
+
+// generationPriority détermine la priorité de l'appelant selon son offre
+func generationPriority(ctx context.Context) int {
+	if u := UserFromContext(ctx); u != nil && UserPlan(u).ID == "premium" {
+		return PriorityHigh
+	}
+	if APIKeyFromContext(ctx) != nil {
+		return PriorityNormal
+	}
+	return PriorityLow
+}
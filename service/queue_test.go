@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// positionRecorder enregistre les positions communiquées à une demande en attente
+type positionRecorder struct {
+	mu        sync.Mutex
+	positions []int
+}
+
+func (r *positionRecorder) record(position int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.positions = append(r.positions, position)
+}
+
+func (r *positionRecorder) all() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.positions...)
+}
+
+func (r *positionRecorder) last() int {
+	all := r.all()
+	if len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1]
+}
+
+// waitFor attend que cond soit vraie, ou échoue au bout d'une seconde
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("délai dépassé : %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueueLivePositions(t *testing.T) {
+	q := NewGenerationQueue(1, 10)
+	release, err := q.Acquire(context.Background(), PriorityLow, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Busy() {
+		t.Fatal("la file doit être occupée quand tous les slots sont pris")
+	}
+
+	// a puis b attendent ; c, prioritaire, passe devant eux
+	order := make(chan string, 3)
+	recs := map[string]*positionRecorder{"a": {}, "b": {}, "c": {}}
+	acquire := func(name string, priority int) {
+		rel, err := q.Acquire(context.Background(), priority, recs[name].record)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		order <- name
+		rel()
+	}
+	go acquire("a", PriorityLow)
+	waitFor(t, "a en position 1", func() bool { return recs["a"].last() == 1 })
+	go acquire("b", PriorityLow)
+	waitFor(t, "b en position 2", func() bool { return recs["b"].last() == 2 })
+	go acquire("c", PriorityHigh)
+	waitFor(t, "c en position 1", func() bool { return recs["c"].last() == 1 })
+	waitFor(t, "a recule en position 2", func() bool { return recs["a"].last() == 2 })
+	waitFor(t, "b recule en position 3", func() bool { return recs["b"].last() == 3 })
+
+	release()
+	for _, want := range []string{"c", "a", "b"} {
+		if got := <-order; got != want {
+			t.Fatalf("servi %q, veut %q", got, want)
+		}
+	}
+	waitFor(t, "b avance en position 1", func() bool { return recs["b"].last() == 1 })
+	if got := recs["b"].all(); len(got) != 4 || got[2] != 2 {
+		t.Errorf("positions de b = %v, veut [2 3 2 1]", got)
+	}
+	if q.Busy() {
+		t.Error("la file doit être libre une fois toutes les demandes servies")
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	q := NewGenerationQueue(1, 1)
+	release, _ := q.Acquire(context.Background(), PriorityLow, nil)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error)
+	go func() {
+		_, err := q.Acquire(ctx, PriorityLow, nil)
+		queued <- err
+	}()
+	waitFor(t, "une demande en attente", func() bool { return q.Stats().Waiting == 1 })
+
+	if _, err := q.Acquire(context.Background(), PriorityHigh, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Acquire() sur file pleine = %v, veut ErrQueueFull", err)
+	}
+
+	cancel()
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() annulée = %v, veut context.Canceled", err)
+	}
+	if s := q.Stats(); s.Waiting != 0 || s.Running != 1 {
+		t.Errorf("Stats() après annulation = %+v", s)
+	}
+}
+
+func TestQueueReservations(t *testing.T) {
+	q := NewGenerationQueue(1, 1)
+
+	// Un slot et une place de file : deux réservations au plus
+	r1, err := q.Reserve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := q.Reserve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Reserve(); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("troisième réservation = %v, veut %v", err, ErrQueueFull)
+	}
+	if !q.Busy() {
+		t.Error("la file doit être occupée quand le slot est promis")
+	}
+
+	// Une demande sans réservation ne peut prendre la place promise dans la file
+	release, err := q.Acquire(context.Background(), PriorityHigh, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Acquire(context.Background(), PriorityHigh, nil); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Acquire sans réservation = %v, veut %v", err, ErrQueueFull)
+	}
+
+	// Les tâches réservées passent même si la file paraît pleine
+	acquired := make(chan func(), 1)
+	go func() {
+		rel, err := q.Acquire(r1.WithReservation(context.Background()), PriorityLow, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- rel
+	}()
+	waitFor(t, "tâche réservée en attente", func() bool { return q.Stats().Waiting == 1 })
+	r1.Cancel() // déjà consommée : sans effet
+
+	// r2 rendue (deux fois sans effet de bord) : le slot et la file restent occupés
+	r2.Cancel()
+	r2.Cancel()
+	r3, err := q.Reserve()
+	if err == nil {
+		t.Fatal("réservation acceptée alors que le slot et la file sont occupés")
+	}
+	release()
+	(<-acquired)()
+	if r3, err = q.Reserve(); err != nil {
+		t.Fatalf("réservation après libération : %v", err)
+	}
+	r3.Cancel()
+	if stats := q.Stats(); stats.Running != 0 || stats.Waiting != 0 || q.reserved != 0 {
+		t.Errorf("file non vide à la fin : %+v, %d réservées", stats, q.reserved)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RetryPolicy décrit comment réessayer un appel au fournisseur :
+// backoff exponentiel base*2^(n-1) plafonné à MaxDelay, avec jitter, dans la limite de Budget au total.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64       // part aléatoire du délai, entre 0 (aucune) et 1 (full jitter)
+	Budget      time.Duration // durée totale maximale, tentatives et attentes comprises
+	// AttemptTimeout borne chaque tentative : un fournisseur qui ne répond pas à temps est en défaut
+	// et la tentative suivante (ou le fournisseur de secours) dispose encore du reste du budget
+	AttemptTimeout time.Duration
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d >= 0 {
+		return d
+	}
+	return def
+}
+
+// DefaultRetryPolicy lit la politique depuis RETRY_MAX_ATTEMPTS, RETRY_BASE_DELAY, RETRY_MAX_DELAY,
+// RETRY_JITTER, RETRY_BUDGET et PROVIDER_ATTEMPT_TIMEOUT. Le budget par défaut (25s) reste sous les 30s de Render/Vercel.
+func DefaultRetryPolicy() RetryPolicy {
+	p := RetryPolicy{
+		MaxAttempts:    envInt("RETRY_MAX_ATTEMPTS", 3),
+		BaseDelay:      envDuration("RETRY_BASE_DELAY", 500*time.Millisecond),
+		MaxDelay:       envDuration("RETRY_MAX_DELAY", 8*time.Second),
+		Jitter:         0.5,
+		Budget:         envDuration("RETRY_BUDGET", 25*time.Second),
+		AttemptTimeout: envDuration("PROVIDER_ATTEMPT_TIMEOUT", 12*time.Second),
+	}
+	if j, err := strconv.ParseFloat(os.Getenv("RETRY_JITTER"), 64); err == nil && j >= 0 && j <= 1 {
+		p.Jitter = j
+	}
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	return p
+}
+
+// Backoff retourne l'attente avant la tentative attempt+1 (attempt commence à 1)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if max := float64(p.MaxDelay); p.MaxDelay > 0 && d > max {
+		d = max
+	}
+	// Le jitter retire une part aléatoire du délai pour étaler les reprises simultanées
+	d -= d * p.Jitter * rand.Float64()
+	return time.Duration(d)
+}
+
+// retryableError indique si l'erreur vaut la peine d'être réessayée
+func retryableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		// Quota épuisé : réessayer ne changera rien
+		if apiErr.Type == "insufficient_quota" || apiErr.Code == "insufficient_quota" {
+			return false
+		}
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// providerFault indique si l'erreur révèle une défaillance du fournisseur (5xx, 429 ou erreur réseau),
+// par opposition à une annulation ou à une requête refusée
+func providerFault(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return faultStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return faultStatus(reqErr.HTTPStatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// faultStatus : 429 et 5xx sont des défaillances du fournisseur
+func faultStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryableStatus : 408, 409, 429 et 5xx sont temporaires ; 400, 401, 403, 404, 422... sont définitifs
+func retryableStatus(code int) bool {
+	switch {
+	case code == 0:
+		return true
+	case code == http.StatusRequestTimeout, code == http.StatusConflict, code == http.StatusTooManyRequests:
+		return true
+	case code >= 500:
+		return true
+	}
+	return false
+}
+
+// retryHint retourne le délai demandé par le serveur : Retry-After (secondes ou date HTTP),
+// sinon le plus long des x-ratelimit-reset-requests / x-ratelimit-reset-tokens
+func retryHint(h http.Header) (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+	var hint time.Duration
+	found := false
+	for _, name := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if d, err := time.ParseDuration(h.Get(name)); err == nil {
+			found = true
+			if d > hint {
+				hint = d
+			}
+		}
+	}
+	return hint, found
+}
+
+// headerCapture conserve les en-têtes de la dernière réponse HTTP d'un appel au fournisseur,
+// que le client go-openai n'expose pas en cas d'erreur
+type headerCapture struct {
+	mu     sync.Mutex
+	header http.Header
+}
+
+func (c *headerCapture) set(h http.Header) {
+	c.mu.Lock()
+	c.header = h
+	c.mu.Unlock()
+}
+
+func (c *headerCapture) get() http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.header
+}
+
+const headerCaptureKey contextKey = "header_capture"
+
+// captureTransport enregistre les en-têtes de réponse dans le headerCapture du contexte de la requête
+type captureTransport struct {
+	base http.RoundTripper
+}
+
+func (t captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if c, ok := req.Context().Value(headerCaptureKey).(*headerCapture); ok && resp != nil {
+		c.set(resp.Header)
+	}
+	return resp, err
+}
+
+// providerHTTPClient est le client HTTP partagé des appels aux fournisseurs
+var providerHTTPClient = &http.Client{Transport: captureTransport{base: http.DefaultTransport}}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestProviderFault(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "500", err: &openai.APIError{HTTPStatusCode: 500}, want: true},
+		{name: "503 sans corps JSON", err: &openai.RequestError{HTTPStatusCode: 503}, want: true},
+		{name: "429", err: &openai.APIError{HTTPStatusCode: 429}, want: true},
+		{name: "erreur réseau", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "401", err: &openai.APIError{HTTPStatusCode: 401}},
+		{name: "400", err: &openai.RequestError{HTTPStatusCode: 400}},
+		{name: "annulation", err: fmt.Errorf("post: %w", context.Canceled)},
+	}
+	for _, tt := range tests {
+		if got := providerFault(tt.err); got != tt.want {
+			t.Errorf("%s : providerFault() = %v, veut %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 6: 300 * time.Millisecond} {
+		if got := p.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, veut %v", attempt, got, want)
+		}
+	}
+	p.Jitter = 1
+	for i := 0; i < 20; i++ {
+		if got := p.Backoff(2); got < 0 || got > 200*time.Millisecond {
+			t.Fatalf("Backoff(2) avec jitter = %v, hors de [0, 200ms]", got)
+		}
+	}
+}
+
+// fakeProvider démarre un fournisseur compatible OpenAI qui répond avec status et content
+// et configure le fournisseur principal sur lui (modèle propre au test, donc disjoncteur neuf)
+func fakeProvider(t *testing.T, status int, content string) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, content)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("OPENAI_BASE_URL", srv.URL+"/v1")
+	t.Setenv("OPENAI_API_KEY", "test")
+	t.Setenv("OPENAI_MODEL", "modele-"+t.Name())
+	t.Setenv("FALLBACK_MODEL", "")
+	t.Setenv("RETRY_MAX_ATTEMPTS", "2")
+	t.Setenv("RETRY_BASE_DELAY", "1ms")
+	t.Setenv("RETRY_JITTER", "0")
+	return &calls
+}
+
+func TestFailoverCountsOnlyProviderFaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		content  string
+		cancel   bool
+		failures int
+	}{
+		{name: "panne", status: http.StatusBadGateway, failures: 1},
+		{name: "limite", status: http.StatusTooManyRequests, failures: 1},
+		{name: "clé refusée", status: http.StatusUnauthorized},
+		// Aucune section reconnue : la réponse est refusée par le parseur
+		{name: "réponse inexploitable", status: http.StatusOK, content: "désolé, je ne peux pas"},
+		{name: "annulation", status: http.StatusBadGateway, cancel: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeProvider(t, tt.status, tt.content)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			if _, err := generateWithFailover(ctx, "projet", GenerateOptions{}); err == nil {
+				t.Fatal("generateWithFailover() doit échouer")
+			}
+			b := breakerFor(Providers()[0])
+			if _, failures, _ := b.State(); failures != tt.failures {
+				t.Errorf("échecs comptés = %d, veut %d", failures, tt.failures)
+			}
+		})
+	}
+}
+
+func TestHangingProviderTripsBreaker(t *testing.T) {
+	// Le fournisseur principal ne répond jamais ; le secondaire répond normalement
+	var hung atomic.Int32
+	release := make(chan struct{})
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hung.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(primary.Close)
+	t.Cleanup(func() { close(release) })
+	fakeProvider(t, http.StatusOK, "1. [Problème] x\n2. [Solution] y\n3. [Marché] z\n4. [Valeur] v\n5. [Canaux] c\n6. [Modèle] m")
+	t.Setenv("FALLBACK_BASE_URL", os.Getenv("OPENAI_BASE_URL"))
+	t.Setenv("FALLBACK_MODEL", "secours-"+t.Name())
+	t.Setenv("OPENAI_BASE_URL", primary.URL+"/v1")
+	t.Setenv("RETRY_MAX_ATTEMPTS", "1")
+	t.Setenv("PROVIDER_ATTEMPT_TIMEOUT", "50ms")
+	t.Setenv("BREAKER_THRESHOLD", "2")
+
+	for i := 1; i <= 3; i++ {
+		start := time.Now()
+		if _, err := generateWithFailover(context.Background(), "projet", GenerateOptions{}); err != nil {
+			t.Fatalf("génération %d : %v, veut un basculement sur le secours", i, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Fatalf("génération %d : %v, la tentative bloquée doit être interrompue", i, elapsed)
+		}
+	}
+	if state, _, _ := breakerFor(Providers()[0]).State(); state != BreakerOpen {
+		t.Errorf("disjoncteur du principal : %v, veut ouvert", state)
+	}
+	// Le disjoncteur ouvert après deux délais dépassés épargne la troisième tentative
+	if got := hung.Load(); got != 2 {
+		t.Errorf("%d appels au fournisseur bloqué, veut 2", got)
+	}
+}
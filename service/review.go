@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"pitch/models"
+	"pitch/render"
+	"pitch/store"
+)
+
+// Rôles dans le workflow de revue : l'auteur (propriétaire du pitch) et le mentor (lien de partage « revue »)
+const (
+	ReviewRoleAuthor   = "author"
+	ReviewRoleReviewer = "reviewer"
+)
+
+// Erreurs du workflow de revue et des commentaires
+var (
+	ErrReviewTransition = errors.New("ce changement de statut n'est pas possible")
+	ErrUnknownSection   = errors.New("section inconnue")
+	ErrParentComment    = errors.New("le commentaire auquel vous répondez n'existe pas")
+	ErrReviewScore      = errors.New("la note doit être comprise entre 1 et 10 et accompagner une approbation ou une demande de modifications")
+)
+
+// reviewTransitions liste, pour chaque statut, les statuts suivants possibles et le rôle qui peut les choisir
+var reviewTransitions = map[string]map[string]string{
+	models.ReviewDraft:            {models.ReviewSubmitted: ReviewRoleAuthor},
+	models.ReviewSubmitted:        {models.ReviewDraft: ReviewRoleAuthor, models.ReviewInReview: ReviewRoleReviewer},
+	models.ReviewInReview:         {models.ReviewApproved: ReviewRoleReviewer, models.ReviewChangesRequested: ReviewRoleReviewer},
+	models.ReviewChangesRequested: {models.ReviewSubmitted: ReviewRoleAuthor},
+	models.ReviewApproved:         {models.ReviewInReview: ReviewRoleReviewer},
+}
+
+// reviewOrder fixe l'ordre d'affichage des actions de revue
+var reviewOrder = []string{models.ReviewDraft, models.ReviewSubmitted, models.ReviewInReview, models.ReviewChangesRequested, models.ReviewApproved}
+
+// ReviewActor est l'auteur d'un commentaire ou d'un changement de statut
+type ReviewActor struct {
+	Role    string
+	Name    string
+	UserID  string // propriétaire connecté
+	ShareID string // mentor venu par un lien de partage
+}
+
+// AuthorActor retourne l'acteur correspondant à l'utilisateur connecté
+func AuthorActor(u *models.User) ReviewActor {
+	name := u.Name
+	if name == "" {
+		name = u.Email
+	}
+	return ReviewActor{Role: ReviewRoleAuthor, Name: name, UserID: u.ID}
+}
+
+// ShareActor retourne l'acteur correspondant à un visiteur venu par un lien de partage
+func ShareActor(s *models.ShareLink, name string) ReviewActor {
+	role := ""
+	if s.CanReview() {
+		role = ReviewRoleReviewer
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = s.Label
+	}
+	if name == "" {
+		name = "Invité"
+	}
+	return ReviewActor{Role: role, Name: name, ShareID: s.ID}
+}
+
+// ReviewActions retourne les statuts que le rôle peut choisir depuis le statut courant du pitch
+func ReviewActions(p *models.Pitch, role string) []models.ReviewAction {
+	var actions []models.ReviewAction
+	next := reviewTransitions[p.Review()]
+	for _, status := range reviewOrder {
+		if next[status] == role && role != "" {
+			actions = append(actions, models.ReviewAction{Status: status, Label: reviewActionLabels[status]})
+		}
+	}
+	return actions
+}
+
+// reviewActionLabels sont les libellés des boutons menant à chaque statut
+var reviewActionLabels = map[string]string{
+	models.ReviewDraft:            "Retirer la demande de revue",
+	models.ReviewSubmitted:        "Soumettre pour revue",
+	models.ReviewInReview:         "Commencer la revue",
+	models.ReviewApproved:         "Approuver",
+	models.ReviewChangesRequested: "Demander des modifications",
+}
+
+// ChangeReviewStatus fait passer le pitch au statut to si l'acteur en a le droit, l'inscrit dans l'historique
+// et notifie l'auteur quand le changement vient d'un mentor. score (0 = non noté) note le verdict du mentor.
+func ChangeReviewStatus(pitchID string, actor ReviewActor, to, note string, score int, mailer Mailer) (*models.Pitch, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxCommentLength {
+		return nil, errors.New("le message est trop long (2000 caractères maximum)")
+	}
+	if score != 0 && (!models.ScoredReview(to) || score < models.MinReviewScore || score > models.MaxReviewScore) {
+		return nil, ErrReviewScore
+	}
+
+	var event models.ReviewEvent
+	p, err := pitchStore.Update(pitchID, func(p *models.Pitch) error {
+		from := p.Review()
+		if role, ok := reviewTransitions[from][to]; !ok || role != actor.Role {
+			return ErrReviewTransition
+		}
+		if actor.Role == ReviewRoleAuthor && actor.UserID == "" {
+			// Seul un utilisateur connecté reconnu comme auteur (PitchActor) agit au nom des auteurs
+			return ErrReviewTransition
+		}
+		event = models.ReviewEvent{From: from, To: to, Actor: actor.Name, Role: actor.Role, Note: note, Score: score, At: time.Now().UTC()}
+		p.ReviewStatus = to
+		p.ReviewHistory = append(p.ReviewHistory, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Role != ReviewRoleAuthor && p.OwnerID != "" {
+		notifyReviewChange(&p, event, mailer)
+	}
+	return &p, nil
+}
+
+// notifyReviewChange prévient l'auteur du pitch d'un changement de statut : notification dans l'application et email
+// (l'email n'est envoyé que si BASE_URL est configurée)
+func notifyReviewChange(p *models.Pitch, e models.ReviewEvent, mailer Mailer) {
+	link := "/pitches/" + p.ID
+	message := fmt.Sprintf("%s a passé votre pitch au statut « %s ».", e.Actor, e.ToLabel())
+	if err := Notify(p.OwnerID, p.ID, message, link); err != nil {
+		log.Printf("revue: notification impossible pour le pitch %s: %v", p.ID, err)
+	}
+
+	u, ok := GetUser(p.OwnerID)
+	if !ok || u.Email == "" {
+		return
+	}
+	baseURL, err := PublicBaseURL()
+	if err != nil {
+		log.Printf("revue: email non envoyé pour le pitch %s: %v", p.ID, err)
+		return
+	}
+	body := "Bonjour,\n\n" + message + "\n"
+	if e.Score > 0 {
+		body += fmt.Sprintf("Note : %d/10\n", e.Score)
+	}
+	if e.Note != "" {
+		body += "\nMessage :\n" + e.Note + "\n"
+	}
+	body += "\nVoir le pitch : " + baseURL + link
+	if err := mailer.Send(Mail{To: u.Email, Subject: "Revue de votre pitch : " + e.ToLabel(), Body: body}); err != nil {
+		log.Printf("revue: email impossible pour le pitch %s: %v", p.ID, err)
+	}
+}
+
+// validSection indique si key est une section à laquelle un commentaire peut être rattaché (vide = général)
+func validSection(key string) bool {
+	if key == "" {
+		return true
+	}
+	for _, s := range render.Sections(nil) {
+		if s.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AddComment ajoute un commentaire de l'acteur sur une section du pitch, éventuellement en réponse à parentID.
+// Une réponse est rattachée au premier commentaire du fil et à sa section.
+func AddComment(pitchID string, actor ReviewActor, section, parentID, body string) (*models.Comment, error) {
+	body = strings.TrimSpace(body)
+	if utf8.RuneCountInString(actor.Name) > MaxAuthorLength {
+		return nil, ErrAuthorTooLong
+	}
+	if body == "" {
+		return nil, errors.New("le commentaire est vide")
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return nil, errors.New("le commentaire est trop long (2000 caractères maximum)")
+	}
+	if !validSection(section) {
+		return nil, ErrUnknownSection
+	}
+	if parentID != "" {
+		parent, ok := commentStore.Get(parentID)
+		if !ok || parent.PitchID != pitchID {
+			return nil, ErrParentComment
+		}
+		if parent.ParentID != "" {
+			parentID = parent.ParentID
+		}
+		section = parent.Section
+	}
+
+	c := models.Comment{
+		ID:        store.NewID(),
+		PitchID:   pitchID,
+		Section:   section,
+		ParentID:  parentID,
+		ShareID:   actor.ShareID,
+		UserID:    actor.UserID,
+		Author:    actor.Name,
+		Body:      body,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := commentStore.Put(c.ID, c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// AddUserComment ajoute un commentaire de l'utilisateur connecté sur un pitch dont il est auteur ou mentor
+func AddUserComment(ctx context.Context, pitchID, section, parentID, body string) (*models.Comment, error) {
+	if UserFromContext(ctx) == nil {
+		return nil, ErrAccountRequired
+	}
+	p, err := GetPitch(ctx, pitchID)
+	if err != nil {
+		return nil, err
+	}
+	actor, ok := PitchActor(ctx, p)
+	if !ok {
+		return nil, ErrOrgForbidden
+	}
+	return AddComment(p.ID, actor, section, parentID, body)
+}
+
+// CommentThreads retourne les sections du pitch (puis les commentaires généraux) avec leurs fils de discussion
+func CommentThreads(p *models.Pitch) []models.SectionThreads {
+	comments := ListComments(p.ID)
+	replies := map[string][]models.Comment{}
+	for _, c := range comments {
+		if c.ParentID != "" {
+			replies[c.ParentID] = append(replies[c.ParentID], c)
+		}
+	}
+
+	sections := append(render.Sections(p.Sections), render.Section{Key: "", Title: "Général"})
+	out := make([]models.SectionThreads, len(sections))
+	index := map[string]int{}
+	for i, s := range sections {
+		out[i] = models.SectionThreads{Key: s.Key, Title: s.Title, Content: s.Content}
+		index[s.Key] = i
+	}
+	for _, c := range comments {
+		if c.ParentID != "" {
+			continue
+		}
+		i, ok := index[c.Section]
+		if !ok {
+			i = len(out) - 1
+		}
+		out[i].Threads = append(out[i].Threads, models.CommentThread{Comment: c, Replies: replies[c.ID]})
+	}
+	return out
+}
+
+var notificationStore = store.New[models.Notification]("notifications")
+
+// Notify enregistre une notification pour l'utilisateur
+func Notify(userID, pitchID, message, link string) error {
+	n := models.Notification{
+		ID:        store.NewID(),
+		UserID:    userID,
+		PitchID:   pitchID,
+		Message:   message,
+		Link:      link,
+		CreatedAt: time.Now().UTC(),
+	}
+	return notificationStore.Put(n.ID, n)
+}
+
+// ListNotifications retourne les notifications de l'utilisateur connecté, des plus récentes aux plus anciennes
+func ListNotifications(ctx context.Context) []models.Notification {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return nil
+	}
+	list := notificationStore.Find(func(n models.Notification) bool { return n.UserID == u.ID })
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// UnreadNotifications retourne le nombre de notifications non lues de l'utilisateur connecté
+func UnreadNotifications(ctx context.Context) int {
+	n := 0
+	for _, notif := range ListNotifications(ctx) {
+		if notif.ReadAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// MarkNotificationsRead marque comme lues toutes les notifications de l'utilisateur connecté
+func MarkNotificationsRead(ctx context.Context) {
+	now := time.Now().UTC()
+	for _, n := range ListNotifications(ctx) {
+		if n.ReadAt == nil {
+			notificationStore.Update(n.ID, func(n *models.Notification) error {
+				n.ReadAt = &now
+				return nil
+			})
+		}
+	}
+}
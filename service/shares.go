@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// Erreurs des liens de partage
+var (
+	ErrShareInvalid      = errors.New("lien de partage invalide")
+	ErrShareExpired      = errors.New("ce lien de partage a expiré ou a été révoqué")
+	ErrSharePassword     = errors.New("mot de passe incorrect")
+	ErrShareReadOnly     = errors.New("ce lien ne permet pas de commenter")
+	ErrInvalidPermission = errors.New("permission de partage inconnue")
+	ErrAuthorTooLong     = errors.New("le nom est trop long")
+)
+
+// Limites des commentaires
+const (
+	MaxCommentLength = 2000
+	MaxAuthorLength  = 80
+)
+
+// shareTokenPrefix préfixe les jetons de partage : sh_<id>_<secret>
+const shareTokenPrefix = "sh_"
+
+var (
+	shareStore   = store.New[models.ShareLink]("shares")
+	commentStore = store.New[models.Comment]("comments")
+)
+
+// CreateShareLink crée un lien de partage du pitch et retourne son jeton en clair (affiché une seule fois).
+// password vide = pas de mot de passe ; expiresAt nil = pas d'expiration.
+func CreateShareLink(ctx context.Context, pitchID, label, permission, password string, expiresAt *time.Time) (string, *models.ShareLink, error) {
+	p, err := authoredPitch(ctx, pitchID)
+	if err != nil {
+		return "", nil, err
+	}
+	switch permission {
+	case models.SharePermissionRead, models.SharePermissionComment, models.SharePermissionReview, models.SharePermissionEdit:
+	default:
+		return "", nil, ErrInvalidPermission
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return "", nil, errors.New("la date d'expiration doit être dans le futur")
+	}
+
+	id := store.NewID()
+	raw := shareTokenPrefix + id + "_" + store.RandomToken(24)
+	s := models.ShareLink{
+		ID:         id,
+		PitchID:    p.ID,
+		OwnerID:    p.OwnerID,
+		Label:      strings.TrimSpace(label),
+		Hash:       hashToken(raw),
+		Permission: permission,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if password != "" {
+		if s.PasswordHash, err = HashPassword(password); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := shareStore.Put(s.ID, s); err != nil {
+		return "", nil, err
+	}
+	return raw, &s, nil
+}
+
+// ListShareLinks retourne les liens de partage du pitch, des plus récents aux plus anciens
+func ListShareLinks(ctx context.Context, pitchID string) ([]models.ShareLink, error) {
+	if _, err := authoredPitch(ctx, pitchID); err != nil {
+		return nil, err
+	}
+	links := shareStore.Find(func(s models.ShareLink) bool { return s.PitchID == pitchID })
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+	return links, nil
+}
+
+// RevokeShareLink révoque le lien id du pitch ; il ne peut plus être ouvert
+func RevokeShareLink(ctx context.Context, pitchID, id string) error {
+	if _, err := authoredPitch(ctx, pitchID); err != nil {
+		return err
+	}
+	_, err := shareStore.Update(id, func(s *models.ShareLink) error {
+		if s.PitchID != pitchID {
+			return store.ErrNotFound
+		}
+		if s.RevokedAt == nil {
+			now := time.Now().UTC()
+			s.RevokedAt = &now
+		}
+		return nil
+	})
+	return err
+}
+
+// OpenShareLink retrouve le lien de partage d'un jeton en clair et vérifie qu'il est encore actif
+func OpenShareLink(raw string) (*models.ShareLink, error) {
+	rest, ok := strings.CutPrefix(raw, shareTokenPrefix)
+	if !ok {
+		return nil, ErrShareInvalid
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, ErrShareInvalid
+	}
+	s, ok := shareStore.Get(id)
+	if !ok || subtle.ConstantTimeCompare([]byte(s.Hash), []byte(hashToken(raw))) != 1 {
+		return nil, ErrShareInvalid
+	}
+	if !s.Active() {
+		return nil, ErrShareExpired
+	}
+	return &s, nil
+}
+
+// CheckSharePassword vérifie le mot de passe du lien et retourne la valeur du cookie de déverrouillage
+func CheckSharePassword(s *models.ShareLink, password string) (string, error) {
+	if s.PasswordHash == "" || CheckPassword(s.PasswordHash, password) {
+		return ShareUnlockValue(s), nil
+	}
+	return "", ErrSharePassword
+}
+
+// ShareUnlockValue est la preuve, conservée en cookie, que le mot de passe du lien a été saisi.
+// Elle dérive de l'empreinte du mot de passe (et de son sel) : changer ou retirer le mot de passe l'invalide.
+func ShareUnlockValue(s *models.ShareLink) string {
+	return hashToken(s.Hash + ":" + s.PasswordHash)
+}
+
+// ShareUnlocked indique si le lien est accessible avec la valeur de cookie fournie
+func ShareUnlocked(s *models.ShareLink, cookie string) bool {
+	return s.PasswordHash == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(ShareUnlockValue(s))) == 1
+}
+
+// ViewSharedPitch comptabilise une consultation du lien et retourne le pitch partagé
+func ViewSharedPitch(s *models.ShareLink) (*models.Pitch, error) {
+	p, ok := pitchStore.Get(s.PitchID)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	shareStore.Update(s.ID, func(s *models.ShareLink) error {
+		now := time.Now().UTC()
+		s.Views++
+		s.LastViewedAt = &now
+		return nil
+	})
+	return &p, nil
+}
+
+// AddShareComment ajoute un commentaire au pitch partagé si le lien le permet
+func AddShareComment(s *models.ShareLink, author, section, parentID, body string) (*models.Comment, error) {
+	if !s.CanComment() {
+		return nil, ErrShareReadOnly
+	}
+	return AddComment(s.PitchID, ShareActor(s, author), section, parentID, body)
+}
+
+// ListComments retourne les commentaires du pitch, du plus ancien au plus récent
+func ListComments(pitchID string) []models.Comment {
+	comments := commentStore.Find(func(c models.Comment) bool { return c.PitchID == pitchID })
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// newSharedPitch enregistre un pitch de ownerID et retourne le contexte de son auteur
+func newSharedPitch(t *testing.T, ownerID string) (*models.Pitch, context.Context) {
+	t.Helper()
+	p := models.Pitch{ID: store.NewID(), OwnerID: ownerID, Description: "Une application de covoiturage rural", CreatedAt: time.Now().UTC()}
+	if err := pitchStore.Put(p.ID, p); err != nil {
+		t.Fatal(err)
+	}
+	return &p, WithUser(context.Background(), &models.User{ID: ownerID, Name: ownerID})
+}
+
+func TestOpenShareLink(t *testing.T) {
+	p, ctx := newSharedPitch(t, "owner-share")
+	raw, link, err := CreateShareLink(ctx, p.ID, "Mentor", models.SharePermissionComment, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenShareLink(raw); err != nil || got.ID != link.ID {
+		t.Fatalf("OpenShareLink(jeton valide) = %v, %v", got, err)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		err  error
+	}{
+		{name: "secret modifié", raw: raw[:len(raw)-1] + "x", err: ErrShareInvalid},
+		{name: "sans préfixe", raw: raw[len(shareTokenPrefix):], err: ErrShareInvalid},
+		{name: "sans secret", raw: shareTokenPrefix + link.ID, err: ErrShareInvalid},
+		{name: "identifiant inconnu", raw: shareTokenPrefix + "inconnu_secret", err: ErrShareInvalid},
+		{name: "vide", raw: "", err: ErrShareInvalid},
+	}
+	for _, tt := range tests {
+		if _, err := OpenShareLink(tt.raw); !errors.Is(err, tt.err) {
+			t.Errorf("%s : OpenShareLink() = %v, veut %v", tt.name, err, tt.err)
+		}
+	}
+
+	if err := RevokeShareLink(ctx, p.ID, link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenShareLink(raw); !errors.Is(err, ErrShareExpired) {
+		t.Errorf("lien révoqué : OpenShareLink() = %v, veut %v", err, ErrShareExpired)
+	}
+}
+
+func TestShareLinkExpiry(t *testing.T) {
+	p, ctx := newSharedPitch(t, "owner-expiry")
+	past := time.Now().Add(-time.Minute)
+	if _, _, err := CreateShareLink(ctx, p.ID, "", models.SharePermissionRead, "", &past); err == nil {
+		t.Error("CreateShareLink accepte une date d'expiration passée")
+	}
+
+	tests := []struct {
+		name    string
+		expires time.Duration // décalage de l'expiration après création (0 = sans expiration)
+		err     error
+	}{
+		{name: "sans expiration"},
+		{name: "expire plus tard", expires: time.Hour},
+		{name: "expiré", expires: -time.Second, err: ErrShareExpired},
+	}
+	for _, tt := range tests {
+		future := time.Now().Add(time.Hour)
+		raw, link, err := CreateShareLink(ctx, p.ID, tt.name, models.SharePermissionRead, "", &future)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shareStore.Update(link.ID, func(s *models.ShareLink) error {
+			s.ExpiresAt = nil
+			if tt.expires != 0 {
+				at := time.Now().Add(tt.expires)
+				s.ExpiresAt = &at
+			}
+			return nil
+		})
+		if _, err := OpenShareLink(raw); !errors.Is(err, tt.err) {
+			t.Errorf("%s : OpenShareLink() = %v, veut %v", tt.name, err, tt.err)
+		}
+	}
+
+	// Seul l'auteur du pitch crée des liens
+	other := WithUser(context.Background(), &models.User{ID: "intrus-share"})
+	if _, _, err := CreateShareLink(other, p.ID, "", models.SharePermissionRead, "", nil); err == nil {
+		t.Error("CreateShareLink accepté pour un autre utilisateur")
+	}
+	if _, _, err := CreateShareLink(ctx, p.ID, "", "admin", "", nil); !errors.Is(err, ErrInvalidPermission) {
+		t.Errorf("permission inconnue : %v, veut %v", err, ErrInvalidPermission)
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// ErrNotAuthor est retourné quand l'action est réservée aux auteurs du pitch
+var ErrNotAuthor = errors.New("seuls les auteurs du pitch (son propriétaire ou les fondateurs de son organisation) peuvent effectuer cette action")
+
+// tenant est le périmètre de données de l'appelant : ses pitchs personnels, ceux de sa clé API
+// et ceux des organisations dont il est membre
+type tenant struct {
+	userID   string
+	apiKeyID string
+	roles    map[string]string // rôle dans chaque organisation
+}
+
+// tenantOf retourne le périmètre de l'utilisateur ou de la clé API du contexte
+func tenantOf(ctx context.Context) tenant {
+	var t tenant
+	if u := UserFromContext(ctx); u != nil {
+		t.userID = u.ID
+		t.roles = memberRoles(u.ID)
+	}
+	if k := APIKeyFromContext(ctx); k != nil {
+		t.apiKeyID = k.ID
+	}
+	return t
+}
+
+// pitchRole retourne le rôle de l'appelant sur le pitch : son rôle dans l'organisation du pitch,
+// owner pour le propriétaire d'un pitch personnel, vide sinon
+func (t tenant) pitchRole(p models.Pitch) string {
+	if p.OrgID != "" {
+		return t.roles[p.OrgID]
+	}
+	if t.userID != "" && p.OwnerID == t.userID {
+		return models.OrgRoleOwner
+	}
+	return ""
+}
+
+// canRead indique si le pitch est dans le périmètre de l'appelant.
+// Un pitch d'organisation n'est visible que de ses membres, quel que soit son créateur ;
+// un pitch anonyme reste accessible à toute personne connaissant son identifiant.
+func (t tenant) canRead(p models.Pitch) bool {
+	switch {
+	case p.OrgID != "":
+		return t.roles[p.OrgID] != ""
+	case p.OwnerID != "":
+		return t.userID != "" && t.userID == p.OwnerID
+	case p.APIKeyID != "":
+		return t.apiKeyID != "" && t.apiKeyID == p.APIKeyID
+	}
+	return true
+}
+
+// canWrite indique si l'appelant peut modifier ou supprimer le pitch.
+// Un pitch anonyme n'a pas d'auteur identifiable : personne ne peut le modifier ni le supprimer.
+func (t tenant) canWrite(p models.Pitch) bool {
+	switch {
+	case p.OrgID != "":
+		return canAuthor(t.roles[p.OrgID])
+	case p.OwnerID == "" && p.APIKeyID == "":
+		return false
+	}
+	return t.canRead(p)
+}
+
+// canAuthor indique si le rôle permet de créer et de modifier des pitchs
+func canAuthor(role string) bool {
+	return role == models.OrgRoleOwner || role == models.OrgRoleFounder
+}
+
+// readablePitches retourne les pitchs visibles de l'appelant ; toute lecture de pitch pour le compte
+// d'un utilisateur ou d'une clé API passe par ce périmètre
+func readablePitches(ctx context.Context) store.Scope[models.Pitch] {
+	return pitchStore.Scope(tenantOf(ctx).canRead)
+}
+
+// writablePitches retourne les pitchs que l'appelant peut modifier ou supprimer
+func writablePitches(ctx context.Context) store.Scope[models.Pitch] {
+	return pitchStore.Scope(tenantOf(ctx).canWrite)
+}
+
+// PitchRole retourne le rôle de l'utilisateur connecté sur le pitch (owner, founder, mentor, viewer), vide s'il n'en a pas
+func PitchRole(ctx context.Context, p *models.Pitch) string {
+	return tenantOf(ctx).pitchRole(*p)
+}
+
+// PitchActor retourne l'acteur de revue de l'utilisateur connecté sur le pitch : auteur (propriétaire, fondateur)
+// ou mentor ; ok est faux s'il ne peut ni commenter ni faire avancer la revue
+func PitchActor(ctx context.Context, p *models.Pitch) (actor ReviewActor, ok bool) {
+	u := UserFromContext(ctx)
+	if u == nil {
+		return ReviewActor{}, false
+	}
+	actor = AuthorActor(u)
+	switch role := PitchRole(ctx, p); {
+	case canAuthor(role):
+		return actor, true
+	case role == models.OrgRoleMentor:
+		actor.Role = ReviewRoleReviewer
+		return actor, true
+	}
+	return ReviewActor{}, false
+}
+
+// authoredPitch retourne le pitch id si l'utilisateur connecté en est auteur
+func authoredPitch(ctx context.Context, id string) (*models.Pitch, error) {
+	if UserFromContext(ctx) == nil {
+		return nil, ErrAccountRequired
+	}
+	p, err := GetPitch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canAuthor(PitchRole(ctx, p)) {
+		return nil, ErrNotAuthor
+	}
+	return p, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+func TestTenantAccess(t *testing.T) {
+	ana := tenant{userID: "ana", roles: map[string]string{
+		"o-owner":   models.OrgRoleOwner,
+		"o-founder": models.OrgRoleFounder,
+		"o-mentor":  models.OrgRoleMentor,
+		"o-viewer":  models.OrgRoleViewer,
+	}}
+	key := tenant{apiKeyID: "k1"}
+	anonymous := tenant{}
+
+	tests := []struct {
+		name      string
+		t         tenant
+		p         models.Pitch
+		read      bool
+		write     bool
+		wantRole  string
+		checkRole bool
+	}{
+		{name: "pitch personnel", t: ana, p: models.Pitch{OwnerID: "ana"}, read: true, write: true, wantRole: models.OrgRoleOwner, checkRole: true},
+		{name: "pitch personnel d'un autre", t: ana, p: models.Pitch{OwnerID: "ben"}},
+		{name: "organisation, propriétaire", t: ana, p: models.Pitch{OwnerID: "ben", OrgID: "o-owner"}, read: true, write: true},
+		{name: "organisation, fondateur", t: ana, p: models.Pitch{OwnerID: "ben", OrgID: "o-founder"}, read: true, write: true},
+		{name: "organisation, mentor", t: ana, p: models.Pitch{OwnerID: "ben", OrgID: "o-mentor"}, read: true, wantRole: models.OrgRoleMentor, checkRole: true},
+		{name: "organisation, lecteur", t: ana, p: models.Pitch{OrgID: "o-viewer"}, read: true},
+		{name: "organisation dont on n'est pas membre", t: ana, p: models.Pitch{OwnerID: "ana", OrgID: "o-autre"}, wantRole: "", checkRole: true},
+		{name: "pitch de la clé API", t: key, p: models.Pitch{APIKeyID: "k1"}, read: true, write: true},
+		{name: "pitch d'une autre clé API", t: key, p: models.Pitch{APIKeyID: "k2"}},
+		{name: "clé API et pitch d'utilisateur", t: key, p: models.Pitch{OwnerID: "ana"}},
+		{name: "anonyme et pitch anonyme", t: anonymous, p: models.Pitch{}, read: true},
+		{name: "clé API et pitch anonyme", t: key, p: models.Pitch{}, read: true},
+		{name: "utilisateur et pitch anonyme", t: ana, p: models.Pitch{}, read: true},
+		{name: "anonyme et pitch d'organisation", t: anonymous, p: models.Pitch{OrgID: "o-owner"}},
+		{name: "anonyme et pitch personnel", t: anonymous, p: models.Pitch{OwnerID: "ana"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.canRead(tt.p); got != tt.read {
+				t.Errorf("canRead = %v, veut %v", got, tt.read)
+			}
+			if got := tt.t.canWrite(tt.p); got != tt.write {
+				t.Errorf("canWrite = %v, veut %v", got, tt.write)
+			}
+			if got := tt.t.pitchRole(tt.p); tt.checkRole && got != tt.wantRole {
+				t.Errorf("pitchRole = %q, veut %q", got, tt.wantRole)
+			}
+		})
+	}
+}
+
+// addMember inscrit l'utilisateur dans l'organisation avec le rôle donné
+func addMember(t *testing.T, orgID, userID, role string) {
+	t.Helper()
+	if err := memberStore.Put(membershipID(orgID, userID), models.Membership{ID: membershipID(orgID, userID), OrgID: orgID, UserID: userID, Role: role}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchExportFollowsMembership(t *testing.T) {
+	addMember(t, "org-batch", "ben-batch", models.OrgRoleFounder)
+	p := models.Pitch{ID: "pitch-batch", OwnerID: "ben-batch", OrgID: "org-batch", Description: "d", Sections: &models.PitchResponse{Probleme: "contenu confidentiel"}}
+	if err := pitchStore.Put(p.ID, p); err != nil {
+		t.Fatal(err)
+	}
+	b := &models.Batch{ID: "lot", Rows: []models.BatchRow{{Line: 1, Description: "d", Status: models.JobSucceeded, PitchID: p.ID}}}
+	ctx := WithUser(context.Background(), &models.User{ID: "ben-batch"})
+
+	var out bytes.Buffer
+	WriteBatchCSV(ctx, &out, b)
+	if !strings.Contains(out.String(), "contenu confidentiel") {
+		t.Fatalf("le membre doit retrouver son pitch dans l'export :\n%s", out.String())
+	}
+
+	memberStore.Delete(membershipID("org-batch", "ben-batch"))
+	out.Reset()
+	WriteBatchCSV(ctx, &out, b)
+	if strings.Contains(out.String(), "contenu confidentiel") {
+		t.Fatalf("un ancien membre ne doit plus exporter le pitch de l'organisation :\n%s", out.String())
+	}
+	out.Reset()
+	WriteBatchZIP(ctx, &out, b)
+	if bytes.Contains(out.Bytes(), []byte("pitchs/001")) {
+		t.Fatal("l'archive ne doit plus contenir le pitch de l'organisation")
+	}
+}
+
+func TestCollabRevokedOnDemotion(t *testing.T) {
+	addMember(t, "org-collab", "cam-collab", models.OrgRoleFounder)
+	p := models.Pitch{ID: "pitch-collab", OrgID: "org-collab", Description: "d", Sections: &models.PitchResponse{}}
+	if err := pitchStore.Put(p.ID, p); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithUser(context.Background(), &models.User{ID: "cam-collab", Name: "Cam"})
+
+	c, err := JoinPitchCollab(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Leave()
+	if !c.allowed() {
+		t.Fatal("un fondateur doit pouvoir modifier le pitch")
+	}
+
+	addMember(t, "org-collab", "cam-collab", models.OrgRoleViewer)
+	if c.allowed() {
+		t.Error("un fondateur rétrogradé en cours de session ne doit plus pouvoir modifier le pitch")
+	}
+	memberStore.Delete(membershipID("org-collab", "cam-collab"))
+	if c.allowed() {
+		t.Error("un membre retiré en cours de session ne doit plus pouvoir modifier le pitch")
+	}
+}
+
+func TestAnonymousPitchNotDeletable(t *testing.T) {
+	p := models.Pitch{ID: store.NewID(), Description: "Un pitch généré sans compte"}
+	if err := pitchStore.Put(p.ID, p); err != nil {
+		t.Fatal(err)
+	}
+	callers := map[string]context.Context{
+		"clé API":     WithAPIKey(context.Background(), &models.APIKey{ID: "k-suppression"}),
+		"utilisateur": WithUser(context.Background(), &models.User{ID: "u-suppression"}),
+		"anonyme":     context.Background(),
+	}
+	for name, ctx := range callers {
+		if err := DeletePitch(ctx, p.ID); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("%s : DeletePitch(pitch anonyme) = %v, veut %v", name, err, store.ErrNotFound)
+		}
+	}
+	if _, ok := pitchStore.Get(p.ID); !ok {
+		t.Error("pitch anonyme supprimé")
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// ErrBudgetExceeded est retourné quand le budget mensuel (global, utilisateur ou clé API) est épuisé
+var ErrBudgetExceeded = errors.New("budget mensuel de génération atteint")
+
+// ModelPrice est le prix d'un modèle en dollars pour 1000 tokens
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// defaultPrices est la table de prix utilisée si OPENAI_PRICES n'est pas défini
+var defaultPrices = map[string]ModelPrice{
+	"gpt-3.5-turbo": {Prompt: 0.0005, Completion: 0.0015},
+	"gpt-4o-mini":   {Prompt: 0.00015, Completion: 0.0006},
+	"gpt-4o":        {Prompt: 0.0025, Completion: 0.01},
+	"gpt-4-turbo":   {Prompt: 0.01, Completion: 0.03},
+
+	"text-embedding-3-small": {Prompt: 0.00002},
+}
+
+var usageStore = store.New[models.UsageRecord]("usage")
+
+// PriceTable retourne la table de prix : OPENAI_PRICES (JSON {"modèle":{"prompt":x,"completion":y}}) complète les valeurs par défaut
+func PriceTable() map[string]ModelPrice {
+	prices := make(map[string]ModelPrice, len(defaultPrices))
+	for m, p := range defaultPrices {
+		prices[m] = p
+	}
+	if raw := os.Getenv("OPENAI_PRICES"); raw != "" {
+		var custom map[string]ModelPrice
+		if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+			log.Printf("usage: OPENAI_PRICES invalide: %v", err)
+		}
+		for m, p := range custom {
+			prices[m] = p
+		}
+	}
+	return prices
+}
+
+// EstimateCost calcule le coût estimé en dollars d'une consommation de tokens
+func EstimateCost(model string, u TokenUsage) float64 {
+	p := PriceTable()[model]
+	return float64(u.PromptTokens)/1000*p.Prompt + float64(u.CompletionTokens)/1000*p.Completion
+}
+
+// envBudget lit un budget en dollars (0 = pas de limite)
+func envBudget(name string) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
+
+// recordUsage enregistre la consommation d'une génération pour l'appelant du contexte
+func recordUsage(ctx context.Context, model string, u TokenUsage, success bool) {
+	if u.PromptTokens == 0 && u.CompletionTokens == 0 {
+		return
+	}
+	rec := models.UsageRecord{
+		ID:               store.NewID(),
+		Model:            model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CostUSD:          EstimateCost(model, u),
+		Success:          success,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if usr := UserFromContext(ctx); usr != nil {
+		rec.UserID = usr.ID
+	}
+	if k := APIKeyFromContext(ctx); k != nil {
+		rec.APIKeyID = k.ID
+	}
+	if err := usageStore.Put(rec.ID, rec); err != nil {
+		log.Printf("usage: enregistrement impossible: %v", err)
+	}
+}
+
+// monthStart retourne le début du mois (UTC) de t
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthlyCost retourne le coût du mois en cours pour les enregistrements sélectionnés par match
+func monthlyCost(match func(models.UsageRecord) bool) float64 {
+	since := monthStart(time.Now())
+	total := 0.0
+	for _, r := range usageStore.Find(func(r models.UsageRecord) bool {
+		return !r.CreatedAt.Before(since) && match(r)
+	}) {
+		total += r.CostUSD
+	}
+	return total
+}
+
+// CheckBudget vérifie les budgets mensuels : MONTHLY_BUDGET_USD (global),
+// USER_MONTHLY_BUDGET_USD (par utilisateur) et API_KEY_MONTHLY_BUDGET_USD (par clé API)
+func CheckBudget(ctx context.Context) error {
+	if b := envBudget("MONTHLY_BUDGET_USD"); b > 0 && monthlyCost(func(models.UsageRecord) bool { return true }) >= b {
+		return ErrBudgetExceeded
+	}
+	if u := UserFromContext(ctx); u != nil {
+		if b := envBudget("USER_MONTHLY_BUDGET_USD"); b > 0 && monthlyCost(func(r models.UsageRecord) bool { return r.UserID == u.ID }) >= b {
+			return ErrBudgetExceeded
+		}
+	}
+	if k := APIKeyFromContext(ctx); k != nil {
+		if b := envBudget("API_KEY_MONTHLY_BUDGET_USD"); b > 0 && monthlyCost(func(r models.UsageRecord) bool { return r.APIKeyID == k.ID }) >= b {
+			return ErrBudgetExceeded
+		}
+	}
+	return nil
+}
+
+// AggregateUsage regroupe les consommations depuis since par "day", "user" ou "api_key",
+// triées par clé décroissante pour les jours et par coût décroissant sinon
+func AggregateUsage(since time.Time, groupBy string) []models.UsageSummary {
+	groups := map[string]*models.UsageSummary{}
+	for _, r := range usageStore.Find(func(r models.UsageRecord) bool { return !r.CreatedAt.Before(since) }) {
+		var key, label string
+		switch groupBy {
+		case "user":
+			key, label = r.UserID, "anonyme"
+			if u, ok := GetUser(r.UserID); ok {
+				label = u.Email
+				if label == "" {
+					label = u.Name
+				}
+			}
+		case "api_key":
+			key, label = r.APIKeyID, "interface web"
+			if k, ok := apiKeyStore.Get(r.APIKeyID); ok {
+				label = k.Name
+			}
+		default:
+			key = r.CreatedAt.Format("2006-01-02")
+			label = key
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &models.UsageSummary{Key: key, Label: label}
+			groups[key] = g
+		}
+		g.Generations++
+		g.PromptTokens += r.PromptTokens
+		g.CompletionTokens += r.CompletionTokens
+		g.CostUSD += r.CostUSD
+	}
+
+	out := make([]models.UsageSummary, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if groupBy == "user" || groupBy == "api_key" {
+			return out[i].CostUSD > out[j].CostUSD
+		}
+		return out[i].Key > out[j].Key
+	})
+	return out
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"pitch/models"
+	"pitch/store"
+)
+
+// Durées de validité des sessions et des liens de réinitialisation
+const (
+	SessionDuration       = 30 * 24 * time.Hour
+	PasswordResetDuration = time.Hour
+	MinPasswordLength     = 8
+)
+
+// Erreurs liées aux comptes utilisateurs
+var (
+	ErrEmailTaken         = errors.New("un compte existe déjà avec cet email")
+	ErrInvalidCredentials = errors.New("email ou mot de passe incorrect")
+	ErrInvalidResetToken  = errors.New("lien de réinitialisation invalide ou expiré")
+)
+
+var (
+	// accountMu sérialise la création des comptes : la vérification de l'email (ou du lien SSO)
+	// et l'enregistrement doivent être atomiques pour ne pas créer deux comptes pour la même personne
+	accountMu sync.Mutex
+
+	userStore          = store.New[models.User]("users")
+	sessionStore       = store.New[models.Session]("sessions")
+	passwordResetStore = store.New[models.PasswordReset]("password_resets")
+)
+
+// hashToken retourne l'empreinte d'un jeton aléatoire (sessions, réinitialisations)
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return errors.New("le mot de passe doit contenir au moins 8 caractères")
+	}
+	return nil
+}
+
+// FindUserByEmail retourne l'utilisateur correspondant à l'email
+func FindUserByEmail(email string) (*models.User, bool) {
+	email = normalizeEmail(email)
+	users := userStore.Find(func(u models.User) bool { return u.Email == email })
+	if len(users) == 0 {
+		return nil, false
+	}
+	return &users[0], true
+}
+
+// GetUser retourne l'utilisateur id
+func GetUser(id string) (*models.User, bool) {
+	u, ok := userStore.Get(id)
+	if !ok {
+		return nil, false
+	}
+	return &u, true
+}
+
+// RegisterUser crée un compte avec email et mot de passe
+func RegisterUser(email, name, password string) (*models.User, error) {
+	email = normalizeEmail(email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, errors.New("adresse email invalide")
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	if _, exists := FindUserByEmail(email); exists {
+		return nil, ErrEmailTaken
+	}
+	u := models.User{
+		ID:           store.NewID(),
+		Email:        email,
+		Name:         strings.TrimSpace(name),
+		PasswordHash: hash,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := userStore.Put(u.ID, u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// AuthenticateUser vérifie l'email et le mot de passe
+func AuthenticateUser(email, password string) (*models.User, error) {
+	u, ok := FindUserByEmail(email)
+	if !ok || u.PasswordHash == "" || !CheckPassword(u.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// CreateSession ouvre une session pour l'utilisateur et retourne le jeton du cookie
+func CreateSession(userID string) (string, time.Time, error) {
+	token := store.RandomToken(32)
+	now := time.Now().UTC()
+	s := models.Session{
+		ID:        hashToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(SessionDuration),
+	}
+	if err := sessionStore.Put(s.ID, s); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, s.ExpiresAt, nil
+}
+
+// SessionUser retourne l'utilisateur de la session token, si elle est valide
+func SessionUser(token string) (*models.User, bool) {
+	if token == "" {
+		return nil, false
+	}
+	s, ok := sessionStore.Get(hashToken(token))
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(s.ExpiresAt) {
+		sessionStore.Delete(s.ID)
+		return nil, false
+	}
+	return GetUser(s.UserID)
+}
+
+// DeleteSession ferme la session token (déconnexion)
+func DeleteSession(token string) {
+	if token != "" {
+		sessionStore.Delete(hashToken(token))
+	}
+}
+
+// RequestPasswordReset envoie un lien de réinitialisation si le compte existe.
+// Aucune erreur n'est retournée pour un email inconnu afin de ne pas révéler les comptes existants.
+func RequestPasswordReset(email string, mailer Mailer) error {
+	baseURL, err := PublicBaseURL()
+	if err != nil {
+		return err
+	}
+	u, ok := FindUserByEmail(email)
+	if !ok {
+		return nil
+	}
+
+	token := store.RandomToken(32)
+	reset := models.PasswordReset{
+		ID:        hashToken(token),
+		UserID:    u.ID,
+		ExpiresAt: time.Now().UTC().Add(PasswordResetDuration),
+	}
+	if err := passwordResetStore.Put(reset.ID, reset); err != nil {
+		return err
+	}
+
+	link := baseURL + "/reset-password?token=" + token
+	return mailer.Send(Mail{
+		To:      u.Email,
+		Subject: "Réinitialisation de votre mot de passe Pitch IA",
+		Body: "Bonjour,\n\nPour choisir un nouveau mot de passe, ouvrez ce lien (valable 1 heure) :\n" + link +
+			"\n\nSi vous n'êtes pas à l'origine de cette demande, ignorez cet email.",
+	})
+}
+
+// ResetPassword change le mot de passe à l'aide d'un jeton de réinitialisation et ferme les sessions existantes
+func ResetPassword(token, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	var userID string
+	_, err := passwordResetStore.Update(hashToken(token), func(r *models.PasswordReset) error {
+		if r.UsedAt != nil || time.Now().After(r.ExpiresAt) {
+			return ErrInvalidResetToken
+		}
+		now := time.Now().UTC()
+		r.UsedAt = &now
+		userID = r.UserID
+		return nil
+	})
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if _, err := userStore.Update(userID, func(u *models.User) error {
+		u.PasswordHash = hash
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, s := range sessionStore.Find(func(s models.Session) bool { return s.UserID == userID }) {
+		sessionStore.Delete(s.ID)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pitch/models"
+)
+
+// captureMailer conserve les emails envoyés
+type captureMailer struct {
+	mu   sync.Mutex
+	sent []Mail
+}
+
+func (m *captureMailer) Send(mail Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	const attempts = 8
+	var wg sync.WaitGroup
+	errs := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Même adresse, casse et espaces différents
+			email := " Double@Example.com"
+			if i%2 == 0 {
+				email = "double@example.com "
+			}
+			_, errs[i] = RegisterUser(email, "Double", "motdepasse")
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrEmailTaken):
+			t.Errorf("RegisterUser() = %v, veut nil ou %v", err, ErrEmailTaken)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d comptes créés, veut 1", created)
+	}
+	if n := len(userStore.Find(func(u models.User) bool { return u.Email == "double@example.com" })); n != 1 {
+		t.Errorf("%d comptes enregistrés pour l'email, veut 1", n)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	u, err := RegisterUser("session@example.com", "Session", "motdepasse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := CreateSession(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := SessionUser(token); !ok || got.ID != u.ID {
+		t.Fatalf("SessionUser(jeton valide) = %v, %v", got, ok)
+	}
+	if _, ok := SessionUser(token + "x"); ok {
+		t.Error("jeton modifié accepté")
+	}
+	if _, ok := SessionUser(""); ok {
+		t.Error("jeton vide accepté")
+	}
+
+	sessionStore.Update(hashToken(token), func(s *models.Session) error {
+		s.ExpiresAt = time.Now().Add(-time.Second)
+		return nil
+	})
+	if _, ok := SessionUser(token); ok {
+		t.Error("session expirée acceptée")
+	}
+	if _, ok := sessionStore.Get(hashToken(token)); ok {
+		t.Error("session expirée conservée")
+	}
+
+	token, _, _ = CreateSession(u.ID)
+	DeleteSession(token)
+	if _, ok := SessionUser(token); ok {
+		t.Error("session fermée acceptée")
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	t.Setenv("BASE_URL", "https://pitch.example")
+	u, err := RegisterUser("reset@example.com", "Reset", "ancienmotdepasse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _, _ := CreateSession(u.ID)
+
+	// requestToken demande un lien et retourne son jeton
+	requestToken := func() string {
+		t.Helper()
+		mailer := &captureMailer{}
+		if err := RequestPasswordReset("Reset@Example.com", mailer); err != nil {
+			t.Fatal(err)
+		}
+		if len(mailer.sent) != 1 {
+			t.Fatalf("%d emails envoyés, veut 1", len(mailer.sent))
+		}
+		_, rest, _ := strings.Cut(mailer.sent[0].Body, "/reset-password?token=")
+		token, _, _ := strings.Cut(rest, "\n")
+		return token
+	}
+
+	// Un email inconnu ne révèle rien et n'envoie rien
+	mailer := &captureMailer{}
+	if err := RequestPasswordReset("inconnu@example.com", mailer); err != nil || len(mailer.sent) != 0 {
+		t.Errorf("email inconnu : %v, %d emails", err, len(mailer.sent))
+	}
+
+	expired := requestToken()
+	passwordResetStore.Update(hashToken(expired), func(r *models.PasswordReset) error {
+		r.ExpiresAt = time.Now().Add(-time.Second)
+		return nil
+	})
+	token := requestToken()
+
+	// Mot de passe trop court : refusé avant de consommer le jeton
+	if err := ResetPassword(token, "court"); err == nil {
+		t.Error("ResetPassword() doit refuser un mot de passe trop court")
+	}
+
+	tests := []struct {
+		name, token string
+		err         error
+	}{
+		{name: "jeton expiré", token: expired, err: ErrInvalidResetToken},
+		{name: "jeton inconnu", token: "inconnu", err: ErrInvalidResetToken},
+		{name: "jeton valide", token: token},
+		{name: "jeton déjà utilisé", token: token, err: ErrInvalidResetToken},
+	}
+	for _, tt := range tests {
+		if err := ResetPassword(tt.token, "nouveaumotdepasse"); !errors.Is(err, tt.err) {
+			t.Errorf("%s : ResetPassword() = %v, veut %v", tt.name, err, tt.err)
+		}
+	}
+
+	if _, err := AuthenticateUser("reset@example.com", "nouveaumotdepasse"); err != nil {
+		t.Errorf("connexion avec le nouveau mot de passe : %v", err)
+	}
+	if _, err := AuthenticateUser("reset@example.com", "ancienmotdepasse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("connexion avec l'ancien mot de passe : %v, veut %v", err, ErrInvalidCredentials)
+	}
+	if _, ok := SessionUser(session); ok {
+		t.Error("les sessions ouvertes doivent être fermées après la réinitialisation")
+	}
+}
@@ -0,0 +1,70 @@
+package store
+
+// Scope est une vue restreinte d'une collection : seuls les éléments acceptés par allow y sont visibles.
+// Un élément hors du périmètre se comporte comme un élément inexistant (ErrNotFound), en lecture comme en écriture ;
+// c'est le moyen d'isoler les données d'un locataire (utilisateur, organisation) au niveau du stockage.
+type Scope[T any] struct {
+	c     *Collection[T]
+	allow func(T) bool
+}
+
+// Scope retourne la vue de la collection limitée aux éléments acceptés par allow
+func (c *Collection[T]) Scope(allow func(T) bool) Scope[T] {
+	return Scope[T]{c: c, allow: allow}
+}
+
+// Get retourne l'élément id s'il est dans le périmètre
+func (s Scope[T]) Get(id string) (T, bool) {
+	item, ok := s.c.Get(id)
+	if !ok || !s.allow(item) {
+		var zero T
+		return zero, false
+	}
+	return item, true
+}
+
+// Find retourne les éléments du périmètre pour lesquels match renvoie true (ordre non défini)
+func (s Scope[T]) Find(match func(T) bool) []T {
+	return s.c.Find(func(item T) bool {
+		return s.allow(item) && (match == nil || match(item))
+	})
+}
+
+// Put enregistre l'élément id ; il doit être dans le périmètre, tout comme l'élément qu'il remplace
+func (s Scope[T]) Put(id string, item T) error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	if old, ok := s.c.items[id]; (ok && !s.allow(old)) || !s.allow(item) {
+		return ErrNotFound
+	}
+	s.c.items[id] = item
+	return s.c.save()
+}
+
+// Update applique fn à l'élément id du périmètre ; la modification est refusée si l'élément en sortirait
+func (s Scope[T]) Update(id string, fn func(*T) error) (T, error) {
+	return s.c.Update(id, func(item *T) error {
+		if !s.allow(*item) {
+			return ErrNotFound
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+		if !s.allow(*item) {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// Delete supprime l'élément id s'il est dans le périmètre et indique s'il a été supprimé
+func (s Scope[T]) Delete(id string) (bool, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	item, ok := s.c.items[id]
+	if !ok || !s.allow(item) {
+		return false, nil
+	}
+	delete(s.c.items, id)
+	return true, s.c.save()
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	t.Setenv("DATA_DIR", "")
+	c := New[item]("scope")
+	c.Put("ana", item{Owner: "ana", Value: 1})
+	c.Put("bob", item{Owner: "bob", Value: 1})
+	mine := c.Scope(func(i item) bool { return i.Owner == "ana" })
+
+	tests := []struct {
+		name string
+		op   func() error
+		want error
+	}{
+		{name: "Put d'un élément du périmètre", op: func() error { return mine.Put("ana2", item{Owner: "ana"}) }},
+		{name: "Put d'un élément hors périmètre", want: ErrNotFound, op: func() error { return mine.Put("x", item{Owner: "bob"}) }},
+		{name: "Put qui écrase un élément hors périmètre", want: ErrNotFound, op: func() error { return mine.Put("bob", item{Owner: "ana"}) }},
+		{name: "Update dans le périmètre", op: func() error {
+			_, err := mine.Update("ana", func(i *item) error { i.Value = 2; return nil })
+			return err
+		}},
+		{name: "Update hors périmètre", want: ErrNotFound, op: func() error {
+			_, err := mine.Update("bob", func(i *item) error { i.Value = 3; return nil })
+			return err
+		}},
+		{name: "Update qui sort du périmètre", want: ErrNotFound, op: func() error {
+			_, err := mine.Update("ana", func(i *item) error { i.Owner = "bob"; return nil })
+			return err
+		}},
+		{name: "Update absent", want: ErrNotFound, op: func() error {
+			_, err := mine.Update("inconnu", func(i *item) error { return nil })
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.op(); !errors.Is(err, tt.want) {
+			t.Errorf("%s : %v, veut %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Les écritures refusées n'ont rien modifié
+	if got, _ := c.Get("bob"); got.Owner != "bob" || got.Value != 1 {
+		t.Errorf("bob modifié hors périmètre : %+v", got)
+	}
+	if got, _ := c.Get("ana"); got.Owner != "ana" || got.Value != 2 {
+		t.Errorf("ana = %+v, veut Owner ana et Value 2", got)
+	}
+	if _, ok := c.Get("x"); ok {
+		t.Error("x enregistré hors périmètre")
+	}
+
+	if _, ok := mine.Get("bob"); ok {
+		t.Error("Get hors périmètre visible")
+	}
+	if _, ok := mine.Get("ana"); !ok {
+		t.Error("Get dans le périmètre invisible")
+	}
+	if got := mine.Find(nil); len(got) != 2 {
+		t.Errorf("Find(nil) = %d éléments, veut 2", len(got))
+	}
+	if got := mine.Find(func(i item) bool { return i.Value == 1 }); len(got) != 0 {
+		t.Errorf("Find(Value == 1) = %+v, veut aucun (bob est hors périmètre)", got)
+	}
+	if ok, err := mine.Delete("bob"); ok || err != nil {
+		t.Errorf("Delete hors périmètre = %v, %v", ok, err)
+	}
+	if _, ok := c.Get("bob"); !ok {
+		t.Error("bob supprimé hors périmètre")
+	}
+	if ok, err := mine.Delete("ana"); !ok || err != nil {
+		t.Errorf("Delete dans le périmètre = %v, %v", ok, err)
+	}
+}
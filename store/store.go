@@ -0,0 +1,160 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound est retourné quand un élément n'existe pas dans la collection
+var ErrNotFound = errors.New("élément introuvable")
+
+// Collection est un stockage clé/valeur en mémoire, persisté en JSON dans DATA_DIR si défini.
+// Sans DATA_DIR (par exemple sur Vercel), les données ne vivent que le temps du processus.
+type Collection[T any] struct {
+	mu    sync.RWMutex
+	path  string
+	items map[string]T
+}
+
+// New crée (ou recharge depuis le disque) la collection nommée name
+func New[T any](name string) *Collection[T] {
+	c := &Collection[T]{items: make(map[string]T)}
+
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		return c
+	}
+	c.path = filepath.Join(dir, name+".json")
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("store: lecture de %s impossible: %v", c.path, err)
+		}
+		return c
+	}
+	if err := json.Unmarshal(data, &c.items); err != nil {
+		log.Printf("store: fichier %s invalide: %v", c.path, err)
+		c.items = make(map[string]T)
+	}
+	return c
+}
+
+// NewID génère un identifiant aléatoire de 16 caractères hexadécimaux
+func NewID() string {
+	return RandomToken(8)
+}
+
+// RandomToken retourne n octets aléatoires encodés en hexadécimal
+func RandomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Get retourne l'élément id
+func (c *Collection[T]) Get(id string) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	item, ok := c.items[id]
+	return item, ok
+}
+
+// Put enregistre (ou remplace) l'élément id
+func (c *Collection[T]) Put(id string, item T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[id] = item
+	return c.save()
+}
+
+// Update applique fn à l'élément id de façon atomique.
+// Si fn retourne une erreur, l'élément n'est pas modifié.
+func (c *Collection[T]) Update(id string, fn func(*T) error) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[id]
+	if !ok {
+		var zero T
+		return zero, ErrNotFound
+	}
+	if err := fn(&item); err != nil {
+		return item, err
+	}
+	c.items[id] = item
+	return item, c.save()
+}
+
+// Delete supprime l'élément id et indique s'il existait
+func (c *Collection[T]) Delete(id string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[id]; !ok {
+		return false, nil
+	}
+	delete(c.items, id)
+	return true, c.save()
+}
+
+// DeleteMany supprime les éléments ids en une seule écriture et retourne le nombre d'éléments supprimés
+func (c *Collection[T]) DeleteMany(ids []string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, id := range ids {
+		if _, ok := c.items[id]; ok {
+			delete(c.items, id)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, c.save()
+}
+
+// Find retourne les éléments pour lesquels match renvoie true (ordre non défini)
+func (c *Collection[T]) Find(match func(T) bool) []T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []T
+	for _, item := range c.items {
+		if match == nil || match(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// List retourne tous les éléments (ordre non défini)
+func (c *Collection[T]) List() []T {
+	return c.Find(nil)
+}
+
+// save écrit la collection sur le disque (appelé avec le verrou pris)
+func (c *Collection[T]) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	// Écriture atomique via un fichier temporaire
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type item struct {
+	Owner string `json:"owner"`
+	Value int    `json:"value"`
+}
+
+func TestCollectionPersistence(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DATA_DIR", dir)
+
+	c := New[item]("items")
+	if err := c.Put("a", item{Owner: "ana", Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Update("a", func(i *item) error { i.Value = 2; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Update("absent", func(i *item) error { return nil }); err != ErrNotFound {
+		t.Errorf("Update d'un élément absent : %v, veut ErrNotFound", err)
+	}
+
+	reloaded := New[item]("items")
+	if got, ok := reloaded.Get("a"); !ok || got.Value != 2 {
+		t.Errorf("après rechargement : %+v, %v", got, ok)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "items.json")); err != nil {
+		t.Errorf("fichier non écrit : %v", err)
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DATA_DIR", dir)
+
+	c := New[item]("many")
+	for _, id := range []string{"a", "b", "c"} {
+		c.Put(id, item{Owner: id})
+	}
+	n, err := c.DeleteMany([]string{"a", "c", "inconnu"})
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteMany() = %d, %v ; veut 2, nil", n, err)
+	}
+	if got := New[item]("many").List(); len(got) != 1 || got[0].Owner != "b" {
+		t.Errorf("après rechargement : %+v, veut seulement b", got)
+	}
+	if n, err := c.DeleteMany(nil); n != 0 || err != nil {
+		t.Errorf("DeleteMany(nil) = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,388 @@
+// Package websocket implémente le côté serveur du protocole WebSocket (RFC 6455) avec la bibliothèque standard :
+// poignée de main HTTP, trames masquées du client, fragmentation, ping/pong et fermeture.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Types de message (opcodes RFC 6455)
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+
+	opContinuation = 0
+	opClose        = 8
+	opPing         = 9
+	opPong         = 10
+)
+
+// Codes de fermeture
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+
+	// Codes réservés qui ne doivent jamais circuler dans une trame de fermeture
+	CloseNoStatus     = 1005 // fermeture reçue sans code
+	CloseAbnormal     = 1006 // connexion coupée sans trame de fermeture
+	CloseTLSHandshake = 1015 // échec de la négociation TLS
+)
+
+// acceptGUID est concaténé à Sec-WebSocket-Key pour calculer Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DefaultReadLimit est la taille maximale par défaut d'un message reçu
+const DefaultReadLimit = 64 << 10
+
+// Erreurs de la connexion
+var (
+	ErrBadHandshake  = errors.New("websocket: poignée de main invalide")
+	ErrBadOrigin     = errors.New("websocket: origine non autorisée")
+	ErrMessageTooBig = errors.New("websocket: message trop volumineux")
+	ErrProtocol      = errors.New("websocket: erreur de protocole")
+)
+
+// CloseError est retournée par ReadMessage quand le client ferme la connexion
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return strings.TrimSpace("websocket: connexion fermée " + strconv.Itoa(e.Code) + " " + e.Reason)
+}
+
+// Conn est une connexion WebSocket côté serveur.
+// ReadMessage doit être appelée par une seule goroutine ; les écritures peuvent être concurrentes.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	wmu    sync.Mutex
+	closed bool
+
+	readLimit   int64
+	idleTimeout time.Duration
+}
+
+// Upgrade bascule la requête HTTP en connexion WebSocket.
+// Les requêtes venant d'une autre origine que l'hôte servi sont refusées (détournement de WebSocket inter-sites).
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "WebSocket attendu", http.StatusUpgradeRequired)
+		return nil, ErrBadHandshake
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		http.Error(w, "Sec-WebSocket-Key invalide", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "Origine non autorisée", http.StatusForbidden)
+		return nil, ErrBadOrigin
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket non supporté", http.StatusInternalServerError)
+		return nil, ErrBadHandshake
+	}
+	netConn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	if brw.Reader.Buffered() > 0 {
+		// Le client ne doit rien envoyer avant la réponse à la poignée de main
+		netConn.Close()
+		return nil, ErrBadHandshake
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	netConn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := netConn.Write([]byte(resp)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetWriteDeadline(time.Time{})
+
+	return &Conn{conn: netConn, br: brw.Reader, readLimit: DefaultReadLimit}, nil
+}
+
+// acceptKey calcule Sec-WebSocket-Accept à partir de Sec-WebSocket-Key
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains indique si l'en-tête name contient le jeton token (liste séparée par des virgules)
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin indique si l'en-tête Origin (s'il est présent) désigne l'hôte de la requête
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// SetReadLimit fixe la taille maximale d'un message reçu ; au-delà, la connexion est fermée (1009)
+func (c *Conn) SetReadLimit(n int64) {
+	c.readLimit = n
+}
+
+// SetIdleTimeout ferme la connexion si aucune trame (pong compris) n'est reçue pendant d ; 0 = jamais
+func (c *Conn) SetIdleTimeout(d time.Duration) {
+	c.idleTimeout = d
+}
+
+// ReadMessage lit le prochain message texte ou binaire. Les pings reçoivent un pong, les pongs sont ignorés ;
+// une trame de fermeture est renvoyée au client et ReadMessage retourne alors une *CloseError.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		msgType int
+		msg     []byte
+	)
+	for {
+		if c.idleTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+		}
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			if errors.Is(err, ErrMessageTooBig) {
+				c.CloseWith(CloseMessageTooBig, "")
+			} else if errors.Is(err, ErrProtocol) {
+				c.CloseWith(CloseProtocolError, "")
+			}
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := &CloseError{Code: CloseNoStatus}
+			switch {
+			case len(payload) == 1:
+				c.CloseWith(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			case len(payload) >= 2:
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Reason = string(payload[2:])
+				if !validCloseCode(ce.Code) {
+					// Code réservé (1005, 1006, 1015…) ou hors des plages définies : ne pas le renvoyer
+					c.CloseWith(CloseProtocolError, "")
+					return 0, nil, ErrProtocol
+				}
+				if !utf8.ValidString(ce.Reason) {
+					c.CloseWith(CloseInvalidPayload, "")
+					return 0, nil, ErrProtocol
+				}
+			}
+			// Renvoyer le code du client, ou une fermeture sans code s'il n'en a pas donné
+			c.CloseWith(ce.Code, "")
+			return 0, nil, ce
+		case opContinuation:
+			if msgType == 0 {
+				c.CloseWith(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			}
+		case TextMessage, BinaryMessage:
+			if msgType != 0 {
+				c.CloseWith(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			}
+			msgType = op
+		default:
+			c.CloseWith(CloseProtocolError, "")
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(msg)+len(payload)) > c.readLimit {
+			c.CloseWith(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if msgType == TextMessage && !utf8.Valid(msg) {
+				c.CloseWith(CloseInvalidPayload, "")
+				return 0, nil, ErrProtocol
+			}
+			return msgType, msg, nil
+		}
+	}
+}
+
+// readFrame lit une trame et démasque sa charge utile
+func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var h [2]byte
+	if _, err = io.ReadFull(c.br, h[:]); err != nil {
+		return
+	}
+	fin = h[0]&0x80 != 0
+	op = int(h[0] & 0x0f)
+	if h[0]&0x70 != 0 || h[1]&0x80 == 0 {
+		// Bits réservés sans extension négociée, ou trame client non masquée
+		return false, 0, nil, ErrProtocol
+	}
+
+	n := int64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		u := binary.BigEndian.Uint64(ext[:])
+		if u > 1<<62 {
+			return false, 0, nil, ErrProtocol
+		}
+		n = int64(u)
+	}
+	if op >= opClose && (!fin || n > 125) {
+		return false, 0, nil, ErrProtocol
+	}
+	if n > c.readLimit {
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// WriteMessage envoie un message texte ou binaire en une seule trame
+func (c *Conn) WriteMessage(msgType int, data []byte) error {
+	if msgType != TextMessage && msgType != BinaryMessage {
+		return ErrProtocol
+	}
+	return c.writeFrame(msgType, data)
+}
+
+// Ping envoie un ping ; le client répond par un pong qui prolonge le délai d'inactivité
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// writeFrame écrit une trame finale non masquée (les trames du serveur ne sont jamais masquées)
+func (c *Conn) writeFrame(op int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | byte(op)
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	if op == opClose {
+		c.closed = true
+	}
+	return nil
+}
+
+// validCloseCode indique si un code peut figurer dans une trame de fermeture (RFC 6455 §7.4) :
+// codes définis par le protocole hors codes réservés, puis plages des bibliothèques et des applications
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// CloseWith envoie une trame de fermeture (code et motif) puis ferme la connexion.
+// Un code qui ne peut pas circuler sur le réseau (CloseNoStatus, CloseAbnormal…) donne une trame sans code.
+func (c *Conn) CloseWith(code int, reason string) error {
+	var payload []byte
+	if validCloseCode(code) {
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+	} else {
+		reason = ""
+	}
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload = append(payload, reason...)
+	c.writeFrame(opClose, payload)
+	return c.conn.Close()
+}
+
+// Close ferme normalement la connexion
+func (c *Conn) Close() error {
+	return c.CloseWith(CloseNormal, "")
+}
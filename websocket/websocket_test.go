@@ -0,0 +1,326 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer renvoie chaque message reçu ; limit fixe la taille maximale d'un message
+func echoServer(t *testing.T, limit int64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		if limit > 0 {
+			c.SetReadLimit(limit)
+		}
+		for {
+			op, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(op, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// client est un client WebSocket minimal qui écrit des trames brutes
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dial ouvre une connexion et fait la poignée de main avec la clé d'exemple de la RFC 6455
+func dial(t *testing.T, srv *httptest.Server) *client {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: "+srv.Listener.Addr().String()+"\r\n"+
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("poignée de main : statut %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	return &client{t: t, conn: conn, br: br}
+}
+
+// send écrit une trame ; masked=false produit une trame client invalide
+func (c *client) send(fin bool, op int, payload []byte, masked bool) {
+	c.t.Helper()
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0, 0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame[1] = byte(n)
+	case n <= 0xffff:
+		frame[1] = 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] = 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	data := append([]byte(nil), payload...)
+	if masked {
+		frame[1] |= 0x80
+		mask := []byte{0x12, 0x34, 0x56, 0x78}
+		frame = append(frame, mask...)
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	if _, err := c.conn.Write(append(frame, data...)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read lit une trame du serveur (jamais masquée, toujours finale)
+func (c *client) read() (int, []byte) {
+	c.t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		c.t.Fatalf("lecture d'une trame : %v", err)
+	}
+	if h[0]&0x80 == 0 || h[1]&0x80 != 0 {
+		c.t.Fatalf("trame serveur non finale ou masquée : %x", h)
+	}
+	n := int(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		n = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatal(err)
+	}
+	return int(h[0] & 0x0f), payload
+}
+
+// expectClose lit la trame de fermeture du serveur et vérifie son code (0 = fermeture sans code)
+func (c *client) expectClose(code int) {
+	c.t.Helper()
+	op, payload := c.read()
+	if op != opClose {
+		c.t.Fatalf("opcode %d, veut une fermeture", op)
+	}
+	got := 0
+	if len(payload) >= 2 {
+		got = int(binary.BigEndian.Uint16(payload))
+	} else if len(payload) == 1 {
+		c.t.Fatal("trame de fermeture d'un octet")
+	}
+	if got != code {
+		c.t.Fatalf("code de fermeture %d, veut %d", got, code)
+	}
+}
+
+func closePayload(code int, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+func TestEcho(t *testing.T) {
+	srv := echoServer(t, 1<<20)
+	c := dial(t, srv)
+	for _, size := range []int{0, 5, 125, 126, 300, 70000} {
+		msg := []byte(strings.Repeat("é", size/2) + strings.Repeat("a", size%2))
+		c.send(true, TextMessage, msg, true)
+		if op, got := c.read(); op != TextMessage || string(got) != string(msg) {
+			t.Fatalf("écho de %d octets : opcode %d, %d octets reçus", len(msg), op, len(got))
+		}
+	}
+	c.send(true, BinaryMessage, []byte{0, 0xff}, true)
+	if op, got := c.read(); op != BinaryMessage || len(got) != 2 {
+		t.Fatalf("écho binaire : opcode %d, %x", op, got)
+	}
+}
+
+func TestFragmentation(t *testing.T) {
+	srv := echoServer(t, 0)
+	c := dial(t, srv)
+
+	// Un ping intercalé entre deux fragments reçoit son pong sans couper le message
+	c.send(false, TextMessage, []byte("Bon"), true)
+	c.send(true, opPing, []byte("p"), true)
+	c.send(false, opContinuation, []byte("jo"), true)
+	c.send(true, opContinuation, []byte("ur"), true)
+
+	if op, payload := c.read(); op != opPong || string(payload) != "p" {
+		t.Fatalf("réponse au ping : opcode %d, %q", op, payload)
+	}
+	if op, msg := c.read(); op != TextMessage || string(msg) != "Bonjour" {
+		t.Fatalf("message réassemblé : opcode %d, %q", op, msg)
+	}
+
+	// Un caractère UTF-8 coupé entre deux fragments reste valide une fois réassemblé
+	e := []byte("é")
+	c.send(false, TextMessage, e[:1], true)
+	c.send(true, opContinuation, e[1:], true)
+	if _, msg := c.read(); string(msg) != "é" {
+		t.Fatalf("message réassemblé : %q", msg)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int64
+		send  func(c *client)
+		code  int
+	}{
+		{name: "trame non masquée", code: CloseProtocolError, send: func(c *client) {
+			c.send(true, TextMessage, []byte("x"), false)
+		}},
+		{name: "continuation sans début", code: CloseProtocolError, send: func(c *client) {
+			c.send(true, opContinuation, []byte("x"), true)
+		}},
+		{name: "nouveau message au milieu d'un message fragmenté", code: CloseProtocolError, send: func(c *client) {
+			c.send(false, TextMessage, []byte("a"), true)
+			c.send(true, TextMessage, []byte("b"), true)
+		}},
+		{name: "trame de contrôle fragmentée", code: CloseProtocolError, send: func(c *client) {
+			c.send(false, opPing, nil, true)
+		}},
+		{name: "trame de contrôle trop longue", code: CloseProtocolError, send: func(c *client) {
+			c.send(true, opPing, make([]byte, 126), true)
+		}},
+		{name: "opcode inconnu", code: CloseProtocolError, send: func(c *client) {
+			c.send(true, 3, nil, true)
+		}},
+		{name: "bits réservés", code: CloseProtocolError, send: func(c *client) {
+			c.conn.Write([]byte{0x80 | 0x40 | TextMessage, 0x80, 0, 0, 0, 0})
+		}},
+		{name: "texte UTF-8 invalide", code: CloseInvalidPayload, send: func(c *client) {
+			c.send(true, TextMessage, []byte{0xff, 0xfe}, true)
+		}},
+		{name: "trame trop volumineuse", limit: 10, code: CloseMessageTooBig, send: func(c *client) {
+			c.send(true, TextMessage, make([]byte, 11), true)
+		}},
+		{name: "message fragmenté trop volumineux", limit: 10, code: CloseMessageTooBig, send: func(c *client) {
+			c.send(false, BinaryMessage, make([]byte, 6), true)
+			c.send(true, opContinuation, make([]byte, 6), true)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, echoServer(t, tt.limit))
+			tt.send(c)
+			c.expectClose(tt.code)
+		})
+	}
+}
+
+func TestCloseCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    int // code renvoyé par le serveur, 0 = fermeture sans code
+	}{
+		{name: "normale", payload: closePayload(CloseNormal, "au revoir"), want: CloseNormal},
+		{name: "départ", payload: closePayload(CloseGoingAway, ""), want: CloseGoingAway},
+		{name: "sans code", payload: nil, want: 0},
+		{name: "code d'application", payload: closePayload(4000, ""), want: 4000},
+		{name: "code de bibliothèque", payload: closePayload(3000, ""), want: 3000},
+		{name: "un seul octet", payload: []byte{0x03}, want: CloseProtocolError},
+		{name: "1005 réservé", payload: closePayload(CloseNoStatus, ""), want: CloseProtocolError},
+		{name: "1006 réservé", payload: closePayload(CloseAbnormal, ""), want: CloseProtocolError},
+		{name: "1015 réservé", payload: closePayload(CloseTLSHandshake, ""), want: CloseProtocolError},
+		{name: "1004 réservé", payload: closePayload(1004, ""), want: CloseProtocolError},
+		{name: "sous 1000", payload: closePayload(999, ""), want: CloseProtocolError},
+		{name: "plage non attribuée", payload: closePayload(2000, ""), want: CloseProtocolError},
+		{name: "au-delà de 4999", payload: closePayload(5000, ""), want: CloseProtocolError},
+		{name: "motif UTF-8 invalide", payload: closePayload(CloseNormal, "\xff"), want: CloseInvalidPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, echoServer(t, 0))
+			c.send(true, opClose, tt.payload, true)
+			c.expectClose(tt.want)
+			// Le serveur ferme la connexion TCP après la trame de fermeture
+			if _, err := c.br.ReadByte(); err != io.EOF {
+				t.Errorf("connexion encore ouverte après la fermeture : %v", err)
+			}
+		})
+	}
+}
+
+func TestCloseWithReservedCode(t *testing.T) {
+	for _, code := range []int{CloseNoStatus, CloseAbnormal, CloseTLSHandshake} {
+		server, peer := net.Pipe()
+		c := &Conn{conn: server, br: bufio.NewReader(server), readLimit: DefaultReadLimit}
+		go c.CloseWith(code, "motif")
+		frame := make([]byte, 16)
+		n, _ := peer.Read(frame)
+		if n != 2 || frame[0] != 0x80|opClose || frame[1] != 0 {
+			t.Errorf("CloseWith(%d) a écrit %x, veut une fermeture sans code", code, frame[:n])
+		}
+		peer.Close()
+	}
+}
+
+func TestUpgradeRejections(t *testing.T) {
+	srv := echoServer(t, 0)
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{name: "requête HTTP ordinaire", header: map[string]string{}, status: http.StatusUpgradeRequired},
+		{name: "mauvaise version", header: map[string]string{"Sec-WebSocket-Version": "8"}, status: http.StatusUpgradeRequired},
+		{name: "clé invalide", header: map[string]string{"Sec-WebSocket-Key": "court"}, status: http.StatusBadRequest},
+		{name: "autre origine", header: map[string]string{"Origin": "http://evil.example"}, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if len(tt.header) > 0 {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		}
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s : statut %d, veut %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+}